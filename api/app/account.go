@@ -193,6 +193,14 @@ func (rs *AccountResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 
 	passwordHasChanged := data.Account.PlainPassword != ""
 
+	if passwordHasChanged {
+		if err := ValidateNewPassword(data.Account.PlainPassword,
+			user.FirstName, user.LastName, user.Email); err != nil {
+			render.Render(w, r, ErrBadRequestWithDetails(err))
+			return
+		}
+	}
+
 	// make sure email is valid
 	if emailHasChanged {
 		// we will ask the user to confirm their email address
@@ -209,6 +217,15 @@ func (rs *AccountResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// other logins and tokens made with the old password end, the current
+	// login stays
+	if passwordHasChanged {
+		if err := RevokeCredentials(rs.Stores, user.ID, accessClaims.SessionID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
 	// make sure email is valid
 	if emailHasChanged {
 		err = sendConfirmEmailForUser(configuration.Configuration.Server.Email.From, user)
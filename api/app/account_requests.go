@@ -26,8 +26,6 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
-	"github.com/infomark-org/infomark/auth"
-	"github.com/infomark-org/infomark/configuration"
 )
 
 // -----------------------------------------------------------------------------
@@ -108,17 +106,6 @@ func (body *CreateUserAccountRequest) Bind(r *http.Request) (err error) {
 		return errors.New("missing \"account\" data")
 	}
 
-	// check password length
-	if len(body.Account.PlainPassword) < configuration.Configuration.Server.Authentication.Password.MinLength {
-		return errors.New("password too short")
-	}
-
-	// encrypt password
-	body.Account.EncryptedPassword, err = auth.HashPassword(body.Account.PlainPassword)
-	if err != nil {
-		return err
-	}
-
 	body.User.Email = strings.TrimSpace(body.User.Email)
 	body.User.Email = strings.ToLower(body.User.Email)
 
@@ -129,6 +116,18 @@ func (body *CreateUserAccountRequest) Bind(r *http.Request) (err error) {
 		return errors.New("email from user does not match email from account")
 	}
 
+	// check password policy
+	if err := ValidateNewPassword(body.Account.PlainPassword,
+		body.User.FirstName, body.User.LastName, body.User.Email); err != nil {
+		return err
+	}
+
+	// encrypt password
+	body.Account.EncryptedPassword, err = HashPassword(body.Account.PlainPassword)
+	if err != nil {
+		return err
+	}
+
 	return body.Validate()
 }
 
@@ -155,11 +154,12 @@ func (body *AccountRequest) Bind(r *http.Request) error {
 
 	// encrypt new password, when given
 	if body.Account.PlainPassword != "" {
-		// check password length
-		if len(body.Account.PlainPassword) < configuration.Configuration.Server.Authentication.Password.MinLength {
-			return errors.New("password too short")
+		// check password policy, the current name and email of the user are
+		// checked by the handler
+		if err := ValidateNewPassword(body.Account.PlainPassword, body.Account.Email); err != nil {
+			return err
 		}
-		hash, err := auth.HashPassword(body.Account.PlainPassword)
+		hash, err := HashPassword(body.Account.PlainPassword)
 		body.Account.EncryptedPassword = hash
 		return err
 	}
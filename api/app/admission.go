@@ -0,0 +1,282 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+)
+
+// Rules of the admission to the exams of a course.
+const (
+	AdmissionRulePercentage    = "percentage"
+	AdmissionRuleSheet         = "sheet"
+	AdmissionRuleAttendance    = "attendance"
+	AdmissionRulePresentations = "presentations"
+	AdmissionRuleExam          = "exam"
+)
+
+// AdmissionSheet is a sheet with its max points and its own requirement.
+type AdmissionSheet struct {
+	ID                 int64
+	Name               string
+	MaxPoints          int
+	RequiredPercentage int
+}
+
+// AdmissionRules are the requirements of a course for the admission to its
+// exams. The sheets with the lowest percentage are dropped before the overall
+// percentage and the per-sheet minimums are evaluated.
+type AdmissionRules struct {
+	Sheets                []AdmissionSheet
+	RequiredPercentage    int
+	DroppableSheets       int
+	RequiredAttendance    int
+	RequiredPresentations int
+	PrerequisiteExams     []model.Exam
+}
+
+// AdmissionFacts are the achievements of a single student.
+type AdmissionFacts struct {
+	// SheetPoints maps sheets to the acquired points.
+	SheetPoints map[int64]int
+	// Attendance is the percentage of attended sessions.
+	Attendance    float64
+	Presentations int
+	// ExamStatus maps exams to the status of the student (see UserExam).
+	ExamStatus map[int64]int
+}
+
+// AdmissionReason tells whether a single rule is met.
+type AdmissionReason struct {
+	Rule    string `json:"rule" example:"percentage"`
+	Met     bool   `json:"met" example:"false"`
+	Message string `json:"message" example:"47.5% of the points, 50% are required"`
+}
+
+// AdmissionStatus is the evaluation of all rules for a student.
+type AdmissionStatus struct {
+	Admitted      bool
+	Percentage    float64
+	DroppedSheets []int64
+	Reasons       []AdmissionReason
+}
+
+func percentageOf(acquired int, max int) float64 {
+	if max == 0 {
+		return 100
+	}
+	return 100 * float64(acquired) / float64(max)
+}
+
+// EvaluateAdmission checks all rules against the achievements of a student.
+// Only rules which are in effect are reported.
+func EvaluateAdmission(rules AdmissionRules, facts AdmissionFacts) AdmissionStatus {
+	status := AdmissionStatus{
+		Admitted:      true,
+		DroppedSheets: []int64{},
+		Reasons:       []AdmissionReason{},
+	}
+
+	report := func(rule string, met bool, format string, args ...interface{}) {
+		status.Admitted = status.Admitted && met
+		status.Reasons = append(status.Reasons, AdmissionReason{
+			Rule:    rule,
+			Met:     met,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// drop the sheets with the lowest percentage, ties by their order
+	sheets := append([]AdmissionSheet{}, rules.Sheets...)
+	sort.SliceStable(sheets, func(i, j int) bool {
+		return percentageOf(facts.SheetPoints[sheets[i].ID], sheets[i].MaxPoints) <
+			percentageOf(facts.SheetPoints[sheets[j].ID], sheets[j].MaxPoints)
+	})
+	drop := rules.DroppableSheets
+	if drop > len(sheets) {
+		drop = len(sheets)
+	}
+	for _, sheet := range sheets[:drop] {
+		status.DroppedSheets = append(status.DroppedSheets, sheet.ID)
+	}
+	dropped := make(map[int64]bool)
+	for _, sheetID := range status.DroppedSheets {
+		dropped[sheetID] = true
+	}
+
+	acquired, max := 0, 0
+	for _, sheet := range rules.Sheets {
+		if dropped[sheet.ID] {
+			continue
+		}
+		acquired += facts.SheetPoints[sheet.ID]
+		max += sheet.MaxPoints
+	}
+	status.Percentage = percentageOf(acquired, max)
+
+	if rules.RequiredPercentage > 0 {
+		report(AdmissionRulePercentage, status.Percentage >= float64(rules.RequiredPercentage),
+			"%.1f%% of the points, %d%% are required", status.Percentage, rules.RequiredPercentage)
+	}
+
+	for _, sheet := range rules.Sheets {
+		if sheet.RequiredPercentage == 0 || dropped[sheet.ID] {
+			continue
+		}
+		percentage := percentageOf(facts.SheetPoints[sheet.ID], sheet.MaxPoints)
+		report(AdmissionRuleSheet, percentage >= float64(sheet.RequiredPercentage),
+			"%.1f%% of the points in %s, %d%% are required", percentage, sheet.Name, sheet.RequiredPercentage)
+	}
+
+	if rules.RequiredAttendance > 0 {
+		report(AdmissionRuleAttendance, facts.Attendance >= float64(rules.RequiredAttendance),
+			"attended %.1f%% of the sessions, %d%% are required", facts.Attendance, rules.RequiredAttendance)
+	}
+
+	if rules.RequiredPresentations > 0 {
+		report(AdmissionRulePresentations, facts.Presentations >= rules.RequiredPresentations,
+			"presented %d times, %d are required", facts.Presentations, rules.RequiredPresentations)
+	}
+
+	for _, exam := range rules.PrerequisiteExams {
+		report(AdmissionRuleExam, facts.ExamStatus[exam.ID] == 2,
+			"%s has to be passed", exam.Name)
+	}
+
+	return status
+}
+
+// AdmissionEvaluator holds the rules of a course and the achievements of all
+// its students to evaluate their admission.
+type AdmissionEvaluator struct {
+	Rules AdmissionRules
+
+	points        map[int64]map[int64]int
+	attendances   map[int64]float64
+	presentations map[int64]int
+	examStatus    map[int64]map[int64]int
+}
+
+// NewAdmissionEvaluator loads the rules of a course and the achievements of
+// its students. Attendance counts the sessions held until now.
+func NewAdmissionEvaluator(stores *Stores, course *model.Course, now time.Time) (*AdmissionEvaluator, error) {
+	e := &AdmissionEvaluator{
+		Rules: AdmissionRules{
+			Sheets:                []AdmissionSheet{},
+			RequiredPercentage:    course.RequiredPercentage,
+			DroppableSheets:       course.DroppableSheets,
+			RequiredAttendance:    course.RequiredAttendance,
+			RequiredPresentations: course.RequiredPresentations,
+			PrerequisiteExams:     []model.Exam{},
+		},
+		points:     make(map[int64]map[int64]int),
+		examStatus: make(map[int64]map[int64]int),
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sheet := range sheets {
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		maxPoints := 0
+		for _, task := range tasks {
+			maxPoints += task.MaxPoints
+		}
+		e.Rules.Sheets = append(e.Rules.Sheets, AdmissionSheet{
+			ID:                 sheet.ID,
+			Name:               sheet.Name,
+			MaxPoints:          maxPoints,
+			RequiredPercentage: sheet.RequiredPercentage,
+		})
+	}
+
+	points, err := stores.Course.PointsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range points {
+		if _, exists := e.points[entry.UserID]; !exists {
+			e.points[entry.UserID] = make(map[int64]int)
+		}
+		e.points[entry.UserID][entry.SheetID] = entry.AcquiredPoints
+	}
+
+	e.attendances, err = GetAttendancePercentages(stores, course.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	e.presentations, err = getPresentationCounts(stores, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	exams, err := stores.Exam.ExamsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, exam := range exams {
+		if !exam.AdmissionPrerequisite {
+			continue
+		}
+		e.Rules.PrerequisiteExams = append(e.Rules.PrerequisiteExams, exam)
+
+		enrollments, err := stores.Exam.GetEnrollmentsInCourseOfExam(course.ID, exam.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, enrollment := range enrollments {
+			if _, exists := e.examStatus[enrollment.UserID]; !exists {
+				e.examStatus[enrollment.UserID] = make(map[int64]int)
+			}
+			e.examStatus[enrollment.UserID][exam.ID] = enrollment.Status
+		}
+	}
+
+	return e, nil
+}
+
+// Facts returns the achievements of a student. Students without a group have
+// no sessions to attend.
+func (e *AdmissionEvaluator) Facts(userID int64) AdmissionFacts {
+	attendance, exists := e.attendances[userID]
+	if !exists {
+		attendance = 100
+	}
+	return AdmissionFacts{
+		SheetPoints:   e.points[userID],
+		Attendance:    attendance,
+		Presentations: e.presentations[userID],
+		ExamStatus:    e.examStatus[userID],
+	}
+}
+
+// Evaluate checks the admission of a student.
+func (e *AdmissionEvaluator) Evaluate(userID int64) AdmissionStatus {
+	return EvaluateAdmission(e.Rules, e.Facts(userID))
+}
@@ -0,0 +1,166 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+)
+
+func TestAdmission(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	tutorJWT := tape.NewJWTRequest(2, false)
+
+	rules := AdmissionRules{
+		Sheets: []AdmissionSheet{
+			{ID: 1, Name: "Sheet 1", MaxPoints: 10},
+			{ID: 2, Name: "Sheet 2", MaxPoints: 10},
+			{ID: 3, Name: "Sheet 3", MaxPoints: 10},
+		},
+	}
+
+	g.Describe("Admission", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+		})
+
+		g.It("Should admit without any rules", func() {
+			status := EvaluateAdmission(AdmissionRules{}, AdmissionFacts{})
+			g.Assert(status.Admitted).Equal(true)
+			g.Assert(len(status.Reasons)).Equal(0)
+		})
+
+		g.It("Should drop the sheets with the lowest percentage", func() {
+			r := rules
+			r.RequiredPercentage = 50
+			facts := AdmissionFacts{SheetPoints: map[int64]int{1: 8, 2: 0, 3: 4}}
+
+			status := EvaluateAdmission(r, facts)
+			g.Assert(status.Admitted).Equal(false)
+			g.Assert(status.Percentage > 39.9 && status.Percentage < 40.1).IsTrue()
+
+			r.DroppableSheets = 1
+			status = EvaluateAdmission(r, facts)
+			g.Assert(status.Admitted).Equal(true)
+			g.Assert(status.DroppedSheets).Equal([]int64{2})
+			g.Assert(status.Percentage).Equal(60.0)
+		})
+
+		g.It("Should require the minimum of single sheets unless dropped", func() {
+			r := AdmissionRules{Sheets: append([]AdmissionSheet{}, rules.Sheets...)}
+			r.Sheets[1].RequiredPercentage = 50
+			facts := AdmissionFacts{SheetPoints: map[int64]int{1: 10, 2: 2, 3: 10}}
+
+			status := EvaluateAdmission(r, facts)
+			g.Assert(status.Admitted).Equal(false)
+			g.Assert(status.Reasons[0].Rule).Equal(AdmissionRuleSheet)
+
+			r.DroppableSheets = 1
+			status = EvaluateAdmission(r, facts)
+			g.Assert(status.Admitted).Equal(true)
+		})
+
+		g.It("Should require attendance, presentations and exams", func() {
+			r := AdmissionRules{
+				RequiredAttendance:    80,
+				RequiredPresentations: 2,
+				PrerequisiteExams:     []model.Exam{{ID: 7, Name: "Midterm"}},
+			}
+
+			status := EvaluateAdmission(r, AdmissionFacts{
+				Attendance:    75,
+				Presentations: 2,
+				ExamStatus:    map[int64]int{7: 2},
+			})
+			g.Assert(status.Admitted).Equal(false)
+			g.Assert(len(status.Reasons)).Equal(3)
+			g.Assert(status.Reasons[0].Rule).Equal(AdmissionRuleAttendance)
+			g.Assert(status.Reasons[0].Met).Equal(false)
+
+			status = EvaluateAdmission(r, AdmissionFacts{
+				Attendance:    80,
+				Presentations: 2,
+				ExamStatus:    map[int64]int{7: 1},
+			})
+			g.Assert(status.Admitted).Equal(false)
+			g.Assert(status.Reasons[2].Rule).Equal(AdmissionRuleExam)
+
+			status = EvaluateAdmission(r, AdmissionFacts{
+				Attendance:    100,
+				Presentations: 3,
+				ExamStatus:    map[int64]int{7: 2},
+			})
+			g.Assert(status.Admitted).Equal(true)
+		})
+
+		g.It("Should list the admission of all students", func() {
+			w := tape.Get("/api/v1/courses/1/admissions", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			_, err := tape.DB.Exec("UPDATE courses SET required_percentage = 0 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/courses/1/admissions", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			admissions := []AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&admissions)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(admissions) > 0).IsTrue()
+			for _, admission := range admissions {
+				g.Assert(admission.Admitted).Equal(true)
+			}
+		})
+
+		g.It("Students should see their own admission", func() {
+			_, err := tape.DB.Exec("UPDATE courses SET required_percentage = 100, required_presentations = 1 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec("DELETE FROM presentations WHERE user_id = 112;")
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1/admissions/own", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			admission := AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&admission)
+			g.Assert(err).Equal(nil)
+			g.Assert(admission.UserID).Equal(int64(112))
+			g.Assert(admission.Admitted).Equal(false)
+			g.Assert(admission.Reasons[len(admission.Reasons)-1].Rule).Equal(AdmissionRulePresentations)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
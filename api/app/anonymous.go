@@ -0,0 +1,54 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/model"
+)
+
+// Pseudonym returns a stable name for a student in a course which does not
+// reveal the identity. It is derived from the server secret such that only
+// course admins (who see the real identity next to it) can link both.
+func Pseudonym(courseID int64, userID int64) string {
+	mac := hmac.New(sha256.New, []byte(configuration.Configuration.Server.Authentication.JWT.Secret))
+	fmt.Fprintf(mac, "%d/%d", courseID, userID)
+	return fmt.Sprintf("anonymous-%s", hex.EncodeToString(mac.Sum(nil))[:10])
+}
+
+// HidesIdentity decides whether the identity of students in an anonymous sheet
+// must be hidden. Only course admins can see who is behind a pseudonym.
+func HidesIdentity(givenRole authorize.CourseRole, anonymous bool) bool {
+	return anonymous && givenRole < authorize.ADMIN
+}
+
+// anonymizeGrade replaces the student of a grade by a pseudonym.
+func anonymizeGrade(p *model.Grade, courseID int64) {
+	p.UserFirstName = Pseudonym(courseID, p.UserID)
+	p.UserLastName = ""
+	p.UserEmail = ""
+	p.UserID = 0
+}
@@ -20,6 +20,8 @@
 package app
 
 import (
+	"time"
+
 	"github.com/alexedwards/scs"
 	"github.com/infomark-org/infomark/auth/authenticate"
 	"github.com/infomark-org/infomark/auth/authorize"
@@ -33,10 +35,18 @@ import (
 type UserStore interface {
 	Get(userID int64) (*model.User, error)
 	Update(p *model.User) error
+	UpdatePasswordHash(userID int64, encryptedPassword string) error
 	GetAll() ([]model.User, error)
 	Create(p *model.User) (*model.User, error)
 	Delete(userID int64) error
 	FindByEmail(email string) (*model.User, error)
+	FindByStudentNumber(studentNumber string) ([]model.User, error)
+	FindByOIDCSubject(subject string) (*model.User, error)
+	FindByCalendarTokenHash(hash string) (*model.User, error)
+	GetRecoveryCodes(userID int64) ([]model.RecoveryCode, error)
+	ReplaceRecoveryCodes(userID int64, encryptedCodes []string) error
+	UseTOTPStep(userID int64, step int64) (bool, error)
+	UseRecoveryCode(codeID int64) (bool, error)
 	Find(query string) ([]model.User, error)
 	GetEnrollments(userID int64) ([]model.Enrollment, error)
 }
@@ -57,6 +67,62 @@ type ExamStore interface {
 	UpdateUserExam(p *model.UserExam) error
 }
 
+// AttendanceStore defines attendance related database queries
+type AttendanceStore interface {
+	GetSession(sessionID int64) (*model.GroupSession, error)
+	SessionsOfGroup(groupID int64) ([]model.GroupSession, error)
+	CreateSession(p *model.GroupSession) (*model.GroupSession, error)
+	UpdateSession(p *model.GroupSession) error
+	DeleteSession(sessionID int64) error
+	FindSessionByCode(courseID int64, code string, now time.Time) (*model.GroupSession, error)
+	GetAttendances(sessionID int64) ([]model.Attendance, error)
+	Attend(sessionID int64, userID int64) error
+	ReplaceAttendances(sessionID int64, userIDs []int64) error
+	GetSummaries(courseID int64, until time.Time) ([]model.AttendanceSummary, error)
+}
+
+// PresentationStore defines presentation related database queries
+type PresentationStore interface {
+	Get(presentationID int64) (*model.Presentation, error)
+	PresentationsOfGroup(groupID int64) ([]model.Presentation, error)
+	Create(p *model.Presentation) (*model.Presentation, error)
+	Update(p *model.Presentation) error
+	Delete(presentationID int64) error
+	CountsOfCourse(courseID int64) ([]model.PresentationCount, error)
+}
+
+// PersonalTokenStore defines personal api token related database queries
+type PersonalTokenStore interface {
+	Get(tokenID int64) (*model.PersonalToken, error)
+	TokensOfUser(userID int64) ([]model.PersonalToken, error)
+	FindByHash(tokenHash string) (*model.PersonalToken, error)
+	Create(p *model.PersonalToken) (*model.PersonalToken, error)
+	Delete(tokenID int64) error
+	DeleteAllOfUser(userID int64) error
+	Touch(tokenID int64, usedAt time.Time) error
+}
+
+// LoginSessionStore defines server-side login session related database queries
+type LoginSessionStore interface {
+	Get(sessionID int64) (*model.LoginSession, error)
+	SessionsOfUser(userID int64, now time.Time) ([]model.LoginSession, error)
+	Create(p *model.LoginSession) (*model.LoginSession, error)
+	Rotate(sessionID int64, oldTokenID string, newTokenID string, usedAt time.Time, expiresAt time.Time) (bool, error)
+	Touch(sessionID int64, usedAt time.Time) error
+	Delete(sessionID int64) error
+	DeleteAllOfUser(userID int64, exceptSessionID int64) error
+	DeleteExpired(now time.Time) error
+}
+
+// LoginLockoutStore defines failed login related database queries
+type LoginLockoutStore interface {
+	Get(userID int64) (*model.LoginLockout, error)
+	GetAll() ([]model.LoginLockout, error)
+	RecordFailure(userID int64, failedAt time.Time, address string, forgetBefore time.Time) (*model.LoginLockout, error)
+	Lock(userID int64, until time.Time) error
+	Clear(userID int64) error
+}
+
 // CourseStore defines course related database queries
 type CourseStore interface {
 	Get(courseID int64) (*model.Course, error)
@@ -65,6 +131,7 @@ type CourseStore interface {
 	Create(p *model.Course) (*model.Course, error)
 	Delete(courseID int64) error
 	Enroll(courseID int64, userID int64, role int64) error
+	ImportEnrollments(courseID int64, entries []model.EnrollmentImportEntry) error
 	Disenroll(courseID int64, userID int64) error
 	EnrolledUsers(
 		courseID int64,
@@ -81,6 +148,7 @@ type CourseStore interface {
 	) ([]model.UserCourse, error)
 	GetUserEnrollment(courseID int64, userID int64) (*model.UserCourse, error)
 	PointsForUser(userID int64, courseID int64) ([]model.SheetPoints, error)
+	PointsOfCourse(courseID int64) ([]model.UserSheetPoints, error)
 	RoleInCourse(userID int64, courseID int64) (authorize.CourseRole, error)
 	UpdateRole(courseID, userID int64, role int) error
 }
@@ -121,6 +189,7 @@ type GroupStore interface {
 	Get(groupID int64) (*model.Group, error)
 	GetAll() ([]model.Group, error)
 	Create(p *model.Group) (*model.Group, error)
+	CreateWithSlots(p *model.Group, slots []model.GroupSlot) (*model.Group, error)
 	Update(p *model.Group) error
 	Delete(taskID int64) error
 	// GroupsOfCourse(courseID int64) ([]model.Group, error)
@@ -136,6 +205,25 @@ type GroupStore interface {
 
 	GetBidsForCourseForUser(courseID int64, userID int64) ([]model.GroupBid, error)
 	GetBidsForCourse(courseID int64) ([]model.GroupBid, error)
+	ReplaceGroupEnrollmentsOfCourse(courseID int64, p []model.GroupEnrollment) error
+
+	GetSlots(groupID int64) ([]model.GroupSlot, error)
+	GetSlotsOfCourse(courseID int64) ([]model.GroupSlot, error)
+	ReplaceSlots(groupID int64, p []model.GroupSlot) error
+
+	JoinGroup(courseID int64, groupID int64, userID int64, waitlist bool) (*model.GroupJoin, error)
+	LeaveGroup(courseID int64, userID int64) error
+	PromoteWaitlist(courseID int64, groupID int64) error
+	GetWaitlistOfUserInCourse(userID int64, courseID int64) (groupID int64, position int, err error)
+	RequestGroupSwap(courseID int64, userID int64, partnerID int64) (swapped bool, err error)
+	GetGroupSwapsOfUser(courseID int64, userID int64) ([]model.GroupSwap, error)
+	DeleteGroupSwapOfUser(courseID int64, userID int64) error
+
+	GetTutors(groupID int64) ([]model.GroupTutor, error)
+	SetTutor(p *model.GroupTutor) error
+	SetLeadTutor(groupID int64, userID int64) error
+	RemoveTutor(groupID int64, userID int64) error
+	IsTutorOfGroup(groupID int64, userID int64) (bool, error)
 
 	GetGroupEnrollmentOfUserInCourse(userID int64, courseID int64) (*model.GroupEnrollment, error)
 	CreateGroupEnrollmentOfUserInCourse(p *model.GroupEnrollment) (*model.GroupEnrollment, error)
@@ -184,59 +272,80 @@ type GradeStore interface {
 	Get(id int64) (*model.Grade, error)
 	GetForSubmission(id int64) (*model.Grade, error)
 	Update(p *model.Grade) error
+	UpdateMany(p []model.Grade) error
 	IdentifyCourseOfGrade(gradeID int64) (*model.Course, error)
 	GetAllMissingGrades(courseID int64, tutorID int64, groupID int64) ([]model.MissingGrade, error)
+	GetPooledGrades(courseID int64) ([]model.MissingGrade, error)
+	ClaimPooledGrades(courseID int64, tutorID int64, gradeIDs []int64, count int) ([]int64, error)
+	AssignGrades(courseID int64, gradeIDs []int64, tutorID int64) ([]int64, error)
+	GetTutorWorkloads(courseID int64) ([]model.TutorWorkload, error)
 	Create(p *model.Grade) (*model.Grade, error)
 
 	UpdatePrivateTestInfo(gradeID int64, log string, status symbol.TestingResult) error
 	UpdatePublicTestInfo(gradeID int64, log string, status symbol.TestingResult) error
 	IdentifyTaskOfGrade(gradeID int64) (*model.Task, error)
 	GetOverviewGrades(courseID int64, groupID int64) ([]model.OverviewGrade, error)
+	GetStatisticEntries(courseID int64, sheetID int64) ([]model.GradeStatisticEntry, error)
 }
 
 // API provides application resources and handlers.
 type API struct {
-	User       *UserResource
-	Account    *AccountResource
-	Auth       *AuthResource
-	Course     *CourseResource
-	Sheet      *SheetResource
-	Task       *TaskResource
-	Group      *GroupResource
-	TaskRating *TaskRatingResource
-	Submission *SubmissionResource
-	Material   *MaterialResource
-	Grade      *GradeResource
-	Common     *CommonResource
-	Exam       *ExamResource
+	User          *UserResource
+	Account       *AccountResource
+	Auth          *AuthResource
+	Course        *CourseResource
+	Sheet         *SheetResource
+	Task          *TaskResource
+	Group         *GroupResource
+	TaskRating    *TaskRatingResource
+	Submission    *SubmissionResource
+	Material      *MaterialResource
+	Grade         *GradeResource
+	Common        *CommonResource
+	Exam          *ExamResource
+	Attendance    *AttendanceResource
+	Presentation  *PresentationResource
+	PersonalToken *PersonalTokenResource
+	LoginSession  *LoginSessionResource
+	LoginLockout  *LoginLockoutResource
 }
 
 // Stores is the collection of stores. We use this struct to express a kind of
 // hierarchy of database queries, e.g. stores.User.Get(1)
 type Stores struct {
-	Course     CourseStore
-	User       UserStore
-	Sheet      SheetStore
-	Task       TaskStore
-	Group      GroupStore
-	Submission SubmissionStore
-	Material   MaterialStore
-	Grade      GradeStore
-	Exam       ExamStore
+	Course        CourseStore
+	User          UserStore
+	Sheet         SheetStore
+	Task          TaskStore
+	Group         GroupStore
+	Submission    SubmissionStore
+	Material      MaterialStore
+	Grade         GradeStore
+	Exam          ExamStore
+	Attendance    AttendanceStore
+	Presentation  PresentationStore
+	PersonalToken PersonalTokenStore
+	LoginSession  LoginSessionStore
+	LoginLockout  LoginLockoutStore
 }
 
 // NewStores build all stores and connect them to a database.
 func NewStores(db *sqlx.DB) *Stores {
 	return &Stores{
-		Course:     database.NewCourseStore(db),
-		User:       database.NewUserStore(db),
-		Sheet:      database.NewSheetStore(db),
-		Task:       database.NewTaskStore(db),
-		Group:      database.NewGroupStore(db),
-		Submission: database.NewSubmissionStore(db),
-		Material:   database.NewMaterialStore(db),
-		Grade:      database.NewGradeStore(db),
-		Exam:       database.NewExamStore(db),
+		Course:        database.NewCourseStore(db),
+		User:          database.NewUserStore(db),
+		Sheet:         database.NewSheetStore(db),
+		Task:          database.NewTaskStore(db),
+		Group:         database.NewGroupStore(db),
+		Submission:    database.NewSubmissionStore(db),
+		Material:      database.NewMaterialStore(db),
+		Grade:         database.NewGradeStore(db),
+		Exam:          database.NewExamStore(db),
+		Attendance:    database.NewAttendanceStore(db),
+		Presentation:  database.NewPresentationStore(db),
+		PersonalToken: database.NewPersonalTokenStore(db),
+		LoginSession:  database.NewLoginSessionStore(db),
+		LoginLockout:  database.NewLoginLockoutStore(db),
 	}
 }
 
@@ -245,19 +354,24 @@ func NewAPI(db *sqlx.DB, tokenAuth *authenticate.TokenAuth, sessionAuth *scs.Man
 	stores := NewStores(db)
 
 	api := &API{
-		Account:    NewAccountResource(stores),
-		Auth:       NewAuthResource(stores, tokenAuth, sessionAuth),
-		User:       NewUserResource(stores),
-		Course:     NewCourseResource(stores),
-		Sheet:      NewSheetResource(stores),
-		Task:       NewTaskResource(stores),
-		Group:      NewGroupResource(stores),
-		TaskRating: NewTaskRatingResource(stores),
-		Submission: NewSubmissionResource(stores, tokenAuth),
-		Material:   NewMaterialResource(stores),
-		Grade:      NewGradeResource(stores),
-		Common:     NewCommonResource(stores),
-		Exam:       NewExamResource(stores),
+		Account:       NewAccountResource(stores),
+		Auth:          NewAuthResource(stores, tokenAuth, sessionAuth),
+		User:          NewUserResource(stores),
+		Course:        NewCourseResource(stores),
+		Sheet:         NewSheetResource(stores),
+		Task:          NewTaskResource(stores),
+		Group:         NewGroupResource(stores),
+		TaskRating:    NewTaskRatingResource(stores),
+		Submission:    NewSubmissionResource(stores, tokenAuth),
+		Material:      NewMaterialResource(stores),
+		Grade:         NewGradeResource(stores),
+		Common:        NewCommonResource(stores),
+		Exam:          NewExamResource(stores),
+		Attendance:    NewAttendanceResource(stores),
+		Presentation:  NewPresentationResource(stores),
+		PersonalToken: NewPersonalTokenResource(stores),
+		LoginSession:  NewLoginSessionResource(stores),
+		LoginLockout:  NewLoginLockoutResource(stores),
 	}
 	return api, nil
 }
@@ -0,0 +1,536 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// DefaultAttendanceCodeMinutes is how long a check-in code is valid by default.
+const DefaultAttendanceCodeMinutes = 10
+
+// AttendanceResource specifies attendance management handler.
+type AttendanceResource struct {
+	Stores *Stores
+}
+
+// NewAttendanceResource create and returns a AttendanceResource.
+func NewAttendanceResource(stores *Stores) *AttendanceResource {
+	return &AttendanceResource{
+		Stores: stores,
+	}
+}
+
+// AttendancePercentage is the percentage of past sessions a student attended.
+// Without any past session the requirement is trivially met.
+func AttendancePercentage(p model.AttendanceSummary) float64 {
+	if p.Sessions == 0 {
+		return 100
+	}
+	return 100 * float64(p.Attended) / float64(p.Sessions)
+}
+
+// GetAttendancePercentages returns the attendance of each student in a group
+// of the course. Students without a group have no entry.
+func GetAttendancePercentages(stores *Stores, courseID int64, now time.Time) (map[int64]float64, error) {
+	summaries, err := stores.Attendance.GetSummaries(courseID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	percentages := make(map[int64]float64)
+	for _, summary := range summaries {
+		percentages[summary.UserID] = AttendancePercentage(summary)
+	}
+	return percentages, nil
+}
+
+// IndexSessionsHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,GroupSessionResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all sessions of a group
+func (rs *AttendanceResource) IndexSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	sessions, err := rs.Stores.Attendance.SessionsOfGroup(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newGroupSessionListResponse(sessions)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateSessionHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: attendances
+// REQUEST: GroupSessionRequest
+// RESPONSE: 201,GroupSessionResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a session of a group
+func (rs *AttendanceResource) CreateSessionHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	data := &GroupSessionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	session, err := rs.Stores.Attendance.CreateSession(&model.GroupSession{
+		GroupID:     group.ID,
+		HeldAt:      data.HeldAt,
+		Description: data.Description,
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newGroupSessionResponse(session)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetSessionHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,AttendanceResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all students who attended a session
+func (rs *AttendanceResource) GetSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	attendances, err := rs.Stores.Attendance.GetAttendances(session.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newAttendanceListResponse(attendances)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditSessionHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: put
+// TAG: attendances
+// REQUEST: GroupSessionRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  change the date and description of a session
+func (rs *AttendanceResource) EditSessionHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	data := &GroupSessionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	session.HeldAt = data.HeldAt
+	session.Description = data.Description
+
+	if err := rs.Stores.Attendance.UpdateSession(session); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteSessionHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: delete
+// TAG: attendances
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete a session including all its attendances
+func (rs *AttendanceResource) DeleteSessionHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	if err := rs.Stores.Attendance.DeleteSession(session.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// EditAttendancesHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}/attendances
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: put
+// TAG: attendances
+// REQUEST: AttendanceListRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  take the attendance of a session as a checklist
+// DESCRIPTION:
+// The given students replace all previous attendances of this session,
+// including those recorded by a code. Only members of the group can attend.
+func (rs *AttendanceResource) EditAttendancesHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	data := &AttendanceListRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	members, err := rs.Stores.Group.GetMembers(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	isMember := make(map[int64]bool)
+	for _, member := range members {
+		isMember[member.ID] = true
+	}
+	for _, userID := range data.UserIDs {
+		if !isMember[userID] {
+			render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("user %d is not a member of this group", userID)))
+			return
+		}
+	}
+
+	if err := rs.Stores.Attendance.ReplaceAttendances(session.ID, data.UserIDs); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// CreateCodeHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}/code
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: post
+// TAG: attendances
+// REQUEST: AttendanceCodeRequest
+// RESPONSE: 200,GroupSessionResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a short-lived code students enter to record their attendance
+// DESCRIPTION:
+// A new code replaces the previous code of this session.
+func (rs *AttendanceResource) CreateCodeHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	data := &AttendanceCodeRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	// codes are short, hence avoid handing out a code which is currently
+	// valid for another session of the course
+	now := NowUTC()
+	code := ""
+	for code == "" {
+		code = strings.ToUpper(auth.GenerateToken(4))
+		_, err := rs.Stores.Attendance.FindSessionByCode(course.ID, code, now)
+		if err == nil {
+			code = ""
+			continue
+		}
+		if err != sql.ErrNoRows {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	session.Code = code
+	session.CodeExpiresAt = null.TimeFrom(now.Add(time.Duration(data.ValidMinutes) * time.Minute))
+
+	if err := rs.Stores.Attendance.UpdateSession(session); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, newGroupSessionResponse(session)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// CheckInHandler is public endpoint for
+// URL: /courses/{course_id}/attendances
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: attendances
+// REQUEST: AttendanceCheckInRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  record the own attendance by the code shown in the session
+// DESCRIPTION:
+// Students can only attend sessions of their own group. The number of
+// attempts per hour is limited for each account.
+func (rs *AttendanceResource) CheckInHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &AttendanceCheckInRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	session, err := rs.Stores.Attendance.FindSessionByCode(course.ID,
+		strings.ToUpper(strings.TrimSpace(data.Code)), NowUTC())
+	if err == sql.ErrNoRows {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("code is invalid or has expired")))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	enrollment, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err != nil || enrollment.GroupID != session.GroupID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are not a member of this group")))
+		return
+	}
+
+	if err := rs.Stores.Attendance.Attend(session.ID, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// IndexSummariesHandler is public endpoint for
+// URL: /courses/{course_id}/attendances
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,AttendanceSummaryResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list the attendance of all students in groups of the course
+func (rs *AttendanceResource) IndexSummariesHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	summaries, err := rs.Stores.Attendance.GetSummaries(course.ID, NowUTC())
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	list := []render.Renderer{}
+	for _, summary := range summaries {
+		list = append(list, newAttendanceSummaryResponse(summary, course))
+	}
+
+	if err := render.RenderList(w, r, list); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// GetMineHandler is public endpoint for
+// URL: /courses/{course_id}/attendances/own
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,AttendanceSummaryResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get the own attendance in the course
+func (rs *AttendanceResource) GetMineHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	summaries, err := rs.Stores.Attendance.GetSummaries(course.ID, NowUTC())
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	own := model.AttendanceSummary{UserID: accessClaims.LoginID}
+	for _, summary := range summaries {
+		if summary.UserID == accessClaims.LoginID {
+			own = summary
+		}
+	}
+
+	if err := render.Render(w, r, newAttendanceSummaryResponse(own, course)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a session from the URL parameter
+// `session_id` passed through as the request. In case the session could not be
+// found or belongs to another group, we stop here and return a 404.
+func (rs *AttendanceResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+		var sessionID int64
+		var err error
+
+		// try to get id from URL
+		if sessionID, err = strconv.ParseInt(chi.URLParam(r, "session_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific session in database
+		session, err := rs.Stores.Attendance.GetSession(sessionID)
+		if err != nil || session.GroupID != group.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// serve next
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyGroupSession, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
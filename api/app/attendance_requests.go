@@ -0,0 +1,118 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// GroupSessionRequest is the request payload for a session of a group.
+type GroupSessionRequest struct {
+	HeldAt      time.Time `json:"held_at" example:"auto"`
+	Description string    `json:"description" example:"Exercise session 3" required:"false"`
+}
+
+// Bind preprocesses a GroupSessionRequest.
+func (body *GroupSessionRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"session\" data")
+	}
+	return body.Validate()
+}
+
+func (body *GroupSessionRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.HeldAt,
+			validation.Required,
+		),
+	)
+}
+
+// AttendanceListRequest replaces all attendances of a session, e.g. from a
+// checklist.
+type AttendanceListRequest struct {
+	UserIDs []int64 `json:"user_ids" example:"112,113"`
+}
+
+// Bind preprocesses an AttendanceListRequest.
+func (body *AttendanceListRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"attendances\" data")
+	}
+	if body.UserIDs == nil {
+		body.UserIDs = []int64{}
+	}
+	return nil
+}
+
+// AttendanceCodeRequest opens a session for self check-in by a code which is
+// valid for the given number of minutes (10 by default).
+type AttendanceCodeRequest struct {
+	ValidMinutes int `json:"valid_minutes" example:"10" minval:"0" maxval:"240" required:"false"`
+}
+
+// Bind preprocesses an AttendanceCodeRequest.
+func (body *AttendanceCodeRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"code\" data")
+	}
+	if body.ValidMinutes == 0 {
+		body.ValidMinutes = DefaultAttendanceCodeMinutes
+	}
+	return body.Validate()
+}
+
+func (body *AttendanceCodeRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.ValidMinutes,
+			validation.Min(1),
+			validation.Max(240),
+		),
+	)
+}
+
+// AttendanceCheckInRequest records the attendance of the identity by the code
+// shown in the session.
+type AttendanceCheckInRequest struct {
+	Code string `json:"code" example:"3FA9C1B7"`
+}
+
+// Bind preprocesses an AttendanceCheckInRequest.
+func (body *AttendanceCheckInRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"check-in\" data")
+	}
+	return body.Validate()
+}
+
+func (body *AttendanceCheckInRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Code,
+			validation.Required,
+		),
+	)
+}
@@ -0,0 +1,127 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSessionResponse is the response payload for a session of a group.
+type GroupSessionResponse struct {
+	ID            int64     `json:"id" example:"12"`
+	GroupID       int64     `json:"group_id" example:"1"`
+	HeldAt        time.Time `json:"held_at" example:"auto"`
+	Description   string    `json:"description" example:"Exercise session 3"`
+	Code          string    `json:"code" example:"3FA9C1"`
+	CodeExpiresAt null.Time `json:"code_expires_at" example:"auto"`
+}
+
+// Render post-processes a GroupSessionResponse.
+func (body *GroupSessionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGroupSessionResponse creates a response from a GroupSession model.
+func newGroupSessionResponse(p *model.GroupSession) *GroupSessionResponse {
+	return &GroupSessionResponse{
+		ID:            p.ID,
+		GroupID:       p.GroupID,
+		HeldAt:        p.HeldAt,
+		Description:   p.Description,
+		Code:          p.Code,
+		CodeExpiresAt: p.CodeExpiresAt,
+	}
+}
+
+// newGroupSessionListResponse creates a response from a list of GroupSession models.
+func newGroupSessionListResponse(sessions []model.GroupSession) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range sessions {
+		list = append(list, newGroupSessionResponse(&sessions[k]))
+	}
+	return list
+}
+
+// AttendanceResponse is a student who attended a session.
+type AttendanceResponse struct {
+	UserID        int64     `json:"user_id" example:"112"`
+	FirstName     string    `json:"first_name" example:"Max"`
+	LastName      string    `json:"last_name" example:"Mustermensch"`
+	Email         string    `json:"email" example:"test@uni-tuebingen.de"`
+	StudentNumber string    `json:"student_number" example:"0815"`
+	CreatedAt     time.Time `json:"created_at" example:"auto"`
+}
+
+// Render post-processes an AttendanceResponse.
+func (body *AttendanceResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newAttendanceListResponse creates a response from a list of Attendance models.
+func newAttendanceListResponse(attendances []model.Attendance) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range attendances {
+		list = append(list, &AttendanceResponse{
+			UserID:        attendances[k].UserID,
+			FirstName:     attendances[k].FirstName,
+			LastName:      attendances[k].LastName,
+			Email:         attendances[k].Email,
+			StudentNumber: attendances[k].StudentNumber,
+			CreatedAt:     attendances[k].CreatedAt,
+		})
+	}
+	return list
+}
+
+// AttendanceSummaryResponse tells how many past sessions of their group a
+// student attended and whether this meets the requirement of the course.
+type AttendanceSummaryResponse struct {
+	UserID     int64   `json:"user_id" example:"112"`
+	GroupID    int64   `json:"group_id" example:"1"`
+	Sessions   int     `json:"sessions" example:"8"`
+	Attended   int     `json:"attended" example:"7"`
+	Percentage float64 `json:"percentage" example:"87.5"`
+	Required   int     `json:"required" example:"75"`
+	Met        bool    `json:"met" example:"true"`
+}
+
+// Render post-processes an AttendanceSummaryResponse.
+func (body *AttendanceSummaryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newAttendanceSummaryResponse creates a response from an AttendanceSummary model.
+func newAttendanceSummaryResponse(p model.AttendanceSummary, course *model.Course) *AttendanceSummaryResponse {
+	percentage := AttendancePercentage(p)
+	return &AttendanceSummaryResponse{
+		UserID:     p.UserID,
+		GroupID:    p.GroupID,
+		Sessions:   p.Sessions,
+		Attended:   p.Attended,
+		Percentage: percentage,
+		Required:   course.RequiredAttendance,
+		Met:        percentage >= float64(course.RequiredAttendance),
+	}
+}
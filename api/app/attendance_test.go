@@ -0,0 +1,279 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	redis "github.com/redis/go-redis/v9"
+)
+
+func TestAttendance(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	option, err := redis.ParseURL(configuration.Configuration.Server.RedisURL())
+	if err != nil {
+		panic(err)
+	}
+	redisClient := redis.NewClient(option)
+	defer redisClient.Close()
+
+	g.Describe("Attendance", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should let only tutors of the group manage sessions", func() {
+			// group 2 is led by tutor 2, group 1 is not
+			w := tape.Post("/api/v1/courses/1/groups/1/sessions", H{"held_at": NowUTC()}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			members, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 0).IsTrue()
+			studentJWT := tape.NewJWTRequest(members[0].ID, false)
+
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions", H{"held_at": NowUTC()}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions",
+				H{"held_at": NowUTC().Add(-time.Hour), "description": "first session"}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			session := GroupSessionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&session)
+			g.Assert(err).Equal(nil)
+			g.Assert(session.GroupID).Equal(int64(2))
+			g.Assert(session.Description).Equal("first session")
+			g.Assert(session.Code).Equal("")
+
+			url := fmt.Sprintf("/api/v1/courses/1/groups/2/sessions/%d", session.ID)
+
+			// sessions belong to their group
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/groups/1/sessions/%d", session.ID), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			nonMembers, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			w = tape.Put(url+"/attendances", H{"user_ids": []int64{nonMembers[0].ID}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put(url+"/attendances", H{"user_ids": []int64{members[0].ID}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			attendances := []AttendanceResponse{}
+			err = json.NewDecoder(w.Body).Decode(&attendances)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(attendances)).Equal(1)
+			g.Assert(attendances[0].UserID).Equal(members[0].ID)
+
+			w = tape.Get("/api/v1/courses/1/attendances/own", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			summary := AttendanceSummaryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&summary)
+			g.Assert(err).Equal(nil)
+			g.Assert(summary.Sessions).Equal(1)
+			g.Assert(summary.Attended).Equal(1)
+			g.Assert(summary.Met).IsTrue()
+
+			w = tape.Delete(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			sessions, err := stores.Attendance.SessionsOfGroup(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sessions)).Equal(0)
+		})
+
+		g.It("Should record attendances by a short-lived code", func() {
+			session, err := stores.Attendance.CreateSession(&model.GroupSession{
+				GroupID: 2,
+				HeldAt:  NowUTC().Add(-time.Minute),
+			})
+			g.Assert(err).Equal(nil)
+			url := fmt.Sprintf("/api/v1/courses/1/groups/2/sessions/%d", session.ID)
+
+			members, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+			studentJWT := tape.NewJWTRequest(members[0].ID, false)
+
+			others, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			otherJWT := tape.NewJWTRequest(others[0].ID, false)
+
+			w := tape.Post(url+"/code", H{"valid_minutes": 500}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post(url+"/code", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			withCode := GroupSessionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&withCode)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(withCode.Code)).Equal(8)
+			g.Assert(withCode.CodeExpiresAt.Valid).IsTrue()
+
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": "WRONG"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": withCode.Code}, otherJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": withCode.Code}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			// recording twice has no effect
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": withCode.Code}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			attendances, err := stores.Attendance.GetAttendances(session.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(attendances)).Equal(1)
+			g.Assert(attendances[0].UserID).Equal(members[0].ID)
+
+			// expired codes are rejected
+			_, err = tape.DB.Exec("UPDATE group_sessions SET code_expires_at = $2 WHERE id = $1",
+				session.ID, NowUTC().Add(-time.Minute))
+			g.Assert(err).Equal(nil)
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": withCode.Code}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should limit the attempts to check in", func() {
+			session, err := stores.Attendance.CreateSession(&model.GroupSession{
+				GroupID: 2,
+				HeldAt:  NowUTC().Add(-time.Minute),
+			})
+			g.Assert(err).Equal(nil)
+			url := fmt.Sprintf("/api/v1/courses/1/groups/2/sessions/%d", session.ID)
+
+			members, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+			studentJWT := tape.NewJWTRequest(members[0].ID, false)
+
+			w := tape.Post(url+"/code", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			withCode := GroupSessionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&withCode)
+			g.Assert(err).Equal(nil)
+
+			limit := int(configuration.Configuration.Server.Authentication.CheckInsPerHour)
+			for i := 0; i < limit; i++ {
+				w = tape.Post("/api/v1/courses/1/attendances", H{"code": "WRONG"}, studentJWT)
+				g.Assert(w.Code).Equal(http.StatusBadRequest)
+			}
+
+			// even the right code is rejected after guessing
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": withCode.Code}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusTooManyRequests)
+
+			attendances, err := stores.Attendance.GetAttendances(session.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(attendances)).Equal(0)
+
+			err = redisClient.Del(context.Background(),
+				fmt.Sprintf("infomark-check-ins:user-%d-infomark-check-ins", members[0].ID)).Err()
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/courses/1/attendances", H{"code": withCode.Code}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+		})
+
+		g.It("Should include the attendance in the pass/fail evaluation", func() {
+			_, err := stores.Attendance.CreateSession(&model.GroupSession{
+				GroupID: 2,
+				HeldAt:  NowUTC().Add(-time.Hour),
+			})
+			g.Assert(err).Equal(nil)
+
+			_, err = tape.DB.Exec("UPDATE courses SET required_percentage = 0, required_attendance = 50 WHERE id = 1")
+			g.Assert(err).Equal(nil)
+
+			members, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+			isMember := make(map[string]bool)
+			for _, member := range members {
+				isMember[member.Email] = true
+			}
+
+			w := tape.Get("/api/v1/courses/1/grades/export?format=csv&columns=email,attendance,passed", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(records[0]).Equal([]string{"email", "attendance", "passed"})
+
+			for _, record := range records[1:] {
+				if isMember[record[0]] {
+					g.Assert(record[1]).Equal("0.00")
+					g.Assert(record[2]).Equal("false")
+				}
+			}
+
+			w = tape.Get("/api/v1/courses/1/attendances", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			summaries := []AttendanceSummaryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&summaries)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(summaries) > 0).IsTrue()
+			for _, summary := range summaries {
+				if summary.GroupID == 2 {
+					g.Assert(summary.Sessions).Equal(1)
+					g.Assert(summary.Met).IsFalse()
+				}
+			}
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+			keys, err := redisClient.Keys(context.Background(), "infomark-check-ins:*").Result()
+			g.Assert(err).Equal(nil)
+			if len(keys) > 0 {
+				err = redisClient.Del(context.Background(), keys...).Err()
+				g.Assert(err).Equal(nil)
+			}
+		})
+	})
+
+}
@@ -23,6 +23,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/alexedwards/scs"
 	"github.com/go-chi/jwtauth/v5"
@@ -31,7 +33,9 @@ import (
 	"github.com/infomark-org/infomark/auth/authenticate"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
+	"github.com/sirupsen/logrus"
 	null "gopkg.in/guregu/null.v3"
 )
 
@@ -40,15 +44,29 @@ type AuthResource struct {
 	Stores      *Stores
 	TokenAuth   *authenticate.TokenAuth
 	SessionAuth *scs.Manager
+	// Authenticator checks email and password of a login.
+	Authenticator Authenticator
+	// OIDC is nil when single sign-on is disabled.
+	OIDC *authenticate.OIDCProvider
 }
 
 // NewAuthResource create and returns a AuthResource.
 func NewAuthResource(stores *Stores, tokenAuth *authenticate.TokenAuth, sessionAuth *scs.Manager) *AuthResource {
-	return &AuthResource{
+	rs := &AuthResource{
 		Stores:      stores,
 		TokenAuth:   tokenAuth,
 		SessionAuth: sessionAuth,
 	}
+
+	config := &configuration.Configuration.Server
+	rs.Authenticator = NewAuthenticator(stores, &config.Authentication)
+	if config.Authentication.OIDC.Enabled {
+		rs.OIDC = authenticate.NewOIDCProvider(
+			&config.Authentication.OIDC,
+			config.Authentication.OIDC.CallbackURL(config.ExternalURL()),
+		)
+	}
+	return rs
 }
 
 // RefreshAccessTokenHandler is public endpoint for
@@ -63,7 +81,8 @@ func NewAuthResource(stores *Stores, tokenAuth *authenticate.TokenAuth, sessionA
 // SUMMARY:  Refresh or Generate Access token
 // DESCRIPTION:
 // This endpoint will generate the access token without login credentials
-// if the refresh token is given.
+// if the refresh token is given. Each refresh token can be used once, the
+// response contains its replacement. Using a refresh token twice revokes the login.
 func (rs *AuthResource) RefreshAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
 	// Login with your username and password to get the generated JWT refresh and
 	// access tokens. Alternatively, if the refresh token is already present in
@@ -103,8 +122,33 @@ func (rs *AuthResource) RefreshAccessTokenHandler(w http.ResponseWriter, r *http
 			return
 		}
 
-		// we just need to return an access-token
-		accessToken, err := tokenManager.CreateAccessJWT(authenticate.NewAccessClaims(targetUser.ID, targetUser.Root))
+		// each refresh token can be used once only
+		session, err := RotateLoginSession(rs.Stores, refreshClaims)
+		if err == ErrLoginSessionInvalid || err == ErrRefreshTokenReused {
+			render.Render(w, r, ErrUnauthorizedWithDetails(err))
+			return
+		}
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		refreshToken, err := tokenManager.CreateRefreshJWT(
+			authenticate.NewRefreshClaims(targetUser.ID, session.ID, session.TokenID))
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		accessClaims := authenticate.NewAccessClaims(targetUser.ID, targetUser.Root)
+		accessClaims.SessionID = session.ID
+		accessClaims.SetupTwoFactor, err = TwoFactorSetupRequired(rs.Stores, targetUser)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		accessToken, err := tokenManager.CreateAccessJWT(accessClaims)
 		if err != nil {
 			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 			return
@@ -112,8 +156,9 @@ func (rs *AuthResource) RefreshAccessTokenHandler(w http.ResponseWriter, r *http
 
 		resp := &AuthResponse{}
 		resp.Access.Token = accessToken
+		resp.Refresh.Token = refreshToken
 
-		// return access token only
+		// return the access token and the replacement of the refresh token
 		if err := render.Render(w, r, resp); err != nil {
 			render.Render(w, r, ErrRender(err))
 			return
@@ -130,20 +175,24 @@ func (rs *AuthResource) RefreshAccessTokenHandler(w http.ResponseWriter, r *http
 			return
 		}
 
-		// does such a user exists with request email address?
-		potentialUser, err := rs.Stores.User.FindByEmail(data.Email)
-		if err != nil {
+		// does such a user exists and does the password match?
+		potentialUser, err := rs.checkLogin(r, data)
+		if err == ErrInvalidCredentials {
 			render.Render(w, r, ErrNotFound)
 			return
 		}
+		if err != nil {
+			rs.renderLoginError(w, r, err)
+			return
+		}
 
-		// does the password match?
-		if !auth.CheckPasswordHash(data.PlainPassword, potentialUser.EncryptedPassword) {
-			render.Render(w, r, ErrNotFound)
+		session, err := StartLoginSession(rs.Stores, potentialUser.ID, model.LoginSessionRefresh, r)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 			return
 		}
 
-		refreshClaims := authenticate.NewRefreshClaims(potentialUser.ID)
+		refreshClaims := authenticate.NewRefreshClaims(potentialUser.ID, session.ID, session.TokenID)
 		refreshToken, err := tokenManager.CreateRefreshJWT(refreshClaims)
 
 		if err != nil {
@@ -152,6 +201,13 @@ func (rs *AuthResource) RefreshAccessTokenHandler(w http.ResponseWriter, r *http
 		}
 
 		accessClaims := authenticate.NewAccessClaims(potentialUser.ID, potentialUser.Root)
+		accessClaims.SessionID = session.ID
+		accessClaims.SetupTwoFactor, err = TwoFactorSetupRequired(rs.Stores, potentialUser)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
 		accessToken, err := tokenManager.CreateAccessJWT(accessClaims)
 
 		if err != nil {
@@ -195,17 +251,15 @@ func (rs *AuthResource) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// does such a user exists with request email address?
-	potentialUser, err := rs.Stores.User.FindByEmail(data.Email)
-	if err != nil {
-		render.Render(w, r, ErrBadRequest)
+	// does such a user exists and does the password match?
+	potentialUser, err := rs.checkLogin(r, data)
+	if err == ErrInvalidCredentials {
+		totalFailedLoginsVec.WithLabelValues().Inc()
+		render.Render(w, r, ErrBadRequestWithDetails(err))
 		return
 	}
-
-	// does the password match?
-	if !auth.CheckPasswordHash(data.PlainPassword, potentialUser.EncryptedPassword) {
-		totalFailedLoginsVec.WithLabelValues().Inc()
-		render.Render(w, r, ErrBadRequestWithDetails(errors.New("credentials are wrong")))
+	if err != nil {
+		rs.renderLoginError(w, r, err)
 		return
 	}
 
@@ -223,10 +277,24 @@ func (rs *AuthResource) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	setupTwoFactor, err := TwoFactorSetupRequired(rs.Stores, potentialUser)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	session, err := StartLoginSession(rs.Stores, potentialUser.ID, model.LoginSessionCookie, r)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// user passed all tests
 	accessClaims := &authenticate.AccessClaims{
-		LoginID: potentialUser.ID,
-		Root:    potentialUser.Root,
+		LoginID:        potentialUser.ID,
+		Root:           potentialUser.Root,
+		SetupTwoFactor: setupTwoFactor,
+		SessionID:      session.ID,
 	}
 
 	// fmt.Println("WRITE accessClaims.LoginID", accessClaims.LoginID)
@@ -234,7 +302,7 @@ func (rs *AuthResource) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	w = accessClaims.WriteToSession(rs.SessionAuth, w, r)
 
-	resp := &loginResponse{Root: potentialUser.Root}
+	resp := &loginResponse{Root: potentialUser.Root, SetupTwoFactor: setupTwoFactor}
 	// return access token only
 	if err := render.Render(w, r, resp); err != nil {
 		render.Render(w, r, ErrRender(err))
@@ -243,6 +311,416 @@ func (rs *AuthResource) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// OIDCLoginHandler is public endpoint for
+// URL: /auth/oidc/login
+// METHOD: get
+// TAG: auth
+// RESPONSE: 302,Redirect
+// RESPONSE: 404,NotFound
+// SUMMARY:  Start a single sign-on session
+// DESCRIPTION:
+// Redirects the browser to the identity provider. It is only available when
+// single sign-on is enabled.
+func (rs *AuthResource) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if rs.OIDC == nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	flow := authenticate.NewOIDCFlow()
+	if err := flow.WriteToSession(rs.SessionAuth, w, r); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	authURL, err := rs.OIDC.AuthCodeURL(r.Context(), flow)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler is public endpoint for
+// URL: /auth/oidc/callback
+// METHOD: get
+// TAG: auth
+// QUERYPARAM: code,string
+// QUERYPARAM: state,string
+// RESPONSE: 302,Redirect
+// RESPONSE: 400,BadRequest
+// RESPONSE: 404,NotFound
+// SUMMARY:  Finish a single sign-on session
+// DESCRIPTION:
+// The identity provider redirects here after the login. The account of the
+// identity is linked or created and the session cookie is set. Accounts with
+// two-factor authentication require the identity provider to report a second
+// factor in the "amr" claim.
+func (rs *AuthResource) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if rs.OIDC == nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("identity provider reported: %s", query.Get("error"))))
+		return
+	}
+
+	// share one session between removing the flow and writing the login
+	r = r.WithContext(rs.SessionAuth.AddToContext(r.Context(), rs.SessionAuth.Load(r)))
+
+	flow, err := authenticate.PopOIDCFlowFromSession(rs.SessionAuth, w, r)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if query.Get("state") != flow.State {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("state does not match")))
+		return
+	}
+
+	identity, err := rs.OIDC.Exchange(r.Context(), query.Get("code"), *flow)
+	if err != nil {
+		totalFailedLoginsVec.WithLabelValues().Inc()
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	user, err := ProvisionOIDCUser(rs.Stores, identity)
+	if err != nil {
+		if err == ErrOIDCMultiFactorRequired {
+			totalFailedLoginsVec.WithLabelValues().Inc()
+		}
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	setupTwoFactor, err := TwoFactorSetupRequired(rs.Stores, user)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	session, err := StartLoginSession(rs.Stores, user.ID, model.LoginSessionCookie, r)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	accessClaims := &authenticate.AccessClaims{
+		LoginID:        user.ID,
+		Root:           user.Root,
+		SessionID:      session.ID,
+		SetupTwoFactor: setupTwoFactor,
+	}
+	w = accessClaims.WriteToSession(rs.SessionAuth, w, r)
+
+	http.Redirect(w, r, configuration.Configuration.Server.ExternalURL()+"/", http.StatusFound)
+}
+
+// checkLogin authenticates the credentials and the second factor of a login.
+// Failed attempts count towards the lockout of the account.
+func (rs *AuthResource) checkLogin(r *http.Request, data *LoginRequest) (*model.User, error) {
+	if err := CheckLoginLockout(rs.Stores, data.Email); err != nil {
+		return nil, err
+	}
+
+	user, err := rs.Authenticator.Authenticate(data.Email, data.PlainPassword)
+	if err == nil {
+		err = VerifyTwoFactor(rs.Stores, user, data.TwoFactorCode)
+	}
+
+	switch err {
+	case nil:
+		// only a complete login may touch the stored hash
+		RehashPassword(rs.Stores, user, data.PlainPassword)
+		return user, ClearFailedLogins(rs.Stores, user.ID)
+	case ErrInvalidCredentials, ErrTwoFactorCodeInvalid:
+		if err := RecordFailedLogin(rs.Stores, data.Email, r); err != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// renderLoginError reports a login which was blocked or failed on the second
+// factor.
+func (rs *AuthResource) renderLoginError(w http.ResponseWriter, r *http.Request, err error) {
+	if blocked, ok := err.(*LoginBlockedError); ok {
+		retryAfter := int64(time.Until(blocked.Until)/time.Second) + 1
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+		render.Render(w, r, ErrTooManyRequestsWithDetails(err))
+		return
+	}
+	rs.renderTwoFactorError(w, r, err)
+}
+
+// renderTwoFactorError reports a failed second factor of a login.
+func (rs *AuthResource) renderTwoFactorError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case ErrTwoFactorCodeRequired:
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+	case ErrTwoFactorCodeInvalid:
+		totalFailedLoginsVec.WithLabelValues().Inc()
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+	default:
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+	}
+}
+
+// GetTwoFactorHandler is public endpoint for
+// URL: /account/two_factor
+// METHOD: get
+// TAG: account
+// RESPONSE: 200,TwoFactorStatusResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// SUMMARY:  state of the two-factor authentication of the request identity
+func (rs *AuthResource) GetTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	required, err := TwoFactorRequired(rs.Stores, user)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	recoveryCodes, err := rs.Stores.User.GetRecoveryCodes(user.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	resp := &TwoFactorStatusResponse{
+		Enabled:           user.TOTPEnabled,
+		Required:          required,
+		RecoveryCodesLeft: len(recoveryCodes),
+	}
+	if err := render.Render(w, r, resp); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// EnrollTwoFactorHandler is public endpoint for
+// URL: /account/two_factor
+// METHOD: post
+// TAG: account
+// RESPONSE: 201,TwoFactorEnrollmentResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// SUMMARY:  start to set up two-factor authentication
+// DESCRIPTION:
+// Generates a new secret for the authenticator app. Two-factor authentication
+// is enabled once a code of the app has been confirmed.
+func (rs *AuthResource) EnrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	if user.TOTPEnabled {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("two-factor authentication is already enabled")))
+		return
+	}
+
+	user.TOTPSecret = null.StringFrom(auth.GenerateTOTPSecret())
+	user.TOTPLastStep = 0
+	if err := rs.Stores.User.Update(user); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	resp := &TwoFactorEnrollmentResponse{
+		Secret: user.TOTPSecret.String,
+		URI: auth.TOTPURI(configuration.Configuration.Server.Authentication.TwoFactor.Issuer,
+			user.Email, user.TOTPSecret.String),
+	}
+
+	render.Status(r, http.StatusCreated)
+	if err := render.Render(w, r, resp); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// ConfirmTwoFactorHandler is public endpoint for
+// URL: /account/two_factor/confirm
+// METHOD: post
+// TAG: account
+// REQUEST: TwoFactorCodeRequest
+// RESPONSE: 200,RecoveryCodesResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// SUMMARY:  enable two-factor authentication
+// DESCRIPTION:
+// Enables two-factor authentication when the code matches the new secret and
+// returns the recovery codes. These are shown only once.
+func (rs *AuthResource) ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	data := &TwoFactorCodeRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if user.TOTPEnabled || !user.TOTPSecret.Valid {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("two-factor authentication is not being set up")))
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret.String, data.Code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		render.Render(w, r, ErrBadRequestWithDetails(ErrTwoFactorCodeInvalid))
+		return
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	if err := rs.Stores.User.Update(user); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	codes, err := NewRecoveryCodes(rs.Stores, user.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// a session restricted to the setup becomes a full session, tokens have
+	// to be requested again
+	if accessClaims.SetupTwoFactor && !authenticate.HasHeaderToken(r) {
+		accessClaims.SetupTwoFactor = false
+		w = accessClaims.WriteToSession(rs.SessionAuth, w, r)
+	}
+
+	if err := render.Render(w, r, &RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// DisableTwoFactorHandler is public endpoint for
+// URL: /account/two_factor/disable
+// METHOD: post
+// TAG: account
+// REQUEST: TwoFactorCodeRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  disable two-factor authentication
+// DESCRIPTION:
+// Requires a current code or a recovery code. Accounts which are required to
+// use two-factor authentication cannot disable it.
+func (rs *AuthResource) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	data := &TwoFactorCodeRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if !user.TOTPEnabled {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("two-factor authentication is not enabled")))
+		return
+	}
+
+	required, err := TwoFactorRequired(rs.Stores, user)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if required {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("two-factor authentication is required for this account")))
+		return
+	}
+
+	if err := VerifyTwoFactor(rs.Stores, user, data.Code); err != nil {
+		rs.renderTwoFactorError(w, r, err)
+		return
+	}
+
+	if err := ResetTwoFactor(rs.Stores, user); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// RenewRecoveryCodesHandler is public endpoint for
+// URL: /account/two_factor/recovery_codes
+// METHOD: post
+// TAG: account
+// REQUEST: TwoFactorCodeRequest
+// RESPONSE: 200,RecoveryCodesResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// SUMMARY:  replace all recovery codes
+// DESCRIPTION:
+// Requires a current code or a recovery code. The old recovery codes become
+// invalid.
+func (rs *AuthResource) RenewRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	data := &TwoFactorCodeRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if !user.TOTPEnabled {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("two-factor authentication is not enabled")))
+		return
+	}
+
+	if err := VerifyTwoFactor(rs.Stores, user, data.Code); err != nil {
+		rs.renderTwoFactorError(w, r, err)
+		return
+	}
+
+	codes, err := NewRecoveryCodes(rs.Stores, user.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, &RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
 // LogoutHandler is public endpoint for
 // URL: /auth/sessions
 // METHOD: delete
@@ -253,9 +731,77 @@ func (rs *AuthResource) LoginHandler(w http.ResponseWriter, r *http.Request) {
 // SUMMARY:  Destroy a session
 func (rs *AuthResource) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	if accessClaims.SessionID != 0 {
+		if err := rs.Stores.LoginSession.Delete(accessClaims.SessionID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
 	accessClaims.DestroyInSession(rs.SessionAuth, w, r)
 }
 
+// ImpersonateHandler is public endpoint for
+// URL: /users/{user_id}/impersonate
+// URLPARAM: user_id,integer
+// METHOD: post
+// TAG: users
+// RESPONSE: 201,ImpersonationResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  act as another user to see what they see
+// DESCRIPTION:
+// Root users get a short-living access token for the user. Requests with this token
+// can only read, nothing can be changed. The token is revoked together with the
+// logins of the user. Every request is logged together with the root user.
+func (rs *AuthResource) ImpersonateHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if !accessClaims.Root || accessClaims.ImpersonatorID != 0 {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if user.Root {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("root users cannot be impersonated")))
+		return
+	}
+
+	// the session ends together with all other logins of the user
+	session, err := StartLoginSession(rs.Stores, user.ID, model.LoginSessionImpersonation, r)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	impersonatedClaims := authenticate.NewAccessClaims(user.ID, user.Root)
+	impersonatedClaims.ImpersonatorID = accessClaims.LoginID
+	impersonatedClaims.SessionID = session.ID
+
+	token, expiresAt, err := rs.TokenAuth.CreateImpersonationJWT(impersonatedClaims)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	logrus.StandardLogger().WithFields(logrus.Fields{
+		"module":          "impersonation",
+		"impersonator_id": accessClaims.LoginID,
+		"login_id":        user.ID,
+		"expires_at":      expiresAt,
+	}).Info("impersonation started")
+
+	resp := &ImpersonationResponse{UserID: user.ID, ExpiresAt: expiresAt}
+	resp.Access.Token = token
+
+	render.Status(r, http.StatusCreated)
+	if err := render.Render(w, r, resp); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
 // RequestPasswordResetHandler is public endpoint for
 // URL: /auth/request_password_reset
 // METHOD: post
@@ -342,9 +888,14 @@ func (rs *AuthResource) UpdatePasswordHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if err := ValidateNewPassword(data.PlainPassword, user.FirstName, user.LastName, user.Email); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
 	// token is ok, remove token and set new password
 	user.ResetPasswordToken = null.String{}
-	user.EncryptedPassword, err = auth.HashPassword(data.PlainPassword)
+	user.EncryptedPassword, err = HashPassword(data.PlainPassword)
 	if err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
@@ -357,6 +908,12 @@ func (rs *AuthResource) UpdatePasswordHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// whoever knew the old password is logged out, tokens are revoked
+	if err := RevokeCredentials(rs.Stores, user.ID, 0); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 }
 
@@ -32,6 +32,9 @@ import (
 type LoginRequest struct {
 	Email         string `json:"email" example:"test@uni-tuebingen.de"`
 	PlainPassword string `json:"plain_password" example:"test"`
+	// TwoFactorCode is either the code of the authenticator app or a recovery
+	// code. It is only required when two-factor authentication is enabled.
+	TwoFactorCode string `json:"two_factor_code" example:"123456"`
 }
 
 // Bind preprocesses a loginRequest.
@@ -75,11 +78,15 @@ func (body *UpdatePasswordRequest) Bind(r *http.Request) error {
 	body.Email = strings.TrimSpace(body.Email)
 	body.Email = strings.ToLower(body.Email)
 
-	return validation.ValidateStruct(body,
+	if err := validation.ValidateStruct(body,
 		validation.Field(&body.Email, validation.Required, is.Email),
 		validation.Field(&body.ResetPasswordToken, validation.Required),
-		validation.Field(&body.PlainPassword, validation.Required, validation.Length(7, 0)),
-	)
+		validation.Field(&body.PlainPassword, validation.Required),
+	); err != nil {
+		return err
+	}
+
+	return ValidateNewPassword(body.PlainPassword, body.Email)
 }
 
 // -----------------------------------------------------------------------------
@@ -101,3 +108,20 @@ func (body *ConfirmEmailRequest) Bind(r *http.Request) error {
 	)
 
 }
+
+// -----------------------------------------------------------------------------
+
+// TwoFactorCodeRequest confirms an action by a code of the authenticator app
+// or a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" example:"123456"`
+}
+
+// Bind preprocesses a TwoFactorCodeRequest.
+func (body *TwoFactorCodeRequest) Bind(r *http.Request) error {
+	body.Code = strings.TrimSpace(body.Code)
+
+	return validation.ValidateStruct(body,
+		validation.Field(&body.Code, validation.Required),
+	)
+}
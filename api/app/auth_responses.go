@@ -21,6 +21,7 @@ package app
 
 import (
 	"net/http"
+	"time"
 )
 
 type AuthResponse struct {
@@ -39,9 +40,64 @@ func (body *AuthResponse) Render(w http.ResponseWriter, r *http.Request) error {
 // .............................................................................
 type loginResponse struct {
 	Root bool `json:"root" example:"false"`
+	// SetupTwoFactor is true when the account can only set up two-factor
+	// authentication until it is enabled.
+	SetupTwoFactor bool `json:"setup_two_factor" example:"false"`
 }
 
 func (body *loginResponse) Render(w http.ResponseWriter, r *http.Request) error {
 	// nothing to hide
 	return nil
 }
+
+// .............................................................................
+
+// TwoFactorStatusResponse is the state of the two-factor authentication of an
+// account.
+type TwoFactorStatusResponse struct {
+	Enabled           bool `json:"enabled" example:"true"`
+	Required          bool `json:"required" example:"false"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left" example:"10"`
+}
+
+// Render post-processes a TwoFactorStatusResponse.
+func (body *TwoFactorStatusResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// TwoFactorEnrollmentResponse contains the secret for the authenticator app.
+// The uri is usually shown as QR code.
+type TwoFactorEnrollmentResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/InfoMark:test@uni-tuebingen.de?secret=JBSWY3DPEHPK3PXP"`
+}
+
+// Render post-processes a TwoFactorEnrollmentResponse.
+func (body *TwoFactorEnrollmentResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// RecoveryCodesResponse lists new recovery codes. They are shown only once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"4f9a-22c1-b0e7"`
+}
+
+// Render post-processes a RecoveryCodesResponse.
+func (body *RecoveryCodesResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ImpersonationResponse contains an access token to act as another user. It
+// cannot be refreshed.
+type ImpersonationResponse struct {
+	UserID    int64     `json:"user_id" example:"112"`
+	ExpiresAt time.Time `json:"expires_at" example:"auto"`
+	Access    struct {
+		Token string `json:"token" example:"eyJhbGciOiJIUzI1...rZikwLEI7XhY"`
+	} `json:"access"`
+}
+
+// Render post-processes a ImpersonationResponse.
+func (body *ImpersonationResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
@@ -0,0 +1,157 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// ErrInvalidCredentials is returned for unknown accounts and wrong passwords.
+var ErrInvalidCredentials = errors.New("credentials are wrong")
+
+// Authenticator checks the credentials of a login and returns the account.
+type Authenticator interface {
+	Authenticate(email string, password string) (*model.User, error)
+}
+
+// NewAuthenticator routes logins of the configured email domains to their
+// LDAP directory and all others to the stored password.
+func NewAuthenticator(stores *Stores, config *configuration.AuthenticationConfiguration) Authenticator {
+	router := &DomainAuthenticator{
+		Default: &PasswordAuthenticator{Stores: stores},
+		Domains: map[string]Authenticator{},
+	}
+	for k := range config.LDAP {
+		directory := &LDAPAuthenticator{
+			Stores:    stores,
+			Directory: authenticate.NewLDAPDirectory(&config.LDAP[k]),
+		}
+		for _, domain := range config.LDAP[k].Domains {
+			router.Domains[strings.ToLower(domain)] = directory
+		}
+	}
+	return router
+}
+
+// DomainAuthenticator selects the authenticator by the domain of the email
+// address.
+type DomainAuthenticator struct {
+	Default Authenticator
+	Domains map[string]Authenticator
+}
+
+// Authenticate implements Authenticator.
+func (a *DomainAuthenticator) Authenticate(email string, password string) (*model.User, error) {
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	if authenticator, ok := a.Domains[domain]; ok {
+		return authenticator.Authenticate(email, password)
+	}
+	return a.Default.Authenticate(email, password)
+}
+
+// PasswordAuthenticator checks the password stored with the account.
+type PasswordAuthenticator struct {
+	Stores *Stores
+}
+
+// Authenticate implements Authenticator.
+func (a *PasswordAuthenticator) Authenticate(email string, password string) (*model.User, error) {
+	user, err := a.Stores.User.FindByEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !auth.CheckPasswordHash(password, user.EncryptedPassword) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// LDAPAuthenticator binds against a directory. Unknown users get a new
+// confirmed account from the attributes of their entry. Root privileges are
+// granted when mapped, but never revoked here.
+type LDAPAuthenticator struct {
+	Stores    *Stores
+	Directory *authenticate.LDAPDirectory
+}
+
+// Authenticate implements Authenticator.
+func (a *LDAPAuthenticator) Authenticate(email string, password string) (*model.User, error) {
+	identity, err := a.Directory.Authenticate(email, password)
+	if err == authenticate.ErrLDAPInvalidCredentials {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.Stores.User.FindByEmail(email)
+	if err == sql.ErrNoRows {
+		if identity.FirstName == "" || identity.LastName == "" {
+			return nil, errors.New("directory entry has no name")
+		}
+
+		// the password is never used, but must not be guessable
+		encryptedPassword, err := HashPassword(auth.GenerateToken(32))
+		if err != nil {
+			return nil, err
+		}
+
+		return a.Stores.User.Create(&model.User{
+			FirstName:         identity.FirstName,
+			LastName:          identity.LastName,
+			Email:             email,
+			StudentNumber:     identity.StudentNumber,
+			Language:          "en",
+			EncryptedPassword: encryptedPassword,
+			Root:              identity.Root,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.FirstName != "" {
+		user.FirstName = identity.FirstName
+	}
+	if identity.LastName != "" {
+		user.LastName = identity.LastName
+	}
+	if identity.StudentNumber != "" {
+		user.StudentNumber = identity.StudentNumber
+	}
+	// the directory has verified the email address
+	user.ConfirmEmailToken = null.String{}
+	user.Root = user.Root || identity.Root
+
+	if err := a.Stores.User.Update(user); err != nil {
+		return nil, err
+	}
+	return a.Stores.User.Get(user.ID)
+}
@@ -0,0 +1,135 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+)
+
+// FixedAuthenticator accepts a single password and returns a fixed account.
+type FixedAuthenticator struct {
+	User     *model.User
+	Password string
+	Calls    int
+}
+
+func (a *FixedAuthenticator) Authenticate(email string, password string) (*model.User, error) {
+	a.Calls++
+	if password != a.Password {
+		return nil, ErrInvalidCredentials
+	}
+	return a.User, nil
+}
+
+func TestAuthenticator(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+	var stores *Stores
+
+	g.Describe("Authenticator", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should check the stored password", func() {
+			authenticator := &PasswordAuthenticator{Stores: stores}
+
+			user, err := authenticator.Authenticate("test@uni-tuebingen.de", "test")
+			g.Assert(err).Equal(nil)
+			g.Assert(user.ID).Equal(int64(1))
+
+			_, err = authenticator.Authenticate("test@uni-tuebingen.de", "wrong")
+			g.Assert(err).Equal(ErrInvalidCredentials)
+
+			_, err = authenticator.Authenticate("unknown@uni-tuebingen.de", "test")
+			g.Assert(err).Equal(ErrInvalidCredentials)
+		})
+
+		g.It("Should route by the domain of the email address", func() {
+			staff := &FixedAuthenticator{User: &model.User{ID: 2}, Password: "directory"}
+			fallback := &FixedAuthenticator{User: &model.User{ID: 1}, Password: "local"}
+
+			authenticator := &DomainAuthenticator{
+				Default: fallback,
+				Domains: map[string]Authenticator{"informatik.uni-tuebingen.de": staff},
+			}
+
+			user, err := authenticator.Authenticate("Jane.Doe@Informatik.Uni-Tuebingen.de", "directory")
+			g.Assert(err).Equal(nil)
+			g.Assert(user.ID).Equal(int64(2))
+
+			_, err = authenticator.Authenticate("jane.doe@informatik.uni-tuebingen.de", "local")
+			g.Assert(err).Equal(ErrInvalidCredentials)
+
+			user, err = authenticator.Authenticate("jane.doe@student.uni-tuebingen.de", "local")
+			g.Assert(err).Equal(nil)
+			g.Assert(user.ID).Equal(int64(1))
+
+			g.Assert(staff.Calls).Equal(2)
+			g.Assert(fallback.Calls).Equal(1)
+		})
+
+		g.It("Should issue tokens through the configured authenticator", func() {
+			staff := &FixedAuthenticator{User: &model.User{ID: 2}, Password: "directory"}
+
+			rs := NewAuthResource(stores, tape.TokenAuth, nil)
+			rs.Authenticator = &DomainAuthenticator{
+				Default: rs.Authenticator,
+				Domains: map[string]Authenticator{"informatik.uni-tuebingen.de": staff},
+			}
+
+			login := func(email string, password string) *httptest.ResponseRecorder {
+				body, _ := json.Marshal(H{"email": email, "plain_password": password})
+				r := httptest.NewRequest("POST", "/api/v1/auth/token", bytes.NewReader(body))
+				r.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				rs.RefreshAccessTokenHandler(w, r)
+				return w
+			}
+
+			w = login("jane.doe@informatik.uni-tuebingen.de", "directory")
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(staff.Calls).Equal(1)
+
+			w = login("jane.doe@informatik.uni-tuebingen.de", "test")
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = login("test@uni-tuebingen.de", "test")
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(staff.Calls).Equal(2)
+		})
+	})
+}
@@ -99,6 +99,12 @@ func (rs *CourseResource) CreateHandler(w http.ResponseWriter, r *http.Request)
 	course.BeginsAt = data.BeginsAt
 	course.EndsAt = data.EndsAt
 	course.RequiredPercentage = data.RequiredPercentage
+	course.RequiredAttendance = data.RequiredAttendance
+	course.RequiredPresentations = data.RequiredPresentations
+	course.DroppableSheets = data.DroppableSheets
+	course.GroupEnrollmentMode = data.GroupEnrollmentMode
+	course.GroupEnrollmentBeginsAt = data.GroupEnrollmentBeginsAt
+	course.GroupEnrollmentEndsAt = data.GroupEnrollmentEndsAt
 
 	// create course entry in database
 	newCourse, err := rs.Stores.Course.Create(course)
@@ -117,6 +123,50 @@ func (rs *CourseResource) CreateHandler(w http.ResponseWriter, r *http.Request)
 
 }
 
+// CloneHandler is public endpoint for
+// URL: /courses/{course_id}/clone
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: courses
+// REQUEST: CourseCloneRequest
+// RESPONSE: 201,CourseResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  clone a course for the next semester
+// DESCRIPTION:
+// All sheets, tasks including their test files and docker images, materials and
+// exams are copied. All dates are shifted by offset_days. Enrollments, groups,
+// submissions and grades are not copied. Like creating a course, this requires root.
+func (rs *CourseResource) CloneHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if !accessClaims.Root {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	data := &CourseCloneRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	clone, err := CloneCourse(rs.Stores, course, data.Name, data.OffsetDays)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, rs.newCourseResponse(clone)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
 // GetHandler is public endpoint for
 // URL: /courses/{course_id}
 // URLPARAM: course_id,integer
@@ -171,6 +221,12 @@ func (rs *CourseResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	course.BeginsAt = data.BeginsAt
 	course.EndsAt = data.EndsAt
 	course.RequiredPercentage = data.RequiredPercentage
+	course.RequiredAttendance = data.RequiredAttendance
+	course.RequiredPresentations = data.RequiredPresentations
+	course.DroppableSheets = data.DroppableSheets
+	course.GroupEnrollmentMode = data.GroupEnrollmentMode
+	course.GroupEnrollmentBeginsAt = data.GroupEnrollmentBeginsAt
+	course.GroupEnrollmentEndsAt = data.GroupEnrollmentEndsAt
 
 	// update database entry
 	if err := rs.Stores.Course.Update(course); err != nil {
@@ -208,6 +264,84 @@ func (rs *CourseResource) DeleteHandler(w http.ResponseWriter, r *http.Request)
 	render.Status(r, http.StatusNoContent)
 }
 
+// ImportEnrollmentsHandler is public endpoint for
+// URL: /courses/{course_id}/enrollments/import
+// URLPARAM: course_id,integer
+// QUERYPARAM: delimiter,string
+// QUERYPARAM: dry_run,boolean
+// QUERYPARAM: create_missing,boolean
+// QUERYPARAM: allow_downgrade,boolean
+// METHOD: post
+// TAG: enrollments
+// REQUEST: csvfile
+// RESPONSE: 200,EnrollmentImportResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  enroll many users from a csv file
+// DESCRIPTION:
+// The csv file needs the column "user" containing an email or a student number and
+// can have the columns "role" (student, tutor, admin), "group" (id or description),
+// "first_name" and "last_name". Lines which do not match an existing user are reported.
+// Members keep their role if a line states none. Lowering a role is rejected unless
+// "allow_downgrade" is set. If "create_missing" is set, accounts for unknown emails are
+// created from the names and the users are invited to choose a password. All valid lines
+// are applied in one transaction unless "dry_run" is set.
+func (rs *CourseResource) ImportEnrollmentsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	delimiter := []rune(helper.StringFromURL(r, "delimiter", ","))
+	if len(delimiter) != 1 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("delimiter must be a single character")))
+		return
+	}
+
+	opts := EnrollmentImportOptions{
+		CreateMissing:  helper.BoolFromURL(r, "create_missing", false),
+		AllowDowngrade: helper.BoolFromURL(r, "allow_downgrade", false),
+	}
+	dryRun := helper.BoolFromURL(r, "dry_run", false)
+
+	file, _, err := r.FormFile("file_data")
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	defer file.Close()
+
+	rows, err := ParseEnrollmentImportCSV(file, delimiter[0])
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	enrollmentImport, err := BuildEnrollmentImport(rs.Stores, course, rows, opts)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	applied := false
+	if !dryRun {
+		invitations, err := enrollmentImport.Apply(rs.Stores, course)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		applied = true
+
+		for _, msg := range invitations {
+			email.OutgoingEmailsChannel <- msg
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	if err := render.Render(w, r, newEnrollmentImportResponse(enrollmentImport, dryRun, applied)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
 // IndexEnrollmentsHandler is public endpoint for
 // URL: /courses/{course_id}/enrollments
 // URLPARAM: course_id,integer
@@ -556,6 +690,95 @@ func (rs *CourseResource) PointsHandler(w http.ResponseWriter, r *http.Request)
 	render.Status(r, http.StatusOK)
 }
 
+// AdmissionsHandler is public endpoint for
+// URL: /courses/{course_id}/admissions
+// URLPARAM: course_id,integer
+// QUERYPARAM: group_id,integer
+// METHOD: get
+// TAG: courses
+// RESPONSE: 200,AdmissionResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get the admission status of all students in the course
+// DESCRIPTION:
+// The admission rules of a course are the overall percentage of points, the minimal
+// percentage of each sheet, a number of sheets with the lowest percentage which are
+// dropped, the attendance, the number of presentations and exams which have to be
+// passed. Each rule in effect is reported with a reason.
+func (rs *CourseResource) AdmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
+
+	var (
+		students []model.UserCourse
+		err      error
+	)
+	if filterGroupID == 0 {
+		students, err = rs.Stores.Course.EnrolledUsers(course.ID,
+			[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+	} else {
+		students, err = rs.Stores.Group.EnrolledUsers(course.ID, filterGroupID,
+			[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	admission, err := NewAdmissionEvaluator(rs.Stores, course, NowUTC())
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	list := []render.Renderer{}
+	for k := range students {
+		list = append(list, newAdmissionResponse(&students[k], admission.Evaluate(students[k].ID)))
+	}
+
+	if err := render.RenderList(w, r, list); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// GetMyAdmissionHandler is public endpoint for
+// URL: /courses/{course_id}/admissions/own
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: courses
+// RESPONSE: 200,AdmissionResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get the own admission status in the course
+func (rs *CourseResource) GetMyAdmissionHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	enrollment, err := rs.Stores.Course.GetUserEnrollment(course.ID, accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	admission, err := NewAdmissionEvaluator(rs.Stores, course, NowUTC())
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, newAdmissionResponse(enrollment, admission.Evaluate(enrollment.ID))); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
 // BidsHandler is public endpoint for
 // URL: /courses/{course_id}/bids
 // URLPARAM: course_id,integer
@@ -0,0 +1,555 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+	"gopkg.in/yaml.v2"
+)
+
+// CourseArchiveVersion is the version of the archive format written by
+// ExportCourseArchive.
+const CourseArchiveVersion = 1
+
+// Manifests of a course archive. Export writes YAML, import reads either.
+const (
+	CourseArchiveManifestYAML = "manifest.yml"
+	CourseArchiveManifestJSON = "manifest.json"
+)
+
+// CourseArchive is the manifest of a course archive. A course archive is a
+// directory (or a zip file of it) containing the manifest and all files it
+// refers to. File paths are relative to the archive.
+type CourseArchive struct {
+	Version   int                     `yaml:"version" json:"version"`
+	Course    CourseArchiveCourse     `yaml:"course" json:"course"`
+	Sheets    []CourseArchiveSheet    `yaml:"sheets" json:"sheets"`
+	Materials []CourseArchiveMaterial `yaml:"materials" json:"materials"`
+	Exams     []CourseArchiveExam     `yaml:"exams" json:"exams"`
+}
+
+// CourseArchiveCourse holds the settings of the course.
+type CourseArchiveCourse struct {
+	Name                    string     `yaml:"name" json:"name"`
+	Description             string     `yaml:"description" json:"description"`
+	BeginsAt                time.Time  `yaml:"begins_at" json:"begins_at"`
+	EndsAt                  time.Time  `yaml:"ends_at" json:"ends_at"`
+	RequiredPercentage      int        `yaml:"required_percentage" json:"required_percentage"`
+	RequiredAttendance      int        `yaml:"required_attendance" json:"required_attendance"`
+	RequiredPresentations   int        `yaml:"required_presentations" json:"required_presentations"`
+	DroppableSheets         int        `yaml:"droppable_sheets" json:"droppable_sheets"`
+	GroupEnrollmentMode     int        `yaml:"group_enrollment_mode" json:"group_enrollment_mode"`
+	GroupEnrollmentBeginsAt *time.Time `yaml:"group_enrollment_begins_at,omitempty" json:"group_enrollment_begins_at,omitempty"`
+	GroupEnrollmentEndsAt   *time.Time `yaml:"group_enrollment_ends_at,omitempty" json:"group_enrollment_ends_at,omitempty"`
+}
+
+// CourseArchiveSheet is a sheet with its tasks. Sheets are identified by name.
+type CourseArchiveSheet struct {
+	Name               string              `yaml:"name" json:"name"`
+	PublishAt          time.Time           `yaml:"publish_at" json:"publish_at"`
+	DueAt              time.Time           `yaml:"due_at" json:"due_at"`
+	Anonymous          bool                `yaml:"anonymous" json:"anonymous"`
+	RequiredPercentage int                 `yaml:"required_percentage" json:"required_percentage"`
+	File               string              `yaml:"file,omitempty" json:"file,omitempty"`
+	Tasks              []CourseArchiveTask `yaml:"tasks" json:"tasks"`
+}
+
+// CourseArchiveTask is a task with its test files. Tasks are identified by
+// name within their sheet.
+type CourseArchiveTask struct {
+	Name               string `yaml:"name" json:"name"`
+	MaxPoints          int    `yaml:"max_points" json:"max_points"`
+	PublicDockerImage  string `yaml:"public_docker_image,omitempty" json:"public_docker_image,omitempty"`
+	PrivateDockerImage string `yaml:"private_docker_image,omitempty" json:"private_docker_image,omitempty"`
+	PublicTests        string `yaml:"public_tests,omitempty" json:"public_tests,omitempty"`
+	PrivateTests       string `yaml:"private_tests,omitempty" json:"private_tests,omitempty"`
+}
+
+// CourseArchiveMaterial is a material of the course identified by name.
+type CourseArchiveMaterial struct {
+	Name         string    `yaml:"name" json:"name"`
+	Kind         int       `yaml:"kind" json:"kind"`
+	Filename     string    `yaml:"filename" json:"filename"`
+	PublishAt    time.Time `yaml:"publish_at" json:"publish_at"`
+	LectureAt    time.Time `yaml:"lecture_at" json:"lecture_at"`
+	RequiredRole int       `yaml:"required_role" json:"required_role"`
+	File         string    `yaml:"file,omitempty" json:"file,omitempty"`
+}
+
+// CourseArchiveExam is an exam of the course identified by name.
+type CourseArchiveExam struct {
+	Name                  string    `yaml:"name" json:"name"`
+	Description           string    `yaml:"description" json:"description"`
+	ExamTime              time.Time `yaml:"exam_time" json:"exam_time"`
+	AdmissionPrerequisite bool      `yaml:"admission_prerequisite" json:"admission_prerequisite"`
+}
+
+func timePtrFromNull(t null.Time) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	value := t.Time.UTC()
+	return &value
+}
+
+func nullFromTimePtr(t *time.Time) null.Time {
+	if t == nil {
+		return null.Time{}
+	}
+	return null.TimeFrom(*t)
+}
+
+func nullFromString(s string) null.String {
+	if s == "" {
+		return null.String{}
+	}
+	return null.StringFrom(s)
+}
+
+// exportFile copies a file into the archive and returns its path relative to
+// the archive or an empty string if there is no file.
+func exportFile(hnd *helper.FileHandle, dir string, name string) (string, error) {
+	if !hnd.Exists() {
+		return "", nil
+	}
+
+	name = name + filepath.Ext(hnd.Path())
+	if err := helper.CopyFile(hnd.Path(), filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// importFile copies a file referenced by the manifest into the uploads.
+func importFile(hnd *helper.FileHandle, dir string, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return fmt.Errorf("file %s points outside of the archive", name)
+	}
+	return hnd.CopyFrom(path)
+}
+
+// ExportCourseArchive writes the content of a course into a directory:
+// the manifest, sheet files, test files of tasks and materials.
+// Enrollments, groups, submissions and grades are not part of an archive.
+func ExportCourseArchive(stores *Stores, course *model.Course, dir string) error {
+	archive := CourseArchive{
+		Version: CourseArchiveVersion,
+		Course: CourseArchiveCourse{
+			Name:                    course.Name,
+			Description:             course.Description,
+			BeginsAt:                course.BeginsAt.UTC(),
+			EndsAt:                  course.EndsAt.UTC(),
+			RequiredPercentage:      course.RequiredPercentage,
+			RequiredAttendance:      course.RequiredAttendance,
+			RequiredPresentations:   course.RequiredPresentations,
+			DroppableSheets:         course.DroppableSheets,
+			GroupEnrollmentMode:     course.GroupEnrollmentMode,
+			GroupEnrollmentBeginsAt: timePtrFromNull(course.GroupEnrollmentBeginsAt),
+			GroupEnrollmentEndsAt:   timePtrFromNull(course.GroupEnrollmentEndsAt),
+		},
+		Sheets:    []CourseArchiveSheet{},
+		Materials: []CourseArchiveMaterial{},
+		Exams:     []CourseArchiveExam{},
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return err
+	}
+
+	for k, sheet := range sheets {
+		prefix := fmt.Sprintf("sheets/%02d", k+1)
+
+		entry := CourseArchiveSheet{
+			Name:               sheet.Name,
+			PublishAt:          sheet.PublishAt.UTC(),
+			DueAt:              sheet.DueAt.UTC(),
+			Anonymous:          sheet.Anonymous,
+			RequiredPercentage: sheet.RequiredPercentage,
+			Tasks:              []CourseArchiveTask{},
+		}
+
+		entry.File, err = exportFile(helper.NewSheetFileHandle(sheet.ID), dir, prefix+"/sheet")
+		if err != nil {
+			return err
+		}
+
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return err
+		}
+
+		for l, task := range tasks {
+			taskPrefix := fmt.Sprintf("%s/tasks/%02d", prefix, l+1)
+
+			taskEntry := CourseArchiveTask{
+				Name:               task.Name,
+				MaxPoints:          task.MaxPoints,
+				PublicDockerImage:  task.PublicDockerImage.ValueOrZero(),
+				PrivateDockerImage: task.PrivateDockerImage.ValueOrZero(),
+			}
+
+			taskEntry.PublicTests, err = exportFile(helper.NewPublicTestFileHandle(task.ID), dir, taskPrefix+"/public")
+			if err != nil {
+				return err
+			}
+			taskEntry.PrivateTests, err = exportFile(helper.NewPrivateTestFileHandle(task.ID), dir, taskPrefix+"/private")
+			if err != nil {
+				return err
+			}
+
+			entry.Tasks = append(entry.Tasks, taskEntry)
+		}
+
+		archive.Sheets = append(archive.Sheets, entry)
+	}
+
+	materials, err := stores.Material.MaterialsOfCourse(course.ID, int(authorize.ADMIN))
+	if err != nil {
+		return err
+	}
+
+	for k, material := range materials {
+		entry := CourseArchiveMaterial{
+			Name:         material.Name,
+			Kind:         material.Kind,
+			Filename:     material.Filename,
+			PublishAt:    material.PublishAt.UTC(),
+			LectureAt:    material.LectureAt.UTC(),
+			RequiredRole: material.RequiredRole,
+		}
+
+		entry.File, err = exportFile(helper.NewMaterialFileHandle(material.ID), dir, fmt.Sprintf("materials/%02d", k+1))
+		if err != nil {
+			return err
+		}
+
+		archive.Materials = append(archive.Materials, entry)
+	}
+
+	exams, err := stores.Exam.ExamsOfCourse(course.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, exam := range exams {
+		archive.Exams = append(archive.Exams, CourseArchiveExam{
+			Name:                  exam.Name,
+			Description:           exam.Description,
+			ExamTime:              exam.ExamTime.UTC(),
+			AdmissionPrerequisite: exam.AdmissionPrerequisite,
+		})
+	}
+
+	content, err := yaml.Marshal(&archive)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, CourseArchiveManifestYAML), content, 0644)
+}
+
+// ReadCourseArchive reads the manifest of a course archive in a directory.
+func ReadCourseArchive(dir string) (*CourseArchive, error) {
+	archive := &CourseArchive{}
+
+	if content, err := ioutil.ReadFile(filepath.Join(dir, CourseArchiveManifestYAML)); err == nil {
+		if err := yaml.Unmarshal(content, archive); err != nil {
+			return nil, err
+		}
+	} else if content, err := ioutil.ReadFile(filepath.Join(dir, CourseArchiveManifestJSON)); err == nil {
+		if err := json.Unmarshal(content, archive); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("neither %s nor %s found in %s",
+			CourseArchiveManifestYAML, CourseArchiveManifestJSON, dir)
+	}
+
+	if archive.Version != CourseArchiveVersion {
+		return nil, fmt.Errorf("archive version %d is not supported", archive.Version)
+	}
+
+	if archive.Course.Name == "" {
+		return nil, fmt.Errorf("archive has no course name")
+	}
+
+	return archive, nil
+}
+
+// ImportCourseArchive imports a course archive from a directory. The content is
+// imported into the course with the given id or, if the id is 0, into the
+// course with the name from the manifest, which is created if it does not exist.
+// Sheets, tasks, materials and exams are matched by name, so importing the
+// same archive twice does not change anything. Entries which are missing in the
+// archive are kept.
+func ImportCourseArchive(stores *Stores, dir string, courseID int64) (*model.Course, error) {
+	archive, err := ReadCourseArchive(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	course, err := findArchiveCourse(stores, archive, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	course.Name = archive.Course.Name
+	course.Description = archive.Course.Description
+	course.BeginsAt = archive.Course.BeginsAt
+	course.EndsAt = archive.Course.EndsAt
+	course.RequiredPercentage = archive.Course.RequiredPercentage
+	course.RequiredAttendance = archive.Course.RequiredAttendance
+	course.RequiredPresentations = archive.Course.RequiredPresentations
+	course.DroppableSheets = archive.Course.DroppableSheets
+	course.GroupEnrollmentMode = archive.Course.GroupEnrollmentMode
+	course.GroupEnrollmentBeginsAt = nullFromTimePtr(archive.Course.GroupEnrollmentBeginsAt)
+	course.GroupEnrollmentEndsAt = nullFromTimePtr(archive.Course.GroupEnrollmentEndsAt)
+
+	if course.ID == 0 {
+		course, err = stores.Course.Create(course)
+	} else {
+		err = stores.Course.Update(course)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := importArchiveSheets(stores, archive, dir, course.ID); err != nil {
+		return nil, err
+	}
+	if err := importArchiveMaterials(stores, archive, dir, course.ID); err != nil {
+		return nil, err
+	}
+	if err := importArchiveExams(stores, archive, course.ID); err != nil {
+		return nil, err
+	}
+
+	return course, nil
+}
+
+func findArchiveCourse(stores *Stores, archive *CourseArchive, courseID int64) (*model.Course, error) {
+	if courseID != 0 {
+		return stores.Course.Get(courseID)
+	}
+
+	courses, err := stores.Course.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var found *model.Course
+	for k := range courses {
+		if courses[k].Name != archive.Course.Name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("there are several courses named %s", archive.Course.Name)
+		}
+		found = &courses[k]
+	}
+
+	if found == nil {
+		return &model.Course{}, nil
+	}
+	return found, nil
+}
+
+func importArchiveSheets(stores *Stores, archive *CourseArchive, dir string, courseID int64) error {
+	sheets, err := stores.Sheet.SheetsOfCourse(courseID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*model.Sheet)
+	for k := range sheets {
+		existing[sheets[k].Name] = &sheets[k]
+	}
+
+	for _, entry := range archive.Sheets {
+		sheet, ok := existing[entry.Name]
+		if !ok {
+			sheet = &model.Sheet{}
+		}
+
+		sheet.Name = entry.Name
+		sheet.PublishAt = entry.PublishAt
+		sheet.DueAt = entry.DueAt
+		sheet.Anonymous = entry.Anonymous
+		sheet.RequiredPercentage = entry.RequiredPercentage
+
+		if ok {
+			err = stores.Sheet.Update(sheet)
+		} else {
+			sheet, err = stores.Sheet.Create(sheet, courseID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := importFile(helper.NewSheetFileHandle(sheet.ID), dir, entry.File); err != nil {
+			return err
+		}
+
+		if err := importArchiveTasks(stores, entry, dir, sheet.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importArchiveTasks(stores *Stores, entry CourseArchiveSheet, dir string, sheetID int64) error {
+	tasks, err := stores.Task.TasksOfSheet(sheetID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*model.Task)
+	for k := range tasks {
+		existing[tasks[k].Name] = &tasks[k]
+	}
+
+	for _, taskEntry := range entry.Tasks {
+		task, ok := existing[taskEntry.Name]
+		if !ok {
+			task = &model.Task{}
+		}
+
+		task.Name = taskEntry.Name
+		task.MaxPoints = taskEntry.MaxPoints
+		task.PublicDockerImage = nullFromString(taskEntry.PublicDockerImage)
+		task.PrivateDockerImage = nullFromString(taskEntry.PrivateDockerImage)
+
+		if ok {
+			err = stores.Task.Update(task)
+		} else {
+			task, err = stores.Task.Create(task, sheetID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := importFile(helper.NewPublicTestFileHandle(task.ID), dir, taskEntry.PublicTests); err != nil {
+			return err
+		}
+		if err := importFile(helper.NewPrivateTestFileHandle(task.ID), dir, taskEntry.PrivateTests); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importArchiveMaterials(stores *Stores, archive *CourseArchive, dir string, courseID int64) error {
+	materials, err := stores.Material.MaterialsOfCourse(courseID, int(authorize.ADMIN))
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*model.Material)
+	for k := range materials {
+		existing[materials[k].Name] = &materials[k]
+	}
+
+	for _, entry := range archive.Materials {
+		material, ok := existing[entry.Name]
+		if !ok {
+			material = &model.Material{}
+		}
+
+		material.Name = entry.Name
+		material.Kind = entry.Kind
+		material.Filename = entry.Filename
+		material.PublishAt = entry.PublishAt
+		material.LectureAt = entry.LectureAt
+		material.RequiredRole = entry.RequiredRole
+
+		if ok {
+			err = stores.Material.Update(material)
+		} else {
+			material, err = stores.Material.Create(material, courseID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := importFile(helper.NewMaterialFileHandle(material.ID), dir, entry.File); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func importArchiveExams(stores *Stores, archive *CourseArchive, courseID int64) error {
+	exams, err := stores.Exam.ExamsOfCourse(courseID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]*model.Exam)
+	for k := range exams {
+		existing[exams[k].Name] = &exams[k]
+	}
+
+	for _, entry := range archive.Exams {
+		exam, ok := existing[entry.Name]
+		if !ok {
+			exam = &model.Exam{CourseID: courseID}
+		}
+
+		exam.Name = entry.Name
+		exam.Description = entry.Description
+		exam.ExamTime = entry.ExamTime
+		exam.AdmissionPrerequisite = entry.AdmissionPrerequisite
+
+		if ok {
+			err = stores.Exam.Update(exam)
+		} else {
+			_, err = stores.Exam.Create(exam)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCourseArchive(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var (
+		stores *Stores
+		dir    string
+	)
+
+	g.Describe("CourseArchive", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+
+			var err error
+			dir, err = ioutil.TempDir("", "infomark-course-archive")
+			g.Assert(err).Equal(nil)
+		})
+
+		g.It("Should export the content of a course", func() {
+			course, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+
+			err = ExportCourseArchive(stores, course, dir)
+			g.Assert(err).Equal(nil)
+
+			archive, err := ReadCourseArchive(dir)
+			g.Assert(err).Equal(nil)
+			g.Assert(archive.Course.Name).Equal(course.Name)
+
+			sheets, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(archive.Sheets)).Equal(len(sheets))
+
+			tasks, err := stores.Task.TasksOfSheet(sheets[0].ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(archive.Sheets[0].Tasks)).Equal(len(tasks))
+		})
+
+		g.It("Should import an archive idempotently", func() {
+			course, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+
+			err = ExportCourseArchive(stores, course, dir)
+			g.Assert(err).Equal(nil)
+
+			// import as a new course
+			archive, err := ReadCourseArchive(dir)
+			g.Assert(err).Equal(nil)
+			archive.Course.Name = "Imported"
+			content, err := yaml.Marshal(archive)
+			g.Assert(err).Equal(nil)
+			err = ioutil.WriteFile(filepath.Join(dir, CourseArchiveManifestYAML), content, 0644)
+			g.Assert(err).Equal(nil)
+
+			coursesBefore, err := stores.Course.GetAll()
+			g.Assert(err).Equal(nil)
+
+			imported, err := ImportCourseArchive(stores, dir, 0)
+			g.Assert(err).Equal(nil)
+			g.Assert(imported.Name).Equal("Imported")
+
+			sheets, err := stores.Sheet.SheetsOfCourse(imported.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sheets)).Equal(len(archive.Sheets))
+
+			exams, err := stores.Exam.ExamsOfCourse(imported.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(exams)).Equal(len(archive.Exams))
+
+			// importing again changes nothing
+			again, err := ImportCourseArchive(stores, dir, 0)
+			g.Assert(err).Equal(nil)
+			g.Assert(again.ID).Equal(imported.ID)
+
+			coursesAfter, err := stores.Course.GetAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(coursesAfter)).Equal(len(coursesBefore) + 1)
+
+			sheetsAgain, err := stores.Sheet.SheetsOfCourse(imported.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sheetsAgain)).Equal(len(sheets))
+
+			tasks, err := stores.Task.TasksOfSheet(sheetsAgain[0].ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(tasks)).Equal(len(archive.Sheets[0].Tasks))
+		})
+
+		g.It("Should reject unknown archive versions", func() {
+			err := ioutil.WriteFile(filepath.Join(dir, CourseArchiveManifestJSON),
+				[]byte(`{"version": 99, "course": {"name": "Info 2"}}`), 0644)
+			g.Assert(err).Equal(nil)
+
+			_, err = ReadCourseArchive(dir)
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.AfterEach(func() {
+			os.RemoveAll(dir)
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -0,0 +1,178 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// shiftDays moves a point in time by whole days to keep the time of the day.
+func shiftDays(t time.Time, days int) time.Time {
+	return t.AddDate(0, 0, days)
+}
+
+func shiftNullDays(t null.Time, days int) null.Time {
+	if !t.Valid {
+		return t
+	}
+	return null.TimeFrom(shiftDays(t.Time, days))
+}
+
+// CloneCourse deep-copies a course with its sheets, tasks, test files, materials
+// and exams into a new course. All dates are shifted by the given number of days.
+// Enrollments, groups, submissions and grades are left out. If anything fails,
+// the partial copy is removed again, including the copied files.
+func CloneCourse(stores *Stores, course *model.Course, name string, offsetDays int) (clone *model.Course, err error) {
+	var (
+		sheetIDs    []int64
+		materialIDs []int64
+		files       []*helper.FileHandle
+	)
+
+	defer func() {
+		if err == nil || clone == nil {
+			return
+		}
+		for _, file := range files {
+			if file.Exists() {
+				file.Delete()
+			}
+		}
+		// sheets, tasks and materials are only linked to the course
+		for _, sheetID := range sheetIDs {
+			if tasks, terr := stores.Task.TasksOfSheet(sheetID); terr == nil {
+				for _, task := range tasks {
+					stores.Task.Delete(task.ID)
+				}
+			}
+			stores.Sheet.Delete(sheetID)
+		}
+		for _, materialID := range materialIDs {
+			stores.Material.Delete(materialID)
+		}
+		stores.Course.Delete(clone.ID)
+		clone = nil
+	}()
+
+	copied := *course
+	copied.Name = name
+	copied.BeginsAt = shiftDays(course.BeginsAt, offsetDays)
+	copied.EndsAt = shiftDays(course.EndsAt, offsetDays)
+	copied.GroupEnrollmentBeginsAt = shiftNullDays(course.GroupEnrollmentBeginsAt, offsetDays)
+	copied.GroupEnrollmentEndsAt = shiftNullDays(course.GroupEnrollmentEndsAt, offsetDays)
+
+	clone, err = stores.Course.Create(&copied)
+	if err != nil {
+		return nil, err
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return clone, err
+	}
+
+	for _, sheet := range sheets {
+		copiedSheet := sheet
+		copiedSheet.PublishAt = shiftDays(sheet.PublishAt, offsetDays)
+		copiedSheet.DueAt = shiftDays(sheet.DueAt, offsetDays)
+
+		newSheet, err := stores.Sheet.Create(&copiedSheet, clone.ID)
+		if err != nil {
+			return clone, err
+		}
+		sheetIDs = append(sheetIDs, newSheet.ID)
+
+		if err := copyFileIfExists(&files, helper.NewSheetFileHandle(sheet.ID), helper.NewSheetFileHandle(newSheet.ID)); err != nil {
+			return clone, err
+		}
+
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return clone, err
+		}
+
+		for _, task := range tasks {
+			copiedTask := task
+			newTask, err := stores.Task.Create(&copiedTask, newSheet.ID)
+			if err != nil {
+				return clone, err
+			}
+
+			if err := copyFileIfExists(&files, helper.NewPublicTestFileHandle(task.ID), helper.NewPublicTestFileHandle(newTask.ID)); err != nil {
+				return clone, err
+			}
+			if err := copyFileIfExists(&files, helper.NewPrivateTestFileHandle(task.ID), helper.NewPrivateTestFileHandle(newTask.ID)); err != nil {
+				return clone, err
+			}
+		}
+	}
+
+	materials, err := stores.Material.MaterialsOfCourse(course.ID, int(authorize.ADMIN))
+	if err != nil {
+		return clone, err
+	}
+
+	for _, material := range materials {
+		copiedMaterial := material
+		copiedMaterial.PublishAt = shiftDays(material.PublishAt, offsetDays)
+		copiedMaterial.LectureAt = shiftDays(material.LectureAt, offsetDays)
+
+		newMaterial, err := stores.Material.Create(&copiedMaterial, clone.ID)
+		if err != nil {
+			return clone, err
+		}
+		materialIDs = append(materialIDs, newMaterial.ID)
+
+		if err := copyFileIfExists(&files, helper.NewMaterialFileHandle(material.ID), helper.NewMaterialFileHandle(newMaterial.ID)); err != nil {
+			return clone, err
+		}
+	}
+
+	exams, err := stores.Exam.ExamsOfCourse(course.ID)
+	if err != nil {
+		return clone, err
+	}
+
+	for _, exam := range exams {
+		copiedExam := exam
+		copiedExam.CourseID = clone.ID
+		copiedExam.ExamTime = shiftDays(exam.ExamTime, offsetDays)
+
+		if _, err := stores.Exam.Create(&copiedExam); err != nil {
+			return clone, err
+		}
+	}
+
+	return clone, nil
+}
+
+// copyFileIfExists copies a file and records the copy to remove it on failure.
+func copyFileIfExists(files *[]*helper.FileHandle, src *helper.FileHandle, dst *helper.FileHandle) error {
+	if !src.Exists() {
+		return nil
+	}
+	*files = append(*files, dst)
+	return src.CopyTo(dst)
+}
@@ -25,15 +25,23 @@ import (
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // CourseRequest is the request payload for course management.
 type CourseRequest struct {
-	Name               string    `json:"name" example:"Info 2"`
-	Description        string    `json:"description" example:"An example course."`
-	BeginsAt           time.Time `json:"begins_at" example:"auto"`
-	EndsAt             time.Time `json:"ends_at" example:"auto"`
-	RequiredPercentage int       `json:"required_percentage" example:"80"`
+	Name                  string    `json:"name" example:"Info 2"`
+	Description           string    `json:"description" example:"An example course."`
+	BeginsAt              time.Time `json:"begins_at" example:"auto"`
+	EndsAt                time.Time `json:"ends_at" example:"auto"`
+	RequiredPercentage    int       `json:"required_percentage" example:"80"`
+	RequiredAttendance    int       `json:"required_attendance" example:"75" minval:"0" maxval:"100"`
+	RequiredPresentations int       `json:"required_presentations" example:"2" minval:"0"`
+	DroppableSheets       int       `json:"droppable_sheets" example:"1" minval:"0"`
+
+	GroupEnrollmentMode     int       `json:"group_enrollment_mode" example:"1" minval:"0" maxval:"2"`
+	GroupEnrollmentBeginsAt null.Time `json:"group_enrollment_begins_at" example:"auto" required:"false"`
+	GroupEnrollmentEndsAt   null.Time `json:"group_enrollment_ends_at" example:"auto" required:"false"`
 }
 
 // Bind preprocesses a CourseRequest.
@@ -52,6 +60,11 @@ func (body *CourseRequest) Validate() error {
 		return errors.New("ends_at should be later than begins_at")
 	}
 
+	if body.GroupEnrollmentBeginsAt.Valid && body.GroupEnrollmentEndsAt.Valid &&
+		body.GroupEnrollmentEndsAt.Time.Before(body.GroupEnrollmentBeginsAt.Time) {
+		return errors.New("group_enrollment_ends_at should be later than group_enrollment_begins_at")
+	}
+
 	return validation.ValidateStruct(body,
 		validation.Field(
 			&body.Name,
@@ -73,6 +86,44 @@ func (body *CourseRequest) Validate() error {
 			&body.RequiredPercentage,
 			validation.Min(0),
 		),
+		validation.Field(
+			&body.RequiredAttendance,
+			validation.Min(0),
+			validation.Max(100),
+		),
+		validation.Field(
+			&body.RequiredPresentations,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.DroppableSheets,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.GroupEnrollmentMode,
+			validation.Min(GroupEnrollmentByAdmin),
+			validation.Max(GroupEnrollmentWaitlist),
+		),
+	)
+}
+
+// CourseCloneRequest is the request payload to clone a course.
+type CourseCloneRequest struct {
+	Name       string `json:"name" example:"Info 2 (summer term)"`
+	OffsetDays int    `json:"offset_days" example:"182"`
+}
+
+// Bind preprocesses a CourseCloneRequest.
+func (body *CourseCloneRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"clone\" data")
+	}
+
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Name,
+			validation.Required,
+		),
 	)
 }
 
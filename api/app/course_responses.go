@@ -30,12 +30,19 @@ import (
 
 // CourseResponse is the response payload for course management.
 type CourseResponse struct {
-	ID                 int64     `json:"id" example:"1"`
-	Name               string    `json:"name" example:"Info2"`
-	Description        string    `json:"description" example:"Some course description here"`
-	BeginsAt           time.Time `json:"begins_at" example:"auto"`
-	EndsAt             time.Time `json:"ends_at" example:"auto"`
-	RequiredPercentage int       `json:"required_percentage" example:"80"`
+	ID                    int64     `json:"id" example:"1"`
+	Name                  string    `json:"name" example:"Info2"`
+	Description           string    `json:"description" example:"Some course description here"`
+	BeginsAt              time.Time `json:"begins_at" example:"auto"`
+	EndsAt                time.Time `json:"ends_at" example:"auto"`
+	RequiredPercentage    int       `json:"required_percentage" example:"80"`
+	RequiredAttendance    int       `json:"required_attendance" example:"75"`
+	RequiredPresentations int       `json:"required_presentations" example:"2"`
+	DroppableSheets       int       `json:"droppable_sheets" example:"1"`
+
+	GroupEnrollmentMode     int       `json:"group_enrollment_mode" example:"1" minval:"0" maxval:"2"`
+	GroupEnrollmentBeginsAt null.Time `json:"group_enrollment_begins_at" example:"auto"`
+	GroupEnrollmentEndsAt   null.Time `json:"group_enrollment_ends_at" example:"auto"`
 }
 
 // Render post-processes a CourseResponse.
@@ -46,12 +53,19 @@ func (body *CourseResponse) Render(w http.ResponseWriter, r *http.Request) error
 // newCourseResponse creates a response from a course model.
 func (rs *CourseResource) newCourseResponse(p *model.Course) *CourseResponse {
 	return &CourseResponse{
-		ID:                 p.ID,
-		Name:               p.Name,
-		Description:        p.Description,
-		BeginsAt:           p.BeginsAt,
-		EndsAt:             p.EndsAt,
-		RequiredPercentage: p.RequiredPercentage,
+		ID:                    p.ID,
+		Name:                  p.Name,
+		Description:           p.Description,
+		BeginsAt:              p.BeginsAt,
+		EndsAt:                p.EndsAt,
+		RequiredPercentage:    p.RequiredPercentage,
+		RequiredAttendance:    p.RequiredAttendance,
+		RequiredPresentations: p.RequiredPresentations,
+		DroppableSheets:       p.DroppableSheets,
+
+		GroupEnrollmentMode:     p.GroupEnrollmentMode,
+		GroupEnrollmentBeginsAt: p.GroupEnrollmentBeginsAt,
+		GroupEnrollmentEndsAt:   p.GroupEnrollmentEndsAt,
 	}
 }
 
@@ -190,3 +204,61 @@ func newEnrollmentListResponse(enrollments []model.UserCourse) []render.Renderer
 
 	return list
 }
+
+// AdmissionResponse is the admission status of a student with the reasons
+// for each rule of the course.
+type AdmissionResponse struct {
+	UserID        int64             `json:"user_id" example:"112"`
+	FirstName     string            `json:"first_name" example:"Max"`
+	LastName      string            `json:"last_name" example:"Mustermensch"`
+	Email         string            `json:"email" example:"test@uni-tuebingen.de"`
+	StudentNumber string            `json:"student_number" example:"0815"`
+	Admitted      bool              `json:"admitted" example:"false"`
+	Percentage    float64           `json:"percentage" example:"47.5"`
+	DroppedSheets []int64           `json:"dropped_sheets" example:"3"`
+	Reasons       []AdmissionReason `json:"reasons"`
+}
+
+// Render post-processes an AdmissionResponse.
+func (body *AdmissionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newAdmissionResponse creates a response from the admission status of a student.
+func newAdmissionResponse(p *model.UserCourse, status AdmissionStatus) *AdmissionResponse {
+	return &AdmissionResponse{
+		UserID:        p.ID,
+		FirstName:     p.FirstName,
+		LastName:      p.LastName,
+		Email:         p.Email,
+		StudentNumber: p.StudentNumber,
+		Admitted:      status.Admitted,
+		Percentage:    status.Percentage,
+		DroppedSheets: status.DroppedSheets,
+		Reasons:       status.Reasons,
+	}
+}
+
+// EnrollmentImportResponse lists the enrollments of an import and all lines
+// which have been rejected.
+type EnrollmentImportResponse struct {
+	DryRun  bool                     `json:"dry_run" example:"true"`
+	Applied bool                     `json:"applied" example:"false"`
+	Changes []EnrollmentImportChange `json:"changes"`
+	Errors  []EnrollmentImportError  `json:"errors"`
+}
+
+// newEnrollmentImportResponse creates a response from an enrollment import.
+func newEnrollmentImportResponse(p *EnrollmentImport, dryRun bool, applied bool) *EnrollmentImportResponse {
+	return &EnrollmentImportResponse{
+		DryRun:  dryRun,
+		Applied: applied,
+		Changes: p.Changes,
+		Errors:  p.Errors,
+	}
+}
+
+// Render post-processes an EnrollmentImportResponse.
+func (body *EnrollmentImportResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
@@ -373,6 +373,49 @@ func TestCourse(t *testing.T) {
 			g.Assert(len(entriesAfter)).Equal(len(entriesBefore) - 1)
 		})
 
+		g.It("Should clone a course with shifted dates", func() {
+			w := tape.Post("/api/v1/courses/1/clone", helper.H{"name": "Clone", "offset_days": 7}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/clone", helper.H{"offset_days": 7}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/clone", helper.H{"name": "Clone", "offset_days": 7}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			clone := &CourseResponse{}
+			err := json.NewDecoder(w.Body).Decode(clone)
+			g.Assert(err).Equal(nil)
+			g.Assert(clone.Name).Equal("Clone")
+
+			course, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(clone.BeginsAt.Equal(course.BeginsAt.AddDate(0, 0, 7))).IsTrue()
+
+			sheets, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			clonedSheets, err := stores.Sheet.SheetsOfCourse(clone.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(clonedSheets)).Equal(len(sheets))
+			g.Assert(clonedSheets[0].DueAt.Equal(sheets[0].DueAt.AddDate(0, 0, 7))).IsTrue()
+
+			tasks, err := stores.Task.TasksOfSheet(sheets[0].ID)
+			g.Assert(err).Equal(nil)
+			clonedTasks, err := stores.Task.TasksOfSheet(clonedSheets[0].ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(clonedTasks)).Equal(len(tasks))
+			g.Assert(clonedTasks[0].PublicDockerImage).Equal(tasks[0].PublicDockerImage)
+
+			enrollments, err := stores.Course.EnrolledUsers(clone.ID,
+				[]string{"0", "1", "2"}, "%%", "%%", "%%", "%%", "%%")
+			g.Assert(err).Equal(nil)
+			g.Assert(len(enrollments)).Equal(0)
+
+			// like creating a course, cloning requires root
+			w = tape.Post("/api/v1/courses/1/clone", helper.H{"name": "Clone", "offset_days": 7}, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
 		g.It("Non-Global root enroll as students", func() {
 			courseID := int64(1)
 
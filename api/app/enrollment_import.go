@@ -0,0 +1,380 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Columns of an enrollment import. Only "user" is required. It contains either
+// the email or the student number. The column "role" is student, tutor or admin.
+// Without a role, new members become students and members keep their role. The
+// column "group" is either the id or the description of a group. The names are
+// used to create missing accounts.
+const (
+	EnrollmentImportColumnUser      = "user"
+	EnrollmentImportColumnRole      = "role"
+	EnrollmentImportColumnGroup     = "group"
+	EnrollmentImportColumnFirstName = "first_name"
+	EnrollmentImportColumnLastName  = "last_name"
+)
+
+// EnrollmentImportOptions controls whether accounts of unknown emails are
+// created and whether roles of members may be lowered. Created accounts receive
+// an invitation to choose a password.
+type EnrollmentImportOptions struct {
+	CreateMissing  bool
+	AllowDowngrade bool
+}
+
+// EnrollmentImportRow is a single parsed line of an enrollment import.
+type EnrollmentImportRow struct {
+	Line      int
+	User      string
+	Role      string
+	Group     string
+	FirstName string
+	LastName  string
+}
+
+// EnrollmentImportError describes why a line of an enrollment import has been
+// rejected.
+type EnrollmentImportError struct {
+	Line    int    `json:"line" example:"3"`
+	Message string `json:"message" example:"user '0815' does not exist"`
+}
+
+// EnrollmentImportChange describes how a line of an enrollment import changes
+// the enrollments of the course.
+type EnrollmentImportChange struct {
+	Line      int    `json:"line" example:"2"`
+	UserID    int64  `json:"user_id" example:"112"`
+	UserEmail string `json:"user_email" example:"test@uni-tuebingen.de"`
+	Created   bool   `json:"created" example:"false"`
+	OldRole   int    `json:"old_role" example:"-1"`
+	NewRole   int    `json:"new_role" example:"0"`
+	GroupID   int64  `json:"group_id" example:"3"`
+
+	user *model.User
+}
+
+// EnrollmentImport is the validated result of an enrollment import. Unlike a
+// grade import, the valid lines are applied even if other lines are rejected.
+type EnrollmentImport struct {
+	Changes []EnrollmentImportChange
+	Errors  []EnrollmentImportError
+}
+
+func (ei *EnrollmentImport) reject(line int, format string, args ...interface{}) {
+	ei.Errors = append(ei.Errors, EnrollmentImportError{
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// ParseEnrollmentImportCSV reads the rows of an enrollment import. The first
+// line must be a header naming at least the column "user".
+// Line numbers refer to the lines of the file including the header.
+func ParseEnrollmentImportCSV(r io.Reader, delimiter rune) ([]EnrollmentImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	column2pos := make(map[string]int)
+	for k, name := range header {
+		column2pos[strings.ToLower(strings.TrimSpace(name))] = k
+	}
+	if _, ok := column2pos[EnrollmentImportColumnUser]; !ok {
+		return nil, fmt.Errorf("missing column '%s' in header", EnrollmentImportColumnUser)
+	}
+
+	rows := []EnrollmentImportRow{}
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(column string) string {
+			pos, ok := column2pos[column]
+			if !ok || pos >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[pos])
+		}
+
+		rows = append(rows, EnrollmentImportRow{
+			Line:      line,
+			User:      value(EnrollmentImportColumnUser),
+			Role:      value(EnrollmentImportColumnRole),
+			Group:     value(EnrollmentImportColumnGroup),
+			FirstName: value(EnrollmentImportColumnFirstName),
+			LastName:  value(EnrollmentImportColumnLastName),
+		})
+	}
+
+	return rows, nil
+}
+
+// enrollmentRoleNames are the names of the course roles by their number.
+var enrollmentRoleNames = []string{"student", "tutor", "admin"}
+
+// parseEnrollmentRole accepts the names and the numbers of course roles.
+func parseEnrollmentRole(role string) (int, bool) {
+	switch strings.ToLower(role) {
+	case "", "student", "0":
+		return int(authorize.STUDENT), true
+	case "tutor", "1":
+		return int(authorize.TUTOR), true
+	case "admin", "2":
+		return int(authorize.ADMIN), true
+	}
+	return 0, false
+}
+
+// BuildEnrollmentImport matches all rows to existing users and groups of the
+// course. Nothing is written to the database.
+func BuildEnrollmentImport(stores *Stores, course *model.Course, rows []EnrollmentImportRow, opts EnrollmentImportOptions) (*EnrollmentImport, error) {
+	enrolled, err := stores.Course.EnrolledUsers(course.ID,
+		[]string{"0", "1", "2"}, "%%", "%%", "%%", "%%", "%%")
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make(map[int64]int)
+	for _, user := range enrolled {
+		roles[user.ID] = int(user.Role)
+	}
+
+	groups, err := stores.Group.GroupsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnrollmentImport{
+		Changes: []EnrollmentImportChange{},
+		Errors:  []EnrollmentImportError{},
+	}
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		if row.User == "" {
+			result.reject(row.Line, "user is required")
+			continue
+		}
+
+		role, ok := parseEnrollmentRole(row.Role)
+		if !ok {
+			result.reject(row.Line, "role '%s' must be one of 'student', 'tutor', 'admin'", row.Role)
+			continue
+		}
+
+		var groupID int64
+		if row.Group != "" {
+			matches := 0
+			for _, group := range groups {
+				if strconv.FormatInt(group.ID, 10) == row.Group || strings.EqualFold(group.Description, row.Group) {
+					groupID = group.ID
+					matches++
+				}
+			}
+			if matches == 0 {
+				result.reject(row.Line, "group '%s' does not exist in the course", row.Group)
+				continue
+			}
+			if matches > 1 {
+				result.reject(row.Line, "group '%s' is ambiguous, use the group id", row.Group)
+				continue
+			}
+		}
+
+		key := strings.ToLower(row.User)
+		if previous, exists := seen[key]; exists {
+			result.reject(row.Line, "duplicate of line %d", previous)
+			continue
+		}
+		seen[key] = row.Line
+
+		change := EnrollmentImportChange{
+			Line:    row.Line,
+			OldRole: -1,
+			NewRole: role,
+			GroupID: groupID,
+		}
+
+		if strings.Contains(row.User, "@") {
+			user, err := stores.User.FindByEmail(row.User)
+			switch {
+			case err == nil:
+				change.user = user
+			case err != sql.ErrNoRows:
+				return nil, err
+			case !opts.CreateMissing:
+				result.reject(row.Line, "user '%s' does not exist", row.User)
+				continue
+			case row.FirstName == "" || row.LastName == "":
+				result.reject(row.Line, "first_name and last_name are required to create an account for '%s'", row.User)
+				continue
+			default:
+				change.Created = true
+				change.user = &model.User{
+					FirstName: row.FirstName,
+					LastName:  row.LastName,
+					Email:     row.User,
+					Language:  "en",
+				}
+			}
+		} else {
+			users, err := stores.User.FindByStudentNumber(row.User)
+			if err != nil {
+				return nil, err
+			}
+			if len(users) == 0 {
+				result.reject(row.Line, "user '%s' does not exist", row.User)
+				continue
+			}
+			if len(users) > 1 {
+				result.reject(row.Line, "student number '%s' is ambiguous, use the email", row.User)
+				continue
+			}
+			change.user = &users[0]
+		}
+
+		change.UserID = change.user.ID
+		change.UserEmail = change.user.Email
+		if oldRole, ok := roles[change.UserID]; ok && !change.Created {
+			change.OldRole = oldRole
+			// members keep their role unless the file states another one
+			if row.Role == "" {
+				change.NewRole = oldRole
+			}
+			if change.NewRole < oldRole && !opts.AllowDowngrade {
+				result.reject(row.Line, "role of '%s' would be lowered from %s to %s, allow downgrades to confirm",
+					row.User, enrollmentRoleNames[oldRole], enrollmentRoleNames[change.NewRole])
+				continue
+			}
+		}
+
+		if change.GroupID != 0 && change.NewRole != int(authorize.STUDENT) {
+			result.reject(row.Line, "only students can be assigned to a group")
+			continue
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	return result, nil
+}
+
+// Apply creates missing accounts, enrolls all users and assigns them to their
+// groups in a single transaction. Users whose group is full are reported as
+// errors but stay enrolled. The returned invitations of the created accounts
+// are to be sent once everything has been written.
+func (ei *EnrollmentImport) Apply(stores *Stores, course *model.Course) ([]*email.Email, error) {
+	entries := make([]model.EnrollmentImportEntry, len(ei.Changes))
+	invitations := []*email.Email{}
+
+	for k := range ei.Changes {
+		change := &ei.Changes[k]
+
+		if change.Created {
+			password, err := HashPassword(auth.GenerateToken(32))
+			if err != nil {
+				return nil, err
+			}
+			change.user.EncryptedPassword = password
+			// choosing the password confirms the email
+			change.user.ResetPasswordToken = null.StringFrom(auth.GenerateToken(32))
+
+			msg, err := newInvitationForUser(configuration.Configuration.Server.Email.From, change.user, course)
+			if err != nil {
+				return nil, err
+			}
+			invitations = append(invitations, msg)
+		}
+
+		entries[k] = model.EnrollmentImportEntry{
+			User:    change.user,
+			OldRole: change.OldRole,
+			NewRole: change.NewRole,
+			GroupID: change.GroupID,
+		}
+	}
+
+	if err := stores.Course.ImportEnrollments(course.ID, entries); err != nil {
+		return nil, err
+	}
+
+	for k := range ei.Changes {
+		change := &ei.Changes[k]
+		change.UserID = change.user.ID
+
+		if change.GroupID != 0 && !entries[k].Joined {
+			ei.reject(change.Line, "group %d is full", change.GroupID)
+		}
+	}
+
+	return invitations, nil
+}
+
+// newInvitationForUser creates the link to choose a password for a created
+// account.
+func newInvitationForUser(from string, user *model.User, course *model.Course) (*email.Email, error) {
+	return email.NewEmailFromTemplate(from,
+		user.Email,
+		"Invitation",
+		email.InviteUserTemplateEN,
+		map[string]string{
+			"first_name":           user.FirstName,
+			"last_name":            user.LastName,
+			"course_name":          course.Name,
+			"email_address":        user.Email,
+			"reset_password_url":   fmt.Sprintf("%s/#/password_reset", configuration.Configuration.Server.ExternalURL()),
+			"reset_password_token": user.ResetPasswordToken.String,
+		})
+}
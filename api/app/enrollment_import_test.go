@@ -0,0 +1,184 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+)
+
+func TestEnrollmentImport(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	writeImport := func(content string) string {
+		f, err := os.CreateTemp("", "enrollments-*.csv")
+		g.Assert(err).Equal(nil)
+		defer f.Close()
+		_, err = f.WriteString(content)
+		g.Assert(err).Equal(nil)
+		return f.Name()
+	}
+
+	g.Describe("EnrollmentImport", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should parse optional columns", func() {
+			rows, err := ParseEnrollmentImportCSV(strings.NewReader("User;Role\na@b.de;tutor\n0815\n"), ';')
+			g.Assert(err).Equal(nil)
+			g.Assert(len(rows)).Equal(2)
+			g.Assert(rows[0].Role).Equal("tutor")
+			g.Assert(rows[1].Line).Equal(3)
+			g.Assert(rows[1].User).Equal("0815")
+			g.Assert(rows[1].Group).Equal("")
+
+			_, err = ParseEnrollmentImportCSV(strings.NewReader("email\na@b.de\n"), ',')
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("Should enroll matching users and report all others", func() {
+			user, err := stores.User.Create(&model.User{
+				FirstName:     "Bulk",
+				LastName:      "Import",
+				Email:         "bulk-import@uni-tuebingen.de",
+				StudentNumber: "bulk-0815",
+				Language:      "en",
+			})
+			g.Assert(err).Equal(nil)
+
+			filename := writeImport(fmt.Sprintf("user,role,group,first_name,last_name\n%s,student,1,,\nnobody@example.com,,,,\nnew@example.com,tutor,,New,Account\n%s,lecturer,,,\n",
+				user.StudentNumber, user.Email))
+			defer os.Remove(filename)
+
+			w, err := tape.Upload("/api/v1/courses/1/enrollments/import", filename, "text/csv", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import?create_missing=true", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := EnrollmentImportResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.Applied).IsTrue()
+			g.Assert(len(result.Changes)).Equal(2)
+			g.Assert(result.Changes[0].UserID).Equal(user.ID)
+			g.Assert(result.Changes[1].Created).IsTrue()
+			g.Assert(len(result.Errors)).Equal(2)
+			g.Assert(result.Errors[0].Line).Equal(3)
+			g.Assert(result.Errors[1].Line).Equal(5)
+
+			enrollment, err := stores.Course.GetUserEnrollment(1, user.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(int(enrollment.Role)).Equal(0)
+
+			groupEnrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(user.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(groupEnrollment.GroupID).Equal(int64(1))
+
+			created, err := stores.User.FindByEmail("new@example.com")
+			g.Assert(err).Equal(nil)
+			g.Assert(created.ResetPasswordToken.Valid).IsTrue()
+
+			role, err := stores.Course.RoleInCourse(created.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(int(role)).Equal(1)
+		})
+
+		g.It("Should lower roles of members only when confirmed", func() {
+			tutor, err := stores.User.Get(2)
+			g.Assert(err).Equal(nil)
+
+			// a plain list of participants keeps the roles
+			filename := writeImport(fmt.Sprintf("user\n%s\n", tutor.Email))
+			defer os.Remove(filename)
+
+			w, err := tape.Upload("/api/v1/courses/1/enrollments/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			role, err := stores.Course.RoleInCourse(tutor.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(int(role)).Equal(1)
+
+			filename = writeImport(fmt.Sprintf("user,role\n%s,student\n", tutor.Email))
+			defer os.Remove(filename)
+
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			result := EnrollmentImportResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(&result)).Equal(nil)
+			g.Assert(len(result.Changes)).Equal(0)
+			g.Assert(len(result.Errors)).Equal(1)
+
+			role, err = stores.Course.RoleInCourse(tutor.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(int(role)).Equal(1)
+
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import?allow_downgrade=true", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			role, err = stores.Course.RoleInCourse(tutor.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(int(role)).Equal(0)
+		})
+
+		g.It("Should not write anything in a dry-run", func() {
+			filename := writeImport("user,first_name,last_name\ndry@example.com,Dry,Run\n")
+			defer os.Remove(filename)
+
+			w, err := tape.Upload("/api/v1/courses/1/enrollments/import?create_missing=true&dry_run=true",
+				filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			_, err = stores.User.FindByEmail("dry@example.com")
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -53,13 +53,16 @@ func ErrRender(err error) render.Renderer {
 	}
 }
 
-// ErrBadRequestWithDetails returns status 400 with a text
+// ErrBadRequestWithDetails returns status 400 with a text. Validation errors
+// are additionally reported per field.
 func ErrBadRequestWithDetails(err error) *ErrResponse {
+	validationErrors, _ := err.(validation.Errors)
 	return &ErrResponse{
-		Err:            err,
-		HTTPStatusCode: http.StatusBadRequest,
-		StatusText:     http.StatusText(http.StatusBadRequest),
-		ErrorText:      err.Error(),
+		Err:              err,
+		HTTPStatusCode:   http.StatusBadRequest,
+		StatusText:       http.StatusText(http.StatusBadRequest),
+		ErrorText:        err.Error(),
+		ValidationErrors: validationErrors,
 	}
 }
 
@@ -94,6 +97,16 @@ func ErrUnauthorizedWithDetails(err error) *ErrResponse {
 	}
 }
 
+// ErrTooManyRequestsWithDetails returns status 429 with a text
+func ErrTooManyRequestsWithDetails(err error) *ErrResponse {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: http.StatusTooManyRequests,
+		StatusText:     http.StatusText(http.StatusTooManyRequests),
+		ErrorText:      err.Error(),
+	}
+}
+
 // see https://stackoverflow.com/a/50143519/7443104
 var (
 	// ErrBadRequest returns status 400 Bad Request for malformed request body.
@@ -20,8 +20,10 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -94,6 +96,7 @@ func (rs *ExamResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	exam.Name = data.Name
 	exam.Description = data.Description
 	exam.ExamTime = data.ExamTime
+	exam.AdmissionPrerequisite = data.AdmissionPrerequisite
 	exam.CourseID = course.ID
 
 	// create course entry in database
@@ -163,6 +166,7 @@ func (rs *ExamResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	exam.Name = data.Name
 	exam.Description = data.Description
 	exam.ExamTime = data.ExamTime
+	exam.AdmissionPrerequisite = data.AdmissionPrerequisite
 
 	// update database entry
 	if err := rs.Stores.Exam.Update(exam); err != nil {
@@ -208,7 +212,11 @@ func (rs *ExamResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  enroll a user into a exam
+// DESCRIPTION:
+// Students need to be admitted (see /courses/{course_id}/admissions/own) unless the exam
+// itself is an admission prerequisite.
 func (rs *ExamResource) EnrollExamHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	exam := r.Context().Value(symbol.CtxKeyExam).(*model.Exam)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
@@ -218,6 +226,27 @@ func (rs *ExamResource) EnrollExamHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if !exam.AdmissionPrerequisite {
+		admission, err := NewAdmissionEvaluator(rs.Stores, course, NowUTC())
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		status := admission.Evaluate(accessClaims.LoginID)
+		if !status.Admitted {
+			missing := []string{}
+			for _, reason := range status.Reasons {
+				if !reason.Met {
+					missing = append(missing, reason.Message)
+				}
+			}
+			render.Render(w, r, ErrBadRequestWithDetails(
+				fmt.Errorf("you are not admitted to this exam: %s", strings.Join(missing, "; "))))
+			return
+		}
+	}
+
 	// update database entry
 	if err := rs.Stores.Exam.Enroll(exam.ID, accessClaims.LoginID); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
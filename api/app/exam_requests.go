@@ -32,6 +32,9 @@ type ExamRequest struct {
 	Name        string    `json:"name" example:"Info 2"`
 	Description string    `json:"description" example:"An example exam."`
 	ExamTime    time.Time `json:"exam_time" example:"auto"`
+	// AdmissionPrerequisite exams have to be passed for admission to all other
+	// exams of the course.
+	AdmissionPrerequisite bool `json:"admission_prerequisite" example:"false" required:"false"`
 }
 
 // Bind preprocesses a ExamRequest.
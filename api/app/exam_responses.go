@@ -32,6 +32,9 @@ type ExamResponse struct {
 	Description string    `json:"description" example:"Some course description here"`
 	ExamTime    time.Time `json:"exam_time" example:"auto"`
 	CourseID    int64     `json:"course_id" example:"1"`
+	// AdmissionPrerequisite exams have to be passed for admission to all other
+	// exams of the course.
+	AdmissionPrerequisite bool `json:"admission_prerequisite" example:"false"`
 }
 
 // Render post-processes a ExamResponse.
@@ -42,11 +45,12 @@ func (body *ExamResponse) Render(w http.ResponseWriter, r *http.Request) error {
 // newExamResponse creates a response from a course model.
 func (rs *ExamResource) newExamResponse(p *model.Exam) *ExamResponse {
 	return &ExamResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		ExamTime:    p.ExamTime,
-		CourseID:    p.CourseID,
+		ID:                    p.ID,
+		Name:                  p.Name,
+		Description:           p.Description,
+		ExamTime:              p.ExamTime,
+		CourseID:              p.CourseID,
+		AdmissionPrerequisite: p.AdmissionPrerequisite,
 	}
 }
 
@@ -225,10 +225,31 @@ func TestExam(t *testing.T) {
 			g.Assert(len(enrollmentsActual)).Equal(numberEnrollmentsExpected)
 		})
 
+		g.It("Students should not be able to enroll into exam without admission", func() {
+			_, err := tape.DB.Exec("DELETE FROM user_exam WHERE user_id = 112;")
+			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec("UPDATE courses SET required_percentage = 100 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec("UPDATE submissions SET user_id = 113 WHERE user_id = 112;")
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/exams/1/enrollments", helper.H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// exams which are a prerequisite of the admission are always open
+			_, err = tape.DB.Exec("UPDATE exams SET admission_prerequisite = true WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/courses/1/exams/1/enrollments", helper.H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+		})
+
 		g.It("Students should be able to enroll into exam", func() {
 			// remove all enrollments from student
 			_, err := tape.DB.Exec("DELETE FROM user_exam WHERE user_id = 112;")
 			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec("UPDATE courses SET required_percentage = 0 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
 
 			examsBefore, err := stores.Exam.GetEnrollmentsOfUser(studentJWT.Claims.LoginID)
 			g.Assert(err).Equal(nil)
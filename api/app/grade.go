@@ -109,6 +109,11 @@ func (rs *GradeResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 func (rs *GradeResource) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if HidesIdentity(givenRole, currentGrade.SheetAnonymous) {
+		anonymizeGrade(currentGrade, course.ID)
+	}
 
 	// return Material information of created entry
 	if err := render.Render(w, r, newGradeResponse(currentGrade, course.ID)); err != nil {
@@ -295,6 +300,7 @@ func (rs *GradeResource) PrivateResultEditHandler(w http.ResponseWriter, r *http
 // SUMMARY:  Query grades in a course
 func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
 	filterSheetID := helper.Int64FromURL(r, "sheet_id", 0)
 	filterTaskID := helper.Int64FromURL(r, "task_id", 0)
@@ -314,7 +320,7 @@ func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	filterPublicExecutationState := helper.IntFromURL(r, "public_execution_state", -1)
 	filterPrivateExecutationState := helper.IntFromURL(r, "private_execution_state", -1)
 
-	submissions, err := rs.Stores.Grade.GetFiltered(
+	grades, err := rs.Stores.Grade.GetFiltered(
 		course.ID,
 		filterSheetID,
 		filterTaskID,
@@ -333,8 +339,20 @@ func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// filtering by a student would reveal who is behind a pseudonym
+	visibleGrades := []model.Grade{}
+	for k := range grades {
+		if HidesIdentity(givenRole, grades[k].SheetAnonymous) {
+			if filterUserID != 0 {
+				continue
+			}
+			anonymizeGrade(&grades[k], course.ID)
+		}
+		visibleGrades = append(visibleGrades, grades[k])
+	}
+
 	// render JSON response
-	if err = render.RenderList(w, r, newGradeListResponse(submissions, course.ID)); err != nil {
+	if err = render.RenderList(w, r, newGradeListResponse(visibleGrades, course.ID)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -384,6 +402,203 @@ func (rs *GradeResource) IndexSummaryHandler(w http.ResponseWriter, r *http.Requ
 
 }
 
+// ExportHandler is public endpoint for
+// URL: /courses/{course_id}/grades/export
+// URLPARAM: course_id,integer
+// QUERYPARAM: format,string
+// QUERYPARAM: group_id,integer
+// QUERYPARAM: columns,string
+// QUERYPARAM: student_number_width,integer
+// QUERYPARAM: student_number_prefix,string
+// QUERYPARAM: delimiter,string
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,SpreadsheetFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  export all grades of a course as csv or xlsx
+// DESCRIPTION:
+// The format is either "csv" (default) or "xlsx". The columns are a comma separated
+// subset of student_number,last_name,first_name,email,sheets,total,max_total,percentage,attendance,presentations,passed,exams.
+// The column "passed" evaluates the admission rules of the course.
+func (rs *GradeResource) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	format := helper.StringFromURL(r, "format", GradeExportFormatCSV)
+	if format != GradeExportFormatCSV && format != GradeExportFormatXLSX {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("unknown format '%s'", format)))
+		return
+	}
+
+	delimiter := []rune(helper.StringFromURL(r, "delimiter", ","))
+	if len(delimiter) != 1 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("delimiter must be a single character")))
+		return
+	}
+
+	opts := NewGradeExportOptions()
+	opts.GroupID = helper.Int64FromURL(r, "group_id", 0)
+	opts.Columns = helper.StringArrayFromURL(r, "columns", DefaultGradeExportColumns)
+	opts.StudentNumberWidth = helper.IntFromURL(r, "student_number_width", 0)
+	opts.StudentNumberPrefix = helper.StringFromURL(r, "student_number_prefix", "")
+
+	if err := opts.Validate(); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	export, err := BuildGradeExport(rs.Stores, course, opts)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", export.Filename(format)))
+
+	if err := export.Write(w, format, delimiter[0]); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
+// StatisticsHandler is public endpoint for
+// URL: /courses/{course_id}/grades/statistics
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,GradeStatisticsResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  aggregated statistics of all grades of a course per task, tutor and group
+// DESCRIPTION:
+// Per task it contains mean, median and a histogram of the acquired points of all graded
+// submissions and the pass rates of the public and private tests. Per tutor it contains
+// the percentage of points given, the leniency compared to all tutors and the median
+// hours between the due date and grading. Per group it contains the percentage, the
+// deviation from all groups and the mean and median of every task.
+func (rs *GradeResource) StatisticsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	entries, err := rs.Stores.Grade.GetStatisticEntries(course.ID, 0)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err := render.Render(w, r, newGradeStatisticsResponse(entries, course.ID, 0)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ImportHandler is public endpoint for
+// URL: /courses/{course_id}/grades/import
+// URLPARAM: course_id,integer
+// QUERYPARAM: sheet_id,integer
+// QUERYPARAM: group_id,integer
+// QUERYPARAM: delimiter,string
+// QUERYPARAM: dry_run,boolean
+// METHOD: post
+// TAG: grades
+// REQUEST: csvfile
+// RESPONSE: 200,GradeImportResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  import points and feedback of many grades from a csv file
+// DESCRIPTION:
+// The csv file needs the columns student,task,points,feedback. A student is identified
+// by email, student number or pseudonym, a task by id or by name. Tutors have to use
+// the pseudonym for anonymously graded sheets. Every line is validated against
+// the max points of the task and, if given, the membership in the group. Tutors can only
+// import grades of a group they are tutoring. Nothing is written when "dry_run" is set or
+// when any line is invalid; otherwise all lines are applied in a single transaction.
+func (rs *GradeResource) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	delimiter := []rune(helper.StringFromURL(r, "delimiter", ","))
+	if len(delimiter) != 1 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("delimiter must be a single character")))
+		return
+	}
+
+	opts := GradeImportOptions{
+		SheetID:      helper.Int64FromURL(r, "sheet_id", 0),
+		GroupID:      helper.Int64FromURL(r, "group_id", 0),
+		HideIdentity: HidesIdentity(givenRole, true),
+	}
+	dryRun := helper.BoolFromURL(r, "dry_run", false)
+
+	if givenRole == authorize.TUTOR {
+		if opts.GroupID == 0 {
+			render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("tutors need to specify a group_id")))
+			return
+		}
+
+		// co-tutors and substitutes (while valid) import like the lead tutor
+		isTutorOfGroup, err := rs.Stores.Group.IsTutorOfGroup(opts.GroupID, accessClaims.LoginID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		if !isTutorOfGroup {
+			render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not the tutor of this group")))
+			return
+		}
+	}
+
+	file, _, err := r.FormFile("file_data")
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	defer file.Close()
+
+	rows, rowErrors, err := ParseGradeImportCSV(file, delimiter[0])
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	gradeImport, err := BuildGradeImport(rs.Stores, course, rows, opts)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	gradeImport.Errors = append(rowErrors, gradeImport.Errors...)
+
+	if !gradeImport.Valid() {
+		render.Status(r, http.StatusBadRequest)
+		if err := render.Render(w, r, newGradeImportResponse(gradeImport, dryRun, false)); err != nil {
+			render.Render(w, r, ErrRender(err))
+		}
+		return
+	}
+
+	applied := false
+	if !dryRun {
+		if err := gradeImport.Apply(rs.Stores, accessClaims.LoginID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		applied = true
+	}
+
+	render.Status(r, http.StatusOK)
+	if err := render.Render(w, r, newGradeImportResponse(gradeImport, dryRun, applied)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
 // IndexMissingHandler is public endpoint for
 // URL: /courses/{course_id}/grades/missing
 // URLPARAM: course_id,integer
@@ -398,6 +613,7 @@ func (rs *GradeResource) IndexSummaryHandler(w http.ResponseWriter, r *http.Requ
 func (rs *GradeResource) IndexMissingHandler(w http.ResponseWriter, r *http.Request) {
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
 	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
 
@@ -407,6 +623,48 @@ func (rs *GradeResource) IndexMissingHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	for k := range grades {
+		if HidesIdentity(givenRole, grades[k].SheetAnonymous) {
+			anonymizeGrade(&grades[k].Grade, course.ID)
+		}
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newMissingGradeListResponse(grades)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+
+}
+
+// IndexPoolHandler is public endpoint for
+// URL: /courses/{course_id}/grades/pool
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,MissingGradeResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  ungraded submissions in the pool which can be claimed by any tutor
+func (rs *GradeResource) IndexPoolHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	grades, err := rs.Stores.Grade.GetPooledGrades(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	for k := range grades {
+		if HidesIdentity(givenRole, grades[k].SheetAnonymous) {
+			anonymizeGrade(&grades[k].Grade, course.ID)
+		}
+	}
+
 	// render JSON response
 	if err = render.RenderList(w, r, newMissingGradeListResponse(grades)); err != nil {
 		render.Render(w, r, ErrRender(err))
@@ -414,7 +672,137 @@ func (rs *GradeResource) IndexMissingHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	render.Status(r, http.StatusOK)
+}
+
+// ClaimHandler is public endpoint for
+// URL: /courses/{course_id}/grades/pool/claim
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: grades
+// REQUEST: GradeClaimRequest
+// RESPONSE: 200,GradeAssignmentResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  assign ungraded submissions from the pool to the request identity
+// DESCRIPTION:
+// Grades which have been claimed concurrently by another tutor are skipped. The
+// response only contains the grades which are now assigned to the request identity.
+func (rs *GradeResource) ClaimHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &GradeClaimRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	count := data.Count
+	if len(data.GradeIDs) > 0 {
+		count = len(data.GradeIDs)
+	}
 
+	gradeIDs, err := rs.Stores.Grade.ClaimPooledGrades(course.ID, accessClaims.LoginID, data.GradeIDs, count)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, newGradeAssignmentResponse(gradeIDs, accessClaims.LoginID)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// AssignHandler is public endpoint for
+// URL: /courses/{course_id}/grades/assign
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: grades
+// REQUEST: GradeAssignRequest
+// RESPONSE: 200,GradeAssignmentResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  reassign ungraded submissions to another tutor or into the pool
+// DESCRIPTION:
+// Either a list of grades or all open grades of "from_tutor_id" are moved. They are
+// assigned to "tutor_id" or moved into the pool if "tutor_id" is missing. Grades
+// which already have a feedback are never reassigned.
+func (rs *GradeResource) AssignHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &GradeAssignRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if data.TutorID != 0 {
+		role, err := rs.Stores.Course.RoleInCourse(data.TutorID, course.ID)
+		if err != nil || role < authorize.TUTOR {
+			render.Render(w, r, ErrBadRequestWithDetails(errors.New("tutor_id is not a tutor of this course")))
+			return
+		}
+	}
+
+	gradeIDs := data.GradeIDs
+	if data.FromTutorID != 0 {
+		grades, err := rs.Stores.Grade.GetAllMissingGrades(course.ID, data.FromTutorID, 0)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		gradeIDs = []int64{}
+		for _, grade := range grades {
+			gradeIDs = append(gradeIDs, grade.ID)
+		}
+	}
+
+	assignedIDs, err := rs.Stores.Grade.AssignGrades(course.ID, gradeIDs, data.TutorID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, newGradeAssignmentResponse(assignedIDs, data.TutorID)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// IndexWorkloadHandler is public endpoint for
+// URL: /courses/{course_id}/grades/workload
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,TutorWorkloadResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  number of open and finished grades of each tutor
+func (rs *GradeResource) IndexWorkloadHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	workloads, err := rs.Stores.Grade.GetTutorWorkloads(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newTutorWorkloadListResponse(workloads)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
 }
 
 // .............................................................................
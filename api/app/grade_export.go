@@ -0,0 +1,331 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/model"
+)
+
+// Supported formats of a grade export.
+const (
+	GradeExportFormatCSV  = "csv"
+	GradeExportFormatXLSX = "xlsx"
+)
+
+// Columns of a grade export. The column "sheets" expands to one column per
+// sheet and "exams" to a mark and a status column per exam of the course.
+const (
+	GradeExportColumnStudentNumber = "student_number"
+	GradeExportColumnFirstName     = "first_name"
+	GradeExportColumnLastName      = "last_name"
+	GradeExportColumnEmail         = "email"
+	GradeExportColumnSheets        = "sheets"
+	GradeExportColumnTotal         = "total"
+	GradeExportColumnMaxTotal      = "max_total"
+	GradeExportColumnPercentage    = "percentage"
+	GradeExportColumnAttendance    = "attendance"
+	GradeExportColumnPresentations = "presentations"
+	GradeExportColumnPassed        = "passed"
+	GradeExportColumnExams         = "exams"
+)
+
+// DefaultGradeExportColumns lists all known columns in their default order.
+var DefaultGradeExportColumns = []string{
+	GradeExportColumnStudentNumber,
+	GradeExportColumnLastName,
+	GradeExportColumnFirstName,
+	GradeExportColumnEmail,
+	GradeExportColumnSheets,
+	GradeExportColumnTotal,
+	GradeExportColumnMaxTotal,
+	GradeExportColumnPercentage,
+	GradeExportColumnAttendance,
+	GradeExportColumnPresentations,
+	GradeExportColumnPassed,
+	GradeExportColumnExams,
+}
+
+// GradeExportOptions controls which students and columns end up in an export.
+type GradeExportOptions struct {
+	// GroupID restricts the export to the students of a single group (0 means all).
+	GroupID int64
+	// Columns is a subset of DefaultGradeExportColumns in the desired order.
+	Columns []string
+	// StudentNumberWidth pads student numbers with leading zeros (0 means no padding).
+	StudentNumberWidth int
+	// StudentNumberPrefix is prepended to each student number.
+	StudentNumberPrefix string
+}
+
+// NewGradeExportOptions returns options exporting all columns of all students.
+func NewGradeExportOptions() GradeExportOptions {
+	return GradeExportOptions{
+		Columns: DefaultGradeExportColumns,
+	}
+}
+
+// Validate checks that all requested columns are known.
+func (o GradeExportOptions) Validate() error {
+	if len(o.Columns) == 0 {
+		return errors.New("at least one column is required")
+	}
+	for _, column := range o.Columns {
+		known := false
+		for _, candidate := range DefaultGradeExportColumns {
+			if column == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown column '%s', valid columns are %s",
+				column, strings.Join(DefaultGradeExportColumns, ","))
+		}
+	}
+	if o.StudentNumberWidth < 0 {
+		return errors.New("student number width must not be negative")
+	}
+	return nil
+}
+
+// FormatStudentNumber applies the configured padding and prefix.
+func (o GradeExportOptions) FormatStudentNumber(studentNumber string) string {
+	studentNumber = strings.TrimSpace(studentNumber)
+	if studentNumber == "" {
+		return ""
+	}
+	if n := o.StudentNumberWidth - len(studentNumber); n > 0 {
+		studentNumber = strings.Repeat("0", n) + studentNumber
+	}
+	return o.StudentNumberPrefix + studentNumber
+}
+
+// GradeExport is a table containing the achievements of all students in a
+// course ready to be handed to the examination office.
+type GradeExport struct {
+	Course *model.Course
+	Rows   [][]interface{}
+}
+
+// examStatusText converts the status of a UserExam (0 unknown, 1 failed, 2 passed).
+func examStatusText(status int) string {
+	switch status {
+	case 1:
+		return "failed"
+	case 2:
+		return "passed"
+	default:
+		return ""
+	}
+}
+
+// BuildGradeExport collects points of all sheets, the attendance, the number
+// of presentations, the exam results and evaluates the admission rules of the
+// course for each student.
+func BuildGradeExport(stores *Stores, course *model.Course, opts GradeExportOptions) (*GradeExport, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		students []model.UserCourse
+		err      error
+	)
+
+	if opts.GroupID == 0 {
+		students, err = stores.Course.EnrolledUsers(course.ID,
+			[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+	} else {
+		students, err = stores.Group.EnrolledUsers(course.ID, opts.GroupID,
+			[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet2pos := make(map[int64]int)
+	maxTotal := 0
+	for k, sheet := range sheets {
+		sheet2pos[sheet.ID] = k
+
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			maxTotal += task.MaxPoints
+		}
+	}
+
+	overview, err := stores.Grade.GetOverviewGrades(course.ID, opts.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	// user -> points for each sheet
+	points := make(map[int64][]int)
+	for _, entry := range overview {
+		if _, exists := points[entry.UserID]; !exists {
+			points[entry.UserID] = make([]int, len(sheets))
+		}
+		if pos, exists := sheet2pos[entry.SheetID]; exists {
+			points[entry.UserID][pos] = entry.Points
+		}
+	}
+
+	admission, err := NewAdmissionEvaluator(stores, course, NowUTC())
+	if err != nil {
+		return nil, err
+	}
+
+	exams, err := stores.Exam.ExamsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// exam -> user -> result
+	examResults := make([]map[int64]model.UserExam, len(exams))
+	for k, exam := range exams {
+		enrollments, err := stores.Exam.GetEnrollmentsInCourseOfExam(course.ID, exam.ID)
+		if err != nil {
+			return nil, err
+		}
+		examResults[k] = make(map[int64]model.UserExam)
+		for _, enrollment := range enrollments {
+			examResults[k][enrollment.UserID] = enrollment
+		}
+	}
+
+	header := []interface{}{}
+	for _, column := range opts.Columns {
+		switch column {
+		case GradeExportColumnSheets:
+			for _, sheet := range sheets {
+				header = append(header, sheet.Name)
+			}
+		case GradeExportColumnExams:
+			for _, exam := range exams {
+				header = append(header, fmt.Sprintf("%s mark", exam.Name))
+				header = append(header, fmt.Sprintf("%s status", exam.Name))
+			}
+		default:
+			header = append(header, column)
+		}
+	}
+
+	export := &GradeExport{Course: course, Rows: [][]interface{}{header}}
+
+	for _, student := range students {
+		studentPoints, exists := points[student.ID]
+		if !exists {
+			studentPoints = make([]int, len(sheets))
+		}
+
+		total := 0
+		for _, p := range studentPoints {
+			total += p
+		}
+
+		percentage := 0.0
+		if maxTotal > 0 {
+			percentage = 100 * float64(total) / float64(maxTotal)
+		}
+
+		facts := admission.Facts(student.ID)
+
+		row := []interface{}{}
+		for _, column := range opts.Columns {
+			switch column {
+			case GradeExportColumnStudentNumber:
+				row = append(row, opts.FormatStudentNumber(student.StudentNumber))
+			case GradeExportColumnFirstName:
+				row = append(row, student.FirstName)
+			case GradeExportColumnLastName:
+				row = append(row, student.LastName)
+			case GradeExportColumnEmail:
+				row = append(row, student.Email)
+			case GradeExportColumnSheets:
+				for _, p := range studentPoints {
+					row = append(row, p)
+				}
+			case GradeExportColumnTotal:
+				row = append(row, total)
+			case GradeExportColumnMaxTotal:
+				row = append(row, maxTotal)
+			case GradeExportColumnPercentage:
+				row = append(row, percentage)
+			case GradeExportColumnAttendance:
+				row = append(row, facts.Attendance)
+			case GradeExportColumnPresentations:
+				row = append(row, facts.Presentations)
+			case GradeExportColumnPassed:
+				row = append(row, admission.Evaluate(student.ID).Admitted)
+			case GradeExportColumnExams:
+				for k := range exams {
+					if result, exists := examResults[k][student.ID]; exists {
+						row = append(row, result.Mark, examStatusText(result.Status))
+					} else {
+						row = append(row, "", "")
+					}
+				}
+			}
+		}
+		export.Rows = append(export.Rows, row)
+	}
+
+	return export, nil
+}
+
+// Filename suggests a name for the exported file.
+func (e *GradeExport) Filename(format string) string {
+	return fmt.Sprintf("infomark-course%d-grades.%s", e.Course.ID, format)
+}
+
+// ContentType returns the MIME type of the given export format.
+func (e *GradeExport) ContentType(format string) string {
+	if format == GradeExportFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Write serializes the export in the given format. The delimiter is only used
+// for CSV files.
+func (e *GradeExport) Write(w io.Writer, format string, delimiter rune) error {
+	switch format {
+	case GradeExportFormatCSV:
+		return helper.WriteCSV(w, e.Rows, delimiter)
+	case GradeExportFormatXLSX:
+		return helper.WriteXLSX(w, "grades", e.Rows)
+	default:
+		return fmt.Errorf("unknown format '%s', valid formats are csv,xlsx", format)
+	}
+}
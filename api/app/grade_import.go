@@ -0,0 +1,356 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/infomark-org/infomark/model"
+)
+
+// Columns of a grade import. The column "student" contains either the email,
+// the student number or the pseudonym of a student, the column "task" either
+// the id or the name of a task.
+const (
+	GradeImportColumnStudent  = "student"
+	GradeImportColumnTask     = "task"
+	GradeImportColumnPoints   = "points"
+	GradeImportColumnFeedback = "feedback"
+)
+
+// GradeImportOptions restricts an import to a sheet and/or a group. If
+// HideIdentity is set, students of anonymous sheets must be given by their
+// pseudonym and the changes do not reveal their identity.
+type GradeImportOptions struct {
+	SheetID      int64
+	GroupID      int64
+	HideIdentity bool
+}
+
+// GradeImportRow is a single parsed line of a grade import.
+type GradeImportRow struct {
+	Line     int
+	Student  string
+	Task     string
+	Points   int
+	Feedback string
+}
+
+// GradeImportError describes why a line of a grade import has been rejected.
+type GradeImportError struct {
+	Line    int    `json:"line" example:"3"`
+	Message string `json:"message" example:"points 12 exceed max points 10 of task"`
+}
+
+// GradeImportChange describes how a line of a grade import changes a grade.
+type GradeImportChange struct {
+	Line        int    `json:"line" example:"2"`
+	GradeID     int64  `json:"grade_id" example:"31"`
+	UserID      int64  `json:"user_id" example:"112"`
+	UserEmail   string `json:"user_email" example:"test@uni-tuebingen.de"`
+	TaskID      int64  `json:"task_id" example:"4"`
+	TaskName    string `json:"task_name" example:"Task 1"`
+	OldPoints   int    `json:"old_points" example:"0"`
+	NewPoints   int    `json:"new_points" example:"8"`
+	OldFeedback string `json:"old_feedback" example:""`
+	NewFeedback string `json:"new_feedback" example:"Well done"`
+}
+
+// GradeImport is the validated result of a grade import.
+type GradeImport struct {
+	Changes []GradeImportChange
+	Errors  []GradeImportError
+
+	grades []model.Grade
+}
+
+// Valid reports whether every line of the import could be applied.
+func (gi *GradeImport) Valid() bool {
+	return len(gi.Errors) == 0
+}
+
+func (gi *GradeImport) reject(line int, format string, args ...interface{}) {
+	gi.Errors = append(gi.Errors, GradeImportError{
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// ParseGradeImportCSV reads the rows of a grade import. The first line must be
+// a header naming the columns student, task, points and feedback in any order.
+// Line numbers refer to the lines of the file including the header.
+func ParseGradeImportCSV(r io.Reader, delimiter rune) ([]GradeImportRow, []GradeImportError, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	column2pos := make(map[string]int)
+	for k, name := range header {
+		column2pos[strings.ToLower(strings.TrimSpace(name))] = k
+	}
+	for _, name := range []string{GradeImportColumnStudent, GradeImportColumnTask,
+		GradeImportColumnPoints, GradeImportColumnFeedback} {
+		if _, ok := column2pos[name]; !ok {
+			return nil, nil, fmt.Errorf("missing column '%s' in header", name)
+		}
+	}
+
+	rows := []GradeImportRow{}
+	rowErrors := []GradeImportError{}
+	line := 1
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, nil, err
+		}
+
+		points, err := strconv.Atoi(strings.TrimSpace(record[column2pos[GradeImportColumnPoints]]))
+		if err != nil {
+			rowErrors = append(rowErrors, GradeImportError{
+				Line:    line,
+				Message: fmt.Sprintf("points '%s' are not an integer", record[column2pos[GradeImportColumnPoints]]),
+			})
+			continue
+		}
+
+		rows = append(rows, GradeImportRow{
+			Line:     line,
+			Student:  strings.TrimSpace(record[column2pos[GradeImportColumnStudent]]),
+			Task:     strings.TrimSpace(record[column2pos[GradeImportColumnTask]]),
+			Points:   points,
+			Feedback: strings.TrimSpace(record[column2pos[GradeImportColumnFeedback]]),
+		})
+	}
+
+	return rows, rowErrors, nil
+}
+
+// BuildGradeImport resolves students, tasks and grades of all rows and
+// validates them against the max points of the task and the group membership.
+// Nothing is written to the database.
+func BuildGradeImport(stores *Stores, course *model.Course, rows []GradeImportRow, opts GradeImportOptions) (*GradeImport, error) {
+	students, err := stores.Course.EnrolledUsers(course.ID,
+		[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+	if err != nil {
+		return nil, err
+	}
+
+	email2student := make(map[string]model.UserCourse)
+	number2student := make(map[string]model.UserCourse)
+	pseudonym2student := make(map[string]model.UserCourse)
+	for _, student := range students {
+		email2student[strings.ToLower(student.Email)] = student
+		// a blank student number must never identify a student
+		if strings.TrimSpace(student.StudentNumber) != "" {
+			number2student[student.StudentNumber] = student
+		}
+		pseudonym2student[Pseudonym(course.ID, student.ID)] = student
+	}
+
+	var members map[int64]bool
+	if opts.GroupID != 0 {
+		groupStudents, err := stores.Group.EnrolledUsers(course.ID, opts.GroupID,
+			[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+		if err != nil {
+			return nil, err
+		}
+		members = make(map[int64]bool)
+		for _, student := range groupStudents {
+			members[student.ID] = true
+		}
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	id2task := make(map[int64]model.Task)
+	anonymousTasks := make(map[int64]bool)
+	name2task := make(map[string][]model.Task)
+	sheetFound := opts.SheetID == 0
+	for _, sheet := range sheets {
+		if opts.SheetID != 0 && sheet.ID != opts.SheetID {
+			continue
+		}
+		sheetFound = true
+
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			id2task[task.ID] = task
+			anonymousTasks[task.ID] = sheet.Anonymous
+			name := strings.ToLower(task.Name)
+			name2task[name] = append(name2task[name], task)
+		}
+	}
+	if !sheetFound {
+		return nil, fmt.Errorf("sheet %d does not belong to the course", opts.SheetID)
+	}
+
+	result := &GradeImport{
+		Changes: []GradeImportChange{},
+		Errors:  []GradeImportError{},
+		grades:  []model.Grade{},
+	}
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		if row.Student == "" {
+			result.reject(row.Line, "student is missing")
+			continue
+		}
+
+		student, byPseudonym := pseudonym2student[row.Student]
+		ok := byPseudonym
+		if !byPseudonym {
+			student, ok = email2student[strings.ToLower(row.Student)]
+			if !strings.Contains(row.Student, "@") {
+				student, ok = number2student[row.Student]
+			}
+		}
+		if !ok {
+			result.reject(row.Line, "student '%s' is not enrolled in the course", row.Student)
+			continue
+		}
+		if members != nil && !members[student.ID] {
+			result.reject(row.Line, "student '%s' is not a member of the group", row.Student)
+			continue
+		}
+
+		var task model.Task
+		if taskID, err := strconv.ParseInt(row.Task, 10, 64); err == nil {
+			task, ok = id2task[taskID]
+		} else {
+			candidates := name2task[strings.ToLower(row.Task)]
+			if len(candidates) > 1 {
+				result.reject(row.Line, "task '%s' is ambiguous, use the task id or restrict the import to a sheet", row.Task)
+				continue
+			}
+			ok = len(candidates) == 1
+			if ok {
+				task = candidates[0]
+			}
+		}
+		if !ok {
+			result.reject(row.Line, "task '%s' does not exist", row.Task)
+			continue
+		}
+
+		hideIdentity := opts.HideIdentity && anonymousTasks[task.ID]
+		if hideIdentity && !byPseudonym {
+			result.reject(row.Line, "task '%s' is graded anonymously, use the pseudonym of the student", row.Task)
+			continue
+		}
+
+		if row.Points < 0 || row.Points > task.MaxPoints {
+			result.reject(row.Line, "points %d are not within 0 and %d", row.Points, task.MaxPoints)
+			continue
+		}
+		if row.Feedback == "" {
+			result.reject(row.Line, "feedback is required")
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%d", student.ID, task.ID)
+		if previous, exists := seen[key]; exists {
+			result.reject(row.Line, "duplicate of line %d", previous)
+			continue
+		}
+		seen[key] = row.Line
+
+		submission, err := stores.Submission.GetByUserAndTask(student.ID, task.ID)
+		if err == sql.ErrNoRows {
+			result.reject(row.Line, "student '%s' has no submission for task '%s'", row.Student, row.Task)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		grade, err := stores.Grade.GetForSubmission(submission.ID)
+		if err == sql.ErrNoRows {
+			result.reject(row.Line, "submission of student '%s' for task '%s' has no grade", row.Student, row.Task)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		change := GradeImportChange{
+			Line:        row.Line,
+			GradeID:     grade.ID,
+			UserID:      student.ID,
+			UserEmail:   student.Email,
+			TaskID:      task.ID,
+			TaskName:    task.Name,
+			OldPoints:   grade.AcquiredPoints,
+			NewPoints:   row.Points,
+			OldFeedback: grade.Feedback,
+			NewFeedback: row.Feedback,
+		}
+		if hideIdentity {
+			change.UserID = 0
+			change.UserEmail = row.Student
+		}
+		result.Changes = append(result.Changes, change)
+
+		grade.AcquiredPoints = row.Points
+		grade.Feedback = row.Feedback
+		result.grades = append(result.grades, *grade)
+	}
+
+	return result, nil
+}
+
+// Apply writes all changes of a valid import in a single transaction. The
+// given tutor is recorded as the grader of every changed grade.
+func (gi *GradeImport) Apply(stores *Stores, tutorID int64) error {
+	if !gi.Valid() {
+		return errors.New("import contains invalid rows")
+	}
+
+	for k := range gi.grades {
+		gi.grades[k].TutorID = tutorID
+	}
+
+	return stores.Grade.UpdateMany(gi.grades)
+}
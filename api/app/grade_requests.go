@@ -20,6 +20,7 @@
 package app
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -83,3 +84,66 @@ func (body *GradeFromWorkerRequest) Validate() error {
 		),
 	)
 }
+
+// GradeClaimRequest is the request payload for tutors claiming ungraded
+// submissions from the pool. Either specific grades or a number of grades
+// can be claimed.
+type GradeClaimRequest struct {
+	GradeIDs []int64 `json:"grade_ids" example:"12"`
+	Count    int     `json:"count" example:"10"`
+}
+
+// Bind preprocesses a GradeClaimRequest.
+func (body *GradeClaimRequest) Bind(r *http.Request) error {
+	return body.Validate()
+}
+
+// Validate validates an incoming GradeClaimRequest.
+func (body *GradeClaimRequest) Validate() error {
+	if len(body.GradeIDs) > 0 {
+		if body.Count != 0 {
+			return errors.New("either grade_ids or count can be given")
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Count,
+			validation.Required,
+			validation.Min(1),
+			validation.Max(500),
+		),
+	)
+}
+
+// GradeAssignRequest is the request payload for admins redistributing
+// ungraded submissions. The grades are either given explicitly or are all
+// open grades of another tutor. A missing tutor_id moves them into the pool.
+type GradeAssignRequest struct {
+	GradeIDs    []int64 `json:"grade_ids" example:"12"`
+	FromTutorID int64   `json:"from_tutor_id" example:"3"`
+	TutorID     int64   `json:"tutor_id" example:"2"`
+}
+
+// Bind preprocesses a GradeAssignRequest.
+func (body *GradeAssignRequest) Bind(r *http.Request) error {
+	return body.Validate()
+}
+
+// Validate validates an incoming GradeAssignRequest.
+func (body *GradeAssignRequest) Validate() error {
+	if (len(body.GradeIDs) == 0) == (body.FromTutorID == 0) {
+		return errors.New("either grade_ids or from_tutor_id is required")
+	}
+	if body.FromTutorID != 0 && body.FromTutorID == body.TutorID {
+		return errors.New("from_tutor_id and tutor_id must differ")
+	}
+
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.TutorID,
+			validation.Min(0),
+		),
+	)
+}
@@ -48,6 +48,8 @@ type GradeResponse struct {
 	Feedback              string    `json:"feedback" example:"Some feedback"`
 	TutorID               int64     `json:"tutor_id" example:"2"`
 	SubmissionID          int64     `json:"submission_id" example:"31"`
+	AssignedTutorID       int64     `json:"assigned_tutor_id" example:"0"`
+	Pooled                bool      `json:"pooled" example:"false"`
 	FileURL               string    `json:"file_url" example:"/api/v1/submissions/61/file"`
 	User                  *struct {
 		ID        int64  `json:"id" example:"1"`
@@ -100,6 +102,8 @@ func newGradeResponse(p *model.Grade, courseID int64) *GradeResponse {
 		TutorID:               p.TutorID,
 		User:                  user,
 		SubmissionID:          p.SubmissionID,
+		AssignedTutorID:       p.AssignedTutorID.Int64,
+		Pooled:                p.Pooled,
 		FileURL:               fileURL,
 	}
 }
@@ -130,6 +134,8 @@ type MissingGradeResponse struct {
 		Feedback              string    `json:"feedback" example:"Some feedback"`
 		TutorID               int64     `json:"tutor_id" example:"2"`
 		SubmissionID          int64     `json:"submission_id" example:"31"`
+		AssignedTutorID       int64     `json:"assigned_tutor_id" example:"0"`
+		Pooled                bool      `json:"pooled" example:"false"`
 		FileURL               string    `json:"file_url" example:"/api/v1/submissions/61/file"`
 		User                  *struct {
 			ID        int64  `json:"id" example:"1"`
@@ -180,6 +186,8 @@ func newMissingGradeResponse(p *model.MissingGrade) *MissingGradeResponse {
 		Feedback              string    `json:"feedback" example:"Some feedback"`
 		TutorID               int64     `json:"tutor_id" example:"2"`
 		SubmissionID          int64     `json:"submission_id" example:"31"`
+		AssignedTutorID       int64     `json:"assigned_tutor_id" example:"0"`
+		Pooled                bool      `json:"pooled" example:"false"`
 		FileURL               string    `json:"file_url" example:"/api/v1/submissions/61/file"`
 		User                  *struct {
 			ID        int64  `json:"id" example:"1"`
@@ -201,6 +209,8 @@ func newMissingGradeResponse(p *model.MissingGrade) *MissingGradeResponse {
 		TutorID:               p.TutorID,
 		User:                  user,
 		SubmissionID:          p.SubmissionID,
+		AssignedTutorID:       p.AssignedTutorID.Int64,
+		Pooled:                p.Pooled,
 		FileURL:               fileURL,
 	}
 
@@ -250,11 +260,20 @@ type GradeOverviewResponse struct {
 	Achievements []AchievementInfo `json:"achievements" example:""`
 }
 
-// newGradeOverviewResponse creates a response from a Material model.
-func newGradeOverviewResponse(collection []model.OverviewGrade, sheets []model.Sheet, role authorize.CourseRole) *GradeOverviewResponse {
+// newGradeOverviewResponse creates a response from a Material model. Anonymous
+// sheets are left out for everyone who must not see the identity of their
+// students, as the points would reveal who is behind a pseudonym.
+func newGradeOverviewResponse(collection []model.OverviewGrade, allSheets []model.Sheet, role authorize.CourseRole) *GradeOverviewResponse {
 	obj := &GradeOverviewResponse{}
 	// collection is sorted by user_id
 
+	sheets := []model.Sheet{}
+	for _, s := range allSheets {
+		if !HidesIdentity(role, s.Anonymous) {
+			sheets = append(sheets, s)
+		}
+	}
+
 	// only do this once
 	sheet2pos := make(map[int64]int)
 	for k, s := range sheets {
@@ -300,7 +319,9 @@ func newGradeOverviewResponse(collection []model.OverviewGrade, sheets []model.S
 				}
 			}
 
-			currentPoints[sheet2pos[entry.SheetID]] = entry.Points
+			if pos, ok := sheet2pos[entry.SheetID]; ok {
+				currentPoints[pos] = entry.Points
+			}
 		}
 
 		// add the last student
@@ -316,3 +337,107 @@ func newGradeOverviewResponse(collection []model.OverviewGrade, sheets []model.S
 func (body *GradeOverviewResponse) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
+
+// GradeImportResponse lists the changes of a grade import or the reasons why
+// some of its lines have been rejected.
+type GradeImportResponse struct {
+	DryRun  bool                `json:"dry_run" example:"true"`
+	Applied bool                `json:"applied" example:"false"`
+	Changes []GradeImportChange `json:"changes"`
+	Errors  []GradeImportError  `json:"errors"`
+}
+
+// newGradeImportResponse creates a response from a validated grade import.
+func newGradeImportResponse(p *GradeImport, dryRun bool, applied bool) *GradeImportResponse {
+	return &GradeImportResponse{
+		DryRun:  dryRun,
+		Applied: applied,
+		Changes: p.Changes,
+		Errors:  p.Errors,
+	}
+}
+
+// Render post-processes a GradeImportResponse.
+func (body *GradeImportResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GradeStatisticsResponse contains aggregated statistics of all grades in a
+// course or a sheet.
+type GradeStatisticsResponse struct {
+	CourseID int64             `json:"course_id" example:"1"`
+	SheetID  int64             `json:"sheet_id" example:"0"`
+	Tasks    []TaskStatistics  `json:"tasks"`
+	Tutors   []TutorStatistics `json:"tutors"`
+	Groups   []GroupStatistics `json:"groups"`
+}
+
+// newGradeStatisticsResponse creates a response from statistic entries.
+func newGradeStatisticsResponse(entries []model.GradeStatisticEntry, courseID int64, sheetID int64) *GradeStatisticsResponse {
+	tasks, tutors, groups := ComputeGradeStatistics(entries)
+	return &GradeStatisticsResponse{
+		CourseID: courseID,
+		SheetID:  sheetID,
+		Tasks:    tasks,
+		Tutors:   tutors,
+		Groups:   groups,
+	}
+}
+
+// Render post-processes a GradeStatisticsResponse.
+func (body *GradeStatisticsResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GradeAssignmentResponse lists the grades which have been claimed or assigned.
+type GradeAssignmentResponse struct {
+	GradeIDs []int64 `json:"grade_ids" example:"12"`
+	TutorID  int64   `json:"tutor_id" example:"2"`
+	Pooled   bool    `json:"pooled" example:"false"`
+}
+
+// newGradeAssignmentResponse creates a response from the ids of assigned grades.
+func newGradeAssignmentResponse(gradeIDs []int64, tutorID int64) *GradeAssignmentResponse {
+	return &GradeAssignmentResponse{
+		GradeIDs: gradeIDs,
+		TutorID:  tutorID,
+		Pooled:   tutorID == 0,
+	}
+}
+
+// Render post-processes a GradeAssignmentResponse.
+func (body *GradeAssignmentResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// TutorWorkloadResponse contains the number of open and finished grades of a
+// tutor.
+type TutorWorkloadResponse struct {
+	TutorID   int64  `json:"tutor_id" example:"2"`
+	FirstName string `json:"first_name" example:"Max"`
+	LastName  string `json:"last_name" example:"Mustermensch"`
+	Email     string `json:"email" example:"test@unit-tuebingen.de"`
+	Open      int    `json:"open" example:"12"`
+	Finished  int    `json:"finished" example:"40"`
+}
+
+// Render post-processes a TutorWorkloadResponse.
+func (body *TutorWorkloadResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newTutorWorkloadListResponse creates a response from a list of workloads.
+func newTutorWorkloadListResponse(workloads []model.TutorWorkload) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range workloads {
+		list = append(list, &TutorWorkloadResponse{
+			TutorID:   workloads[k].TutorID,
+			FirstName: workloads[k].FirstName,
+			LastName:  workloads[k].LastName,
+			Email:     workloads[k].Email,
+			Open:      workloads[k].Open,
+			Finished:  workloads[k].Finished,
+		})
+	}
+	return list
+}
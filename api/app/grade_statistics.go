@@ -0,0 +1,298 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"sort"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// A grade counts as graded as soon as a tutor has written a feedback (see
+// GetAllMissingGrades). Points of ungraded grades are not part of the
+// point statistics as they are 0 by default. Ungraded grades without a
+// responsible tutor (tutor id 0) are not part of any tutor statistics.
+
+// TaskStatistics aggregates all grades of a single task.
+type TaskStatistics struct {
+	TaskID               int64   `json:"task_id" example:"4"`
+	TaskName             string  `json:"task_name" example:"Task 1"`
+	SheetID              int64   `json:"sheet_id" example:"2"`
+	MaxPoints            int     `json:"max_points" example:"10"`
+	Submissions          int     `json:"submissions" example:"120"`
+	Graded               int     `json:"graded" example:"97"`
+	Mean                 float64 `json:"mean" example:"6.4"`
+	Median               float64 `json:"median" example:"7"`
+	Histogram            []int   `json:"histogram" example:"0,1,4,2,7,9,20,31,15,6,2"`
+	PublicTestsFinished  int     `json:"public_tests_finished" example:"118"`
+	PublicTestPassRate   float64 `json:"public_test_pass_rate" example:"0.91"`
+	PrivateTestsFinished int     `json:"private_tests_finished" example:"118"`
+	PrivateTestPassRate  float64 `json:"private_test_pass_rate" example:"0.74"`
+}
+
+// TutorStatistics aggregates all grades a tutor is responsible for. The
+// leniency is the difference between the percentage this tutor gives and the
+// percentage over all tutors.
+type TutorStatistics struct {
+	TutorID            int64   `json:"tutor_id" example:"2"`
+	FirstName          string  `json:"first_name" example:"Max"`
+	LastName           string  `json:"last_name" example:"Mustermensch"`
+	Assigned           int     `json:"assigned" example:"40"`
+	Graded             int     `json:"graded" example:"38"`
+	Percentage         float64 `json:"percentage" example:"71.5"`
+	Leniency           float64 `json:"leniency" example:"3.2"`
+	MedianGradingHours float64 `json:"median_grading_hours" example:"52.5"`
+}
+
+// GroupTaskStatistics is the mean and median of a task within a group.
+type GroupTaskStatistics struct {
+	TaskID int64   `json:"task_id" example:"4"`
+	Graded int     `json:"graded" example:"18"`
+	Mean   float64 `json:"mean" example:"6.9"`
+	Median float64 `json:"median" example:"7"`
+}
+
+// GroupStatistics aggregates all grades of the members of a group. The
+// deviation is the difference between the percentage in this group and the
+// percentage over all groups.
+type GroupStatistics struct {
+	GroupID    int64                 `json:"group_id" example:"3"`
+	TutorIDs   []int64               `json:"tutor_ids" example:"2"`
+	Graded     int                   `json:"graded" example:"70"`
+	Percentage float64               `json:"percentage" example:"68.2"`
+	Deviation  float64               `json:"deviation" example:"-1.4"`
+	Tasks      []GroupTaskStatistics `json:"tasks"`
+}
+
+// pointCollector accumulates points and their maximum.
+type pointCollector struct {
+	points    []float64
+	acquired  int
+	maxPoints int
+}
+
+func (pc *pointCollector) add(entry model.GradeStatisticEntry) {
+	pc.points = append(pc.points, float64(entry.AcquiredPoints))
+	pc.acquired += entry.AcquiredPoints
+	pc.maxPoints += entry.MaxPoints
+}
+
+func (pc *pointCollector) percentage() float64 {
+	if pc.maxPoints == 0 {
+		return 0
+	}
+	return 100 * float64(pc.acquired) / float64(pc.maxPoints)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func isGraded(entry model.GradeStatisticEntry) bool {
+	return entry.Feedback != ""
+}
+
+// ComputeGradeStatistics aggregates the given grades per task, per tutor and
+// per group. The entries must be ordered by sheet and task.
+func ComputeGradeStatistics(entries []model.GradeStatisticEntry) ([]TaskStatistics, []TutorStatistics, []GroupStatistics) {
+	tasks := []TaskStatistics{}
+	taskPoints := []pointCollector{}
+	task2pos := make(map[int64]int)
+
+	tutors := []TutorStatistics{}
+	tutorPoints := []pointCollector{}
+	tutorHours := [][]float64{}
+	tutor2pos := make(map[int64]int)
+
+	groups := []GroupStatistics{}
+	groupPoints := []pointCollector{}
+	groupTaskPoints := []map[int64]*pointCollector{}
+	group2pos := make(map[int64]int)
+
+	publicPassed := make(map[int64]int)
+	privatePassed := make(map[int64]int)
+	total := pointCollector{}
+
+	for _, entry := range entries {
+		tk, ok := task2pos[entry.TaskID]
+		if !ok {
+			tk = len(tasks)
+			task2pos[entry.TaskID] = tk
+			tasks = append(tasks, TaskStatistics{
+				TaskID:    entry.TaskID,
+				TaskName:  entry.TaskName,
+				SheetID:   entry.SheetID,
+				MaxPoints: entry.MaxPoints,
+				Histogram: make([]int, entry.MaxPoints+1),
+			})
+			taskPoints = append(taskPoints, pointCollector{})
+		}
+
+		tr := -1
+		if entry.TutorID != 0 {
+			tr, ok = tutor2pos[entry.TutorID]
+			if !ok {
+				tr = len(tutors)
+				tutor2pos[entry.TutorID] = tr
+				tutors = append(tutors, TutorStatistics{
+					TutorID:   entry.TutorID,
+					FirstName: entry.TutorFirstName,
+					LastName:  entry.TutorLastName,
+				})
+				tutorPoints = append(tutorPoints, pointCollector{})
+				tutorHours = append(tutorHours, []float64{})
+			}
+		}
+
+		gr := -1
+		if entry.GroupID != 0 {
+			gr, ok = group2pos[entry.GroupID]
+			if !ok {
+				gr = len(groups)
+				group2pos[entry.GroupID] = gr
+				groups = append(groups, GroupStatistics{
+					GroupID:  entry.GroupID,
+					TutorIDs: []int64{},
+					Tasks:    []GroupTaskStatistics{},
+				})
+				groupPoints = append(groupPoints, pointCollector{})
+				groupTaskPoints = append(groupTaskPoints, make(map[int64]*pointCollector))
+			}
+
+			knownTutor := entry.TutorID == 0
+			for _, tutorID := range groups[gr].TutorIDs {
+				if tutorID == entry.TutorID {
+					knownTutor = true
+				}
+			}
+			if !knownTutor {
+				groups[gr].TutorIDs = append(groups[gr].TutorIDs, entry.TutorID)
+			}
+		}
+
+		tasks[tk].Submissions++
+		if tr >= 0 {
+			tutors[tr].Assigned++
+		}
+
+		if entry.PublicExecutionState == int(symbol.TestingStateFinished) {
+			tasks[tk].PublicTestsFinished++
+			if entry.PublicTestStatus == int(symbol.TestingResultSuccess) {
+				publicPassed[entry.TaskID]++
+			}
+		}
+		if entry.PrivateExecutionState == int(symbol.TestingStateFinished) {
+			tasks[tk].PrivateTestsFinished++
+			if entry.PrivateTestStatus == int(symbol.TestingResultSuccess) {
+				privatePassed[entry.TaskID]++
+			}
+		}
+
+		if !isGraded(entry) {
+			continue
+		}
+
+		total.add(entry)
+
+		tasks[tk].Graded++
+		taskPoints[tk].add(entry)
+		if entry.AcquiredPoints >= 0 && entry.AcquiredPoints < len(tasks[tk].Histogram) {
+			tasks[tk].Histogram[entry.AcquiredPoints]++
+		}
+
+		if tr >= 0 {
+			tutors[tr].Graded++
+			tutorPoints[tr].add(entry)
+			hours := entry.UpdatedAt.Sub(entry.SheetDueAt).Hours()
+			if hours < 0 {
+				hours = 0
+			}
+			tutorHours[tr] = append(tutorHours[tr], hours)
+		}
+
+		if gr >= 0 {
+			groups[gr].Graded++
+			groupPoints[gr].add(entry)
+			if _, ok := groupTaskPoints[gr][entry.TaskID]; !ok {
+				groupTaskPoints[gr][entry.TaskID] = &pointCollector{}
+				groups[gr].Tasks = append(groups[gr].Tasks, GroupTaskStatistics{TaskID: entry.TaskID})
+			}
+			groupTaskPoints[gr][entry.TaskID].add(entry)
+		}
+	}
+
+	for k := range tasks {
+		tasks[k].Mean = mean(taskPoints[k].points)
+		tasks[k].Median = median(taskPoints[k].points)
+		if tasks[k].PublicTestsFinished > 0 {
+			tasks[k].PublicTestPassRate = float64(publicPassed[tasks[k].TaskID]) / float64(tasks[k].PublicTestsFinished)
+		}
+		if tasks[k].PrivateTestsFinished > 0 {
+			tasks[k].PrivateTestPassRate = float64(privatePassed[tasks[k].TaskID]) / float64(tasks[k].PrivateTestsFinished)
+		}
+	}
+
+	for k := range tutors {
+		tutors[k].Percentage = tutorPoints[k].percentage()
+		if tutors[k].Graded > 0 {
+			tutors[k].Leniency = tutors[k].Percentage - total.percentage()
+		}
+		tutors[k].MedianGradingHours = median(tutorHours[k])
+	}
+
+	for k := range groups {
+		groups[k].Percentage = groupPoints[k].percentage()
+		if groups[k].Graded > 0 {
+			groups[k].Deviation = groups[k].Percentage - total.percentage()
+		}
+		for j := range groups[k].Tasks {
+			collector := groupTaskPoints[k][groups[k].Tasks[j].TaskID]
+			groups[k].Tasks[j].Graded = len(collector.points)
+			groups[k].Tasks[j].Mean = mean(collector.points)
+			groups[k].Tasks[j].Median = median(collector.points)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].GroupID < groups[j].GroupID })
+	sort.Slice(tutors, func(i, j int) bool { return tutors[i].TutorID < tutors[j].TutorID })
+
+	return tasks, tutors, groups
+}
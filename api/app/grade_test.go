@@ -20,12 +20,14 @@
 package app
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/franela/goblin"
@@ -660,6 +662,497 @@ func TestGrade(t *testing.T) {
 
 		})
 
+		g.It("Should export grades only for admins", func() {
+			w := tape.Get("/api/v1/courses/1/grades/export")
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Get("/api/v1/courses/1/grades/export", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/export", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/export", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should export grades as csv", func() {
+			students, err := stores.Course.EnrolledUsers(1, []string{"0"}, "%%", "%%", "%%", "%%", "%%")
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1/grades/export?format=csv&columns=student_number,total,passed&student_number_width=12&student_number_prefix=S", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv")).IsTrue()
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(records)).Equal(len(students) + 1)
+			g.Assert(records[0]).Equal([]string{"student_number", "total", "passed"})
+
+			for _, record := range records[1:] {
+				g.Assert(len(record)).Equal(3)
+				g.Assert(strings.HasPrefix(record[0], "S")).IsTrue()
+				g.Assert(len(record[0]) >= 13).IsTrue()
+			}
+		})
+
+		g.It("Should export grades as xlsx", func() {
+			w := tape.Get("/api/v1/courses/1/grades/export?format=xlsx", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Header().Get("Content-Type")).Equal("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			g.Assert(helper.IsZipFile(w.Body.Bytes())).IsTrue()
+		})
+
+		g.It("Should reject unknown export options", func() {
+			w := tape.Get("/api/v1/courses/1/grades/export?format=pdf", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get("/api/v1/courses/1/grades/export?columns=student_number,password", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should compute statistics per task, tutor and group", func() {
+			entries := []model.GradeStatisticEntry{
+				{TaskID: 1, MaxPoints: 4, GroupID: 1, TutorID: 2, AcquiredPoints: 4, Feedback: "a",
+					PublicExecutionState: 2, PublicTestStatus: 0},
+				{TaskID: 1, MaxPoints: 4, GroupID: 1, TutorID: 2, AcquiredPoints: 2, Feedback: "b",
+					PublicExecutionState: 2, PublicTestStatus: 1},
+				{TaskID: 1, MaxPoints: 4, GroupID: 2, TutorID: 3, AcquiredPoints: 0, Feedback: "c"},
+				{TaskID: 1, MaxPoints: 4, GroupID: 2, TutorID: 3, AcquiredPoints: 0, Feedback: ""},
+			}
+
+			tasks, tutors, groups := ComputeGradeStatistics(entries)
+			g.Assert(len(tasks)).Equal(1)
+			g.Assert(tasks[0].Submissions).Equal(4)
+			g.Assert(tasks[0].Graded).Equal(3)
+			g.Assert(tasks[0].Mean).Equal(2.0)
+			g.Assert(tasks[0].Median).Equal(2.0)
+			g.Assert(tasks[0].Histogram).Equal([]int{1, 0, 1, 0, 1})
+			g.Assert(tasks[0].PublicTestsFinished).Equal(2)
+			g.Assert(tasks[0].PublicTestPassRate).Equal(0.5)
+
+			g.Assert(len(tutors)).Equal(2)
+			g.Assert(tutors[0].TutorID).Equal(int64(2))
+			g.Assert(tutors[0].Percentage).Equal(75.0)
+			g.Assert(tutors[0].Leniency).Equal(25.0)
+			g.Assert(tutors[1].Assigned).Equal(2)
+			g.Assert(tutors[1].Graded).Equal(1)
+			g.Assert(tutors[1].Leniency).Equal(-50.0)
+
+			g.Assert(len(groups)).Equal(2)
+			g.Assert(groups[0].Deviation).Equal(25.0)
+			g.Assert(groups[1].TutorIDs).Equal([]int64{3})
+			g.Assert(groups[1].Tasks[0].Mean).Equal(0.0)
+		})
+
+		g.It("Should not attribute ungraded grades to the placeholder tutor", func() {
+			entries := []model.GradeStatisticEntry{
+				{TaskID: 1, MaxPoints: 4, GroupID: 1, TutorID: 2, AcquiredPoints: 4, Feedback: "a"},
+				// pooled, nobody is responsible
+				{TaskID: 1, MaxPoints: 4, GroupID: 1, TutorID: 0, AcquiredPoints: 0, Feedback: ""},
+			}
+
+			tasks, tutors, groups := ComputeGradeStatistics(entries)
+			g.Assert(tasks[0].Submissions).Equal(2)
+			g.Assert(len(tutors)).Equal(1)
+			g.Assert(tutors[0].Assigned).Equal(1)
+			g.Assert(tutors[0].Percentage).Equal(100.0)
+			g.Assert(groups[0].TutorIDs).Equal([]int64{2})
+
+			// in the database, ungraded grades carry the placeholder tutor 1
+			_, err := tape.DB.Exec(`
+UPDATE grades SET feedback = '', tutor_id = 1, assigned_tutor_id = 2
+WHERE submission_id IN (
+  SELECT s.id FROM submissions s
+  INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+  WHERE ts.sheet_id = 1)`)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1/sheets/1/statistics", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := GradeStatisticsResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+
+			submissions := 0
+			for _, task := range result.Tasks {
+				g.Assert(task.Graded).Equal(0)
+				submissions += task.Submissions
+			}
+			g.Assert(len(result.Tutors)).Equal(1)
+			g.Assert(result.Tutors[0].TutorID).Equal(int64(2))
+			g.Assert(result.Tutors[0].Assigned).Equal(submissions)
+			g.Assert(result.Tutors[0].Graded).Equal(0)
+			for _, group := range result.Groups {
+				g.Assert(group.TutorIDs).Equal([]int64{2})
+			}
+		})
+
+		g.It("Should return statistics only for admins", func() {
+			w := tape.Get("/api/v1/courses/1/grades/statistics", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/statistics", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := GradeStatisticsResponse{}
+			err := json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(result.Tasks) > 0).IsTrue()
+			g.Assert(len(result.Tutors) > 0).IsTrue()
+
+			w = tape.Get("/api/v1/courses/1/sheets/1/statistics", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/sheets/1/statistics", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result = GradeStatisticsResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.SheetID).Equal(int64(1))
+			for _, task := range result.Tasks {
+				g.Assert(task.SheetID).Equal(int64(1))
+			}
+		})
+
+		g.It("Should move ungraded submissions through the pool", func() {
+			grade, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			grade.Feedback = ""
+			g.Assert(stores.Grade.Update(grade)).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/grades/assign", H{"grade_ids": []int64{1}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/grades/assign", H{"grade_ids": []int64{1}}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.Pooled).IsTrue()
+			g.Assert(gradeAfter.AssignedTutorID.Valid).IsFalse()
+
+			pool := []MissingGradeResponse{}
+			w = tape.Get("/api/v1/courses/1/grades/pool", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+			w = tape.Get("/api/v1/courses/1/grades/pool", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&pool)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pool)).Equal(1)
+			g.Assert(pool[0].Grade.ID).Equal(int64(1))
+
+			w = tape.Post("/api/v1/courses/1/grades/pool/claim", H{"count": 5}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			claimed := GradeAssignmentResponse{}
+			err = json.NewDecoder(w.Body).Decode(&claimed)
+			g.Assert(err).Equal(nil)
+			g.Assert(claimed.GradeIDs).Equal([]int64{1})
+
+			gradeAfter, err = stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.Pooled).IsFalse()
+			g.Assert(gradeAfter.AssignedTutorID.Int64).Equal(tutorJWT.Claims.LoginID)
+
+			missing, err := stores.Grade.GetAllMissingGrades(1, tutorJWT.Claims.LoginID, 0)
+			g.Assert(err).Equal(nil)
+			found := false
+			for _, entry := range missing {
+				if entry.ID == 1 {
+					found = true
+				}
+			}
+			g.Assert(found).IsTrue()
+
+			// nothing left to claim
+			w = tape.Post("/api/v1/courses/1/grades/pool/claim", H{"grade_ids": []int64{1}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&claimed)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(claimed.GradeIDs)).Equal(0)
+		})
+
+		g.It("Should report the workload of tutors", func() {
+			w := tape.Get("/api/v1/courses/1/grades/workload", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/workload", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			workloads := []TutorWorkloadResponse{}
+			err := json.NewDecoder(w.Body).Decode(&workloads)
+			g.Assert(err).Equal(nil)
+
+			for _, workload := range workloads {
+				missing, err := stores.Grade.GetAllMissingGrades(1, workload.TutorID, 0)
+				g.Assert(err).Equal(nil)
+				g.Assert(workload.Open).Equal(len(missing))
+			}
+		})
+
+		g.It("Should count the groups of co-tutors in their workload", func() {
+			w := tape.Post("/api/v1/courses/1/groups/1/tutors", H{"user_id": 2, "role": 1}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/grades/workload", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			workloads := []TutorWorkloadResponse{}
+			err := json.NewDecoder(w.Body).Decode(&workloads)
+			g.Assert(err).Equal(nil)
+
+			found := false
+			for _, workload := range workloads {
+				if workload.TutorID != 2 {
+					continue
+				}
+				found = true
+				missing, err := stores.Grade.GetAllMissingGrades(1, 2, 0)
+				g.Assert(err).Equal(nil)
+				g.Assert(workload.Open).Equal(len(missing))
+			}
+			g.Assert(found).IsTrue()
+		})
+
+		g.It("Should reject invalid assignments", func() {
+			w := tape.Post("/api/v1/courses/1/grades/assign", H{}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/grades/assign", H{"grade_ids": []int64{1}, "tutor_id": 112}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should leave out anonymous sheets in the summary for tutors", func() {
+			task, err := stores.Grade.IdentifyTaskOfGrade(1)
+			g.Assert(err).Equal(nil)
+			sheet, err := stores.Task.IdentifySheetOfTask(task.ID)
+			g.Assert(err).Equal(nil)
+
+			sheet.Anonymous = true
+			g.Assert(stores.Sheet.Update(sheet)).Equal(nil)
+
+			sheetIDs := func(jwt JWTRequest) []int64 {
+				response := GradeOverviewResponse{}
+				w := tape.Get("/api/v1/courses/1/grades/summary", jwt)
+				g.Assert(w.Code).Equal(http.StatusOK)
+				g.Assert(json.NewDecoder(w.Body).Decode(&response)).Equal(nil)
+
+				ids := []int64{}
+				for _, info := range response.Sheets {
+					ids = append(ids, info.ID)
+				}
+				for _, achievement := range response.Achievements {
+					g.Assert(len(achievement.Points)).Equal(len(ids))
+				}
+				return ids
+			}
+
+			contains := func(ids []int64, id int64) bool {
+				for _, other := range ids {
+					if other == id {
+						return true
+					}
+				}
+				return false
+			}
+
+			g.Assert(contains(sheetIDs(adminJWT), sheet.ID)).IsTrue()
+			g.Assert(contains(sheetIDs(tutorJWT), sheet.ID)).IsFalse()
+		})
+
+		g.It("Should hide the identity of students in anonymous sheets from tutors", func() {
+			grade, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			task, err := stores.Grade.IdentifyTaskOfGrade(1)
+			g.Assert(err).Equal(nil)
+			sheet, err := stores.Task.IdentifySheetOfTask(task.ID)
+			g.Assert(err).Equal(nil)
+
+			sheet.Anonymous = true
+			g.Assert(stores.Sheet.Update(sheet)).Equal(nil)
+
+			pseudonym := Pseudonym(1, grade.UserID)
+			g.Assert(pseudonym).Equal(Pseudonym(1, grade.UserID))
+			g.Assert(pseudonym != Pseudonym(2, grade.UserID)).IsTrue()
+
+			result := GradeResponse{}
+			w := tape.Get("/api/v1/courses/1/grades/1", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.User.ID).Equal(int64(0))
+			g.Assert(result.User.FirstName).Equal(pseudonym)
+			g.Assert(result.User.Email).Equal("")
+
+			w = tape.Get("/api/v1/courses/1/grades/1", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.User.ID).Equal(grade.UserID)
+			g.Assert(result.User.Email).Equal(grade.UserEmail)
+
+			// tutors cannot find the submissions of a specific student
+			submissions := []SubmissionResponse{}
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/submissions?sheet_id=%d&user_id=%d", sheet.ID, grade.UserID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&submissions)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(submissions)).Equal(0)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/submissions?sheet_id=%d", sheet.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&submissions)
+			g.Assert(err).Equal(nil)
+			for _, submission := range submissions {
+				g.Assert(submission.UserID).Equal(int64(0))
+			}
+		})
+
+		g.Describe("Import", func() {
+			writeImport := func(content string) string {
+				f, err := os.CreateTemp("", "grades-*.csv")
+				g.Assert(err).Equal(nil)
+				defer f.Close()
+				_, err = f.WriteString(content)
+				g.Assert(err).Equal(nil)
+				return f.Name()
+			}
+
+			g.It("Should validate the import without writing in a dry-run", func() {
+				gradeBefore, err := stores.Grade.Get(1)
+				g.Assert(err).Equal(nil)
+				user, err := stores.User.Get(gradeBefore.UserID)
+				g.Assert(err).Equal(nil)
+				task, err := stores.Grade.IdentifyTaskOfGrade(1)
+				g.Assert(err).Equal(nil)
+
+				filename := writeImport(fmt.Sprintf("student,task,points,feedback\n%s,%d,%d,imported feedback\n",
+					user.Email, task.ID, task.MaxPoints))
+				defer os.Remove(filename)
+
+				w, err := tape.Upload("/api/v1/courses/1/grades/import?dry_run=true", filename, "text/csv", adminJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusOK)
+
+				result := GradeImportResponse{}
+				err = json.NewDecoder(w.Body).Decode(&result)
+				g.Assert(err).Equal(nil)
+				g.Assert(result.DryRun).IsTrue()
+				g.Assert(result.Applied).IsFalse()
+				g.Assert(len(result.Changes)).Equal(1)
+				g.Assert(result.Changes[0].GradeID).Equal(int64(1))
+				g.Assert(result.Changes[0].OldPoints).Equal(gradeBefore.AcquiredPoints)
+				g.Assert(result.Changes[0].NewPoints).Equal(task.MaxPoints)
+
+				gradeAfter, err := stores.Grade.Get(1)
+				g.Assert(err).Equal(nil)
+				g.Assert(gradeAfter.AcquiredPoints).Equal(gradeBefore.AcquiredPoints)
+				g.Assert(gradeAfter.Feedback).Equal(gradeBefore.Feedback)
+			})
+
+			g.It("Should apply a valid import", func() {
+				gradeBefore, err := stores.Grade.Get(1)
+				g.Assert(err).Equal(nil)
+				user, err := stores.User.Get(gradeBefore.UserID)
+				g.Assert(err).Equal(nil)
+				task, err := stores.Grade.IdentifyTaskOfGrade(1)
+				g.Assert(err).Equal(nil)
+
+				filename := writeImport(fmt.Sprintf("feedback;points;task;student\nimported feedback;%d;%d;%s\n",
+					task.MaxPoints, task.ID, user.StudentNumber))
+				defer os.Remove(filename)
+
+				w, err := tape.Upload("/api/v1/courses/1/grades/import?delimiter=;", filename, "text/csv", adminJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusOK)
+
+				gradeAfter, err := stores.Grade.Get(1)
+				g.Assert(err).Equal(nil)
+				g.Assert(gradeAfter.AcquiredPoints).Equal(task.MaxPoints)
+				g.Assert(gradeAfter.Feedback).Equal("imported feedback")
+				g.Assert(gradeAfter.TutorID).Equal(int64(1))
+			})
+
+			g.It("Should reject the whole import if a single row is invalid", func() {
+				gradeBefore, err := stores.Grade.Get(1)
+				g.Assert(err).Equal(nil)
+				user, err := stores.User.Get(gradeBefore.UserID)
+				g.Assert(err).Equal(nil)
+				task, err := stores.Grade.IdentifyTaskOfGrade(1)
+				g.Assert(err).Equal(nil)
+
+				filename := writeImport(fmt.Sprintf("student,task,points,feedback\n%s,%d,%d,fine\n%s,%d,%d,too much\nnobody@example.com,%d,0,unknown\n",
+					user.Email, task.ID, 0,
+					user.Email, task.ID, task.MaxPoints+1,
+					task.ID))
+				defer os.Remove(filename)
+
+				w, err := tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", adminJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+				result := GradeImportResponse{}
+				err = json.NewDecoder(w.Body).Decode(&result)
+				g.Assert(err).Equal(nil)
+				g.Assert(result.Applied).IsFalse()
+				g.Assert(len(result.Errors)).Equal(2)
+				g.Assert(result.Errors[0].Line).Equal(3)
+				g.Assert(result.Errors[1].Line).Equal(4)
+
+				gradeAfter, err := stores.Grade.Get(1)
+				g.Assert(err).Equal(nil)
+				g.Assert(gradeAfter.AcquiredPoints).Equal(gradeBefore.AcquiredPoints)
+			})
+
+			g.It("Should reject rows without a student", func() {
+				task, err := stores.Grade.IdentifyTaskOfGrade(1)
+				g.Assert(err).Equal(nil)
+
+				// one student without a student number must not be matched
+				user, err := stores.User.Get(112)
+				g.Assert(err).Equal(nil)
+				user.StudentNumber = ""
+				g.Assert(stores.User.Update(user)).Equal(nil)
+
+				filename := writeImport(fmt.Sprintf("student,task,points,feedback\n ,%d,0,blank\n", task.ID))
+				defer os.Remove(filename)
+
+				w, err := tape.Upload("/api/v1/courses/1/grades/import?dry_run=true", filename, "text/csv", adminJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+				result := GradeImportResponse{}
+				err = json.NewDecoder(w.Body).Decode(&result)
+				g.Assert(err).Equal(nil)
+				g.Assert(len(result.Errors)).Equal(1)
+				g.Assert(result.Errors[0].Line).Equal(2)
+				g.Assert(result.Errors[0].Message).Equal("student is missing")
+			})
+
+			g.It("Should restrict tutors to their own groups", func() {
+				filename := writeImport("student,task,points,feedback\n")
+				defer os.Remove(filename)
+
+				w, err := tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", studentJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusForbidden)
+
+				w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", tutorJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusForbidden)
+
+				groups, err := stores.Group.GetOfTutor(tutorJWT.Claims.LoginID, 1)
+				g.Assert(err).Equal(nil)
+				g.Assert(len(groups) > 0).IsTrue()
+
+				w, err = tape.Upload(fmt.Sprintf("/api/v1/courses/1/grades/import?group_id=%d", groups[0].ID), filename, "text/csv", tutorJWT)
+				g.Assert(err).Equal(nil)
+				g.Assert(w.Code).Equal(http.StatusOK)
+			})
+		})
+
 		g.AfterEach(func() {
 			tape.AfterEach()
 		})
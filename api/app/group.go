@@ -21,13 +21,16 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/assignment"
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/auth/authenticate"
 	"github.com/infomark-org/infomark/auth/authorize"
@@ -75,8 +78,14 @@ func (rs *GroupResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	slots, err := rs.Stores.Group.GetSlotsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// render JSON response
-	if err = render.RenderList(w, r, rs.newGroupListResponse(groups)); err != nil {
+	if err = render.RenderList(w, r, rs.newGroupListResponse(groups, slots)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -93,6 +102,9 @@ func (rs *GroupResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  create a new group
+// DESCRIPTION:
+// Weekly meetings must not collide with meetings of other groups in the course
+// which have the same tutor or take place in the same location.
 func (rs *GroupResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 
 	// start from empty Request
@@ -109,6 +121,7 @@ func (rs *GroupResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	group.TutorID = data.Tutor.ID
 	group.CourseID = course.ID
 	group.Description = data.Description
+	group.Capacity = data.Capacity
 
 	tutor, err := rs.Stores.User.Get(group.TutorID)
 	if err != nil {
@@ -116,17 +129,34 @@ func (rs *GroupResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	slots := data.SlotModels()
+	conflicts, err := FindGroupSlotConflicts(rs.Stores, course.ID, 0, group.TutorID, slots)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if len(conflicts) > 0 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New(strings.Join(conflicts, "; "))))
+		return
+	}
+
 	// create Group entry in database
-	newGroup, err := rs.Stores.Group.Create(group)
+	newGroup, err := rs.Stores.Group.CreateWithSlots(group, slots)
 	if err != nil {
-		render.Render(w, r, ErrRender(err))
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	slots, err = rs.Stores.Group.GetSlots(newGroup.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
 
 	render.Status(r, http.StatusCreated)
 
 	// return Group information of created entry
-	if err := render.Render(w, r, rs.newGroupResponse(newGroup, tutor)); err != nil {
+	if err := render.Render(w, r, rs.newGroupResponse(newGroup, tutor, slots)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -154,8 +184,23 @@ func (rs *GroupResource) GetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	slots, err := rs.Stores.Group.GetSlots(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	members, err := rs.Stores.Group.GetMembers(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	response := rs.newGroupResponse(group, tutor, slots)
+	response.Members = len(members)
+
 	// render JSON response
-	if err := render.Render(w, r, rs.newGroupResponse(group, tutor)); err != nil {
+	if err := render.Render(w, r, response); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -215,8 +260,14 @@ func (rs *GroupResource) GetMineHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	slots, err := rs.Stores.Group.GetSlotsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// render JSON response
-	if err := render.RenderList(w, r, rs.newGroupListResponse(groups)); err != nil {
+	if err := render.RenderList(w, r, rs.newGroupListResponse(groups, slots)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -237,6 +288,10 @@ func (rs *GroupResource) GetMineHandler(w http.ResponseWriter, r *http.Request)
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  update a specific group
+// DESCRIPTION:
+// If "slots" is given, all weekly meetings of the group are replaced. They must
+// not collide with meetings of other groups in the course which have the same
+// tutor or take place in the same location.
 func (rs *GroupResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	// start from empty Request
 	data := &GroupRequest{}
@@ -250,6 +305,28 @@ func (rs *GroupResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
 	group.TutorID = data.Tutor.ID
 	group.Description = data.Description
+	group.Capacity = data.Capacity
+
+	// without new slots the existing ones must still fit to the (new) tutor
+	slots := data.SlotModels()
+	if data.Slots == nil {
+		var err error
+		slots, err = rs.Stores.Group.GetSlots(group.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	conflicts, err := FindGroupSlotConflicts(rs.Stores, group.CourseID, group.ID, group.TutorID, slots)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if len(conflicts) > 0 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New(strings.Join(conflicts, "; "))))
+		return
+	}
 
 	// update database entry
 	if err := rs.Stores.Group.Update(group); err != nil {
@@ -257,6 +334,19 @@ func (rs *GroupResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if data.Slots != nil {
+		if err := rs.Stores.Group.ReplaceSlots(group.ID, slots); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	// a raised capacity gives seats to the waitlist
+	if err := rs.Stores.Group.PromoteWaitlist(group.CourseID, group.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	render.Status(r, http.StatusNoContent)
 }
 
@@ -352,6 +442,9 @@ func (rs *GroupResource) IndexEnrollmentsHandler(w http.ResponseWriter, r *http.
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  will assign a given user to a group or change the group assignment
+// DESCRIPTION:
+// Fails if the group has reached its capacity. A seat freed in the previous
+// group is given to the waitlist of that group.
 func (rs *GroupResource) EditGroupEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
 	// start from empty Request
 	data := &GroupEnrollmentRequest{}
@@ -365,29 +458,16 @@ func (rs *GroupResource) EditGroupEnrollmentHandler(w http.ResponseWriter, r *ht
 	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 
-	enrollment, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(data.UserID, course.ID)
-
+	// creates the enrollment or changes an existing one within the course
+	join, err := rs.Stores.Group.JoinGroup(course.ID, group.ID, data.UserID, false)
 	if err != nil {
-		// does not exists yet
-
-		enrollment := &model.GroupEnrollment{
-			UserID:  data.UserID,
-			GroupID: group.ID,
-		}
-
-		_, err := rs.Stores.Group.CreateGroupEnrollmentOfUserInCourse(enrollment)
-		if err != nil {
-			render.Render(w, r, ErrRender(err))
-			return
-		}
+		render.Render(w, r, ErrRender(err))
+		return
+	}
 
-	} else {
-		// does exists --> simply change it
-		enrollment.GroupID = group.ID
-		if err := rs.Stores.Group.ChangeGroupEnrollmentOfUserInCourse(enrollment); err != nil {
-			render.Render(w, r, ErrRender(err))
-			return
-		}
+	if !join.Enrolled {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("group is full")))
+		return
 	}
 
 	render.Status(r, http.StatusNoContent)
@@ -456,6 +536,445 @@ func (rs *GroupResource) ChangeBidHandler(w http.ResponseWriter, r *http.Request
 	render.Status(r, http.StatusNoContent)
 }
 
+// AssignHandler is public endpoint for
+// URL: /courses/{course_id}/groups/assignments
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: groups
+// REQUEST: GroupAssignmentRequest
+// RESPONSE: 200,GroupAssignmentResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  distribute all students to groups according to their bids
+// DESCRIPTION:
+// The assignment maximizes the sum of all bids while each group gets between
+// min_per_group and max_per_group students. Students without a bid for a group
+// are treated as if they bid 10 for it. No group exceeds its capacity. Unless
+// dry_run is set, all group enrollments of the course are replaced in a single
+// transaction.
+func (rs *GroupResource) AssignHandler(w http.ResponseWriter, r *http.Request) {
+
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &GroupAssignmentRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	solution, err := SolveGroupAssignment(rs.Stores, course.ID, data.MinPerGroup, data.MaxPerGroup)
+	if errors.Is(err, assignment.ErrInfeasible) {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if !data.DryRun {
+		if err := solution.Apply(rs.Stores, course.ID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	if err := render.Render(w, r, newGroupAssignmentResponse(solution, data.DryRun, !data.DryRun)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// JoinHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/join
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: groups
+// RESPONSE: 200,GroupJoinResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  join a group or its waitlist during the self-enrollment phase
+// DESCRIPTION:
+// A student who is already in a group of the course changes the group. If the
+// group is full, the student is put on its waitlist when the course uses
+// waitlists. Otherwise the request fails. Students wait for at most one group
+// and are moved automatically as soon as a seat is free.
+func (rs *GroupResource) JoinHandler(w http.ResponseWriter, r *http.Request) {
+	courseRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	if courseRole != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("only students in a course can join a group")))
+		return
+	}
+
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	if err := checkGroupSelfEnrollment(course, NowUTC()); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	join, err := rs.Stores.Group.JoinGroup(course.ID, group.ID, accessClaims.LoginID,
+		course.GroupEnrollmentMode == GroupEnrollmentWaitlist)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if !join.Enrolled && !join.Waitlisted {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("group is full")))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	if err := render.Render(w, r, &GroupJoinResponse{
+		Enrolled:   join.Enrolled,
+		Waitlisted: join.Waitlisted,
+		Position:   join.Position,
+	}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// LeaveHandler is public endpoint for
+// URL: /courses/{course_id}/groups/own
+// URLPARAM: course_id,integer
+// METHOD: delete
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  leave the own group and all waitlists during the self-enrollment phase
+func (rs *GroupResource) LeaveHandler(w http.ResponseWriter, r *http.Request) {
+	courseRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	if courseRole != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("only students in a course can leave a group")))
+		return
+	}
+
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	if err := checkGroupSelfEnrollment(course, NowUTC()); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Group.LeaveGroup(course.ID, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// GetWaitlistHandler is public endpoint for
+// URL: /courses/{course_id}/groups/waitlist
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupWaitlistResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// RESPONSE: 404,NotFound
+// SUMMARY:  get the group the identity is waiting for
+func (rs *GroupResource) GetWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	groupID, position, err := rs.Stores.Group.GetWaitlistOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err == sql.ErrNoRows {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	if err := render.Render(w, r, &GroupWaitlistResponse{GroupID: groupID, Position: position}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// IndexSwapsHandler is public endpoint for
+// URL: /courses/{course_id}/groups/swaps
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupSwapResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get all swap requests from and to the identity
+func (rs *GroupResource) IndexSwapsHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	swaps, err := rs.Stores.Group.GetGroupSwapsOfUser(course.ID, accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	list := []render.Renderer{}
+	for _, swap := range swaps {
+		response := &GroupSwapResponse{
+			ID:        swap.ID,
+			CreatedAt: swap.CreatedAt,
+			Outgoing:  swap.UserID == accessClaims.LoginID,
+			UserID:    swap.UserID,
+		}
+		if response.Outgoing {
+			response.UserID = swap.PartnerID
+		}
+
+		other, err := rs.Stores.User.Get(response.UserID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		response.FirstName = other.FirstName
+		response.LastName = other.LastName
+		response.Email = other.Email
+
+		enrollment, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(other.ID, course.ID)
+		if err == nil {
+			response.GroupID = enrollment.GroupID
+		}
+
+		list = append(list, response)
+	}
+
+	if err := render.RenderList(w, r, list); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// RequestSwapHandler is public endpoint for
+// URL: /courses/{course_id}/groups/swaps
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: groups
+// REQUEST: GroupSwapRequest
+// RESPONSE: 200,GroupSwapResultResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  ask another student to swap the groups
+// DESCRIPTION:
+// Each student has at most one swap request per course, a new one replaces the
+// previous one. As soon as the partner asks for the same swap, both students
+// change their groups. Capacities are not affected by a swap.
+func (rs *GroupResource) RequestSwapHandler(w http.ResponseWriter, r *http.Request) {
+	courseRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	if courseRole != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("only students in a course can swap groups")))
+		return
+	}
+
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &GroupSwapRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := checkGroupSelfEnrollment(course, NowUTC()); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	partner, err := rs.Stores.User.FindByEmail(data.PartnerEmail)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("partner is not a student of the course")))
+		return
+	}
+	if partner.ID == accessClaims.LoginID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("cannot swap with yourself")))
+		return
+	}
+
+	partnerRole, err := rs.Stores.Course.RoleInCourse(partner.ID, course.ID)
+	if err != nil || partnerRole != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("partner is not a student of the course")))
+		return
+	}
+
+	own, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are not enrolled in a group")))
+		return
+	}
+	other, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(partner.ID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("partner is not enrolled in a group")))
+		return
+	}
+	if own.GroupID == other.GroupID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("partner is already in your group")))
+		return
+	}
+
+	swapped, err := rs.Stores.Group.RequestGroupSwap(course.ID, accessClaims.LoginID, partner.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	if err := render.Render(w, r, &GroupSwapResultResponse{Swapped: swapped}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// DeleteSwapHandler is public endpoint for
+// URL: /courses/{course_id}/groups/swaps
+// URLPARAM: course_id,integer
+// METHOD: delete
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  withdraw the own swap request
+func (rs *GroupResource) DeleteSwapHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	if err := rs.Stores.Group.DeleteGroupSwapOfUser(course.ID, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// IndexTutorsHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/tutors
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupTutorResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list the lead tutor, co-tutors and substitutes of a group
+func (rs *GroupResource) IndexTutorsHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	tutors, err := rs.Stores.Group.GetTutors(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newGroupTutorListResponse(tutors)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ChangeTutorHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/tutors
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: groups
+// REQUEST: GroupTutorRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  add a tutor to a group or change the role of a tutor
+// DESCRIPTION:
+// Roles are 0 (lead), 1 (co-tutor) and 2 (substitute). A new lead tutor replaces
+// the previous one, who is no longer a tutor of this group.
+func (rs *GroupResource) ChangeTutorHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &GroupTutorRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	role, err := rs.Stores.Course.RoleInCourse(data.UserID, course.ID)
+	if err != nil || role < authorize.TUTOR {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("user is not a tutor of this course")))
+		return
+	}
+
+	if data.Role == GroupTutorLead {
+		err = rs.Stores.Group.SetLeadTutor(group.ID, data.UserID)
+	} else {
+		if data.UserID == group.TutorID {
+			render.Render(w, r, ErrBadRequestWithDetails(errors.New("user is the lead tutor of this group")))
+			return
+		}
+		err = rs.Stores.Group.SetTutor(&model.GroupTutor{
+			GroupID:    group.ID,
+			UserID:     data.UserID,
+			Role:       data.Role,
+			ValidFrom:  data.ValidFrom,
+			ValidUntil: data.ValidUntil,
+		})
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteTutorHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/tutors/{user_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: user_id,integer
+// METHOD: delete
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  remove a co-tutor or substitute from a group
+func (rs *GroupResource) DeleteTutorHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if user.ID == group.TutorID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("the lead tutor cannot be removed, assign another lead tutor instead")))
+		return
+	}
+
+	if err := rs.Stores.Group.RemoveTutor(group.ID, user.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
 // SendEmailHandler is public endpoint for
 // URL: /courses/{course_id}/groups/{group_id}/emails
 // URLPARAM: course_id,integer
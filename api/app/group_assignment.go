@@ -0,0 +1,174 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/infomark-org/infomark/api/assignment"
+	"github.com/infomark-org/infomark/model"
+)
+
+// DefaultGroupBid is the bid of students for groups they did not bid for. It
+// is the best possible bid, such that students without any preference are
+// placed wherever there is room left.
+const DefaultGroupBid = 10
+
+// GroupAssignmentEntry places a student into a group.
+type GroupAssignmentEntry struct {
+	UserID          int64  `json:"user_id" example:"112"`
+	FirstName       string `json:"first_name" example:"Max"`
+	LastName        string `json:"last_name" example:"Mustermensch"`
+	GroupID         int64  `json:"group_id" example:"3"`
+	Bid             int    `json:"bid" example:"8"`
+	PreviousGroupID int64  `json:"previous_group_id" example:"0"`
+}
+
+// GroupAssignmentCount is the number of students assigned to a group.
+type GroupAssignmentCount struct {
+	GroupID     int64  `json:"group_id" example:"3"`
+	Description string `json:"description" example:"Group on Monday"`
+	Students    int    `json:"students" example:"18"`
+}
+
+// GroupAssignment is the solution of distributing all students of a course to
+// its groups.
+type GroupAssignment struct {
+	Entries  []GroupAssignmentEntry
+	Groups   []GroupAssignmentCount
+	TotalBid int
+	Changed  int
+}
+
+// SolveGroupAssignment distributes all students of a course to its groups such
+// that the sum of their bids is maximal and every group has between
+// minPerGroup and maxPerGroup members. Nothing is written to the database.
+func SolveGroupAssignment(stores *Stores, courseID int64, minPerGroup int, maxPerGroup int) (*GroupAssignment, error) {
+	groups, err := stores.Group.GroupsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := stores.Course.EnrolledUsers(courseID,
+		[]string{"0"}, "%%", "%%", "%%", "%%", "%%")
+	if err != nil {
+		return nil, err
+	}
+
+	bids, err := stores.Group.GetBidsForCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	problem := &assignment.Problem{
+		UserIDs:     []int64{},
+		GroupIDs:    []int64{},
+		Bids:        make(map[int64]map[int64]int),
+		DefaultBid:  DefaultGroupBid,
+		MinPerGroup: minPerGroup,
+		MaxPerGroup: maxPerGroup,
+		Capacities:  make(map[int64]int),
+	}
+
+	for _, group := range groups {
+		problem.GroupIDs = append(problem.GroupIDs, group.ID)
+		// a capacity of 0 means unlimited
+		if group.Capacity > 0 {
+			problem.Capacities[group.ID] = group.Capacity
+		}
+	}
+
+	id2student := make(map[int64]model.UserCourse)
+	for _, student := range students {
+		problem.UserIDs = append(problem.UserIDs, student.ID)
+		id2student[student.ID] = student
+	}
+
+	for _, bid := range bids {
+		if _, ok := problem.Bids[bid.UserID]; !ok {
+			problem.Bids[bid.UserID] = make(map[int64]int)
+		}
+		problem.Bids[bid.UserID][bid.GroupID] = bid.Bid
+	}
+
+	solution, err := assignment.Solve(problem)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GroupAssignment{
+		Entries: []GroupAssignmentEntry{},
+		Groups:  []GroupAssignmentCount{},
+	}
+
+	group2count := make(map[int64]int)
+	for _, item := range solution {
+		student := id2student[item.UserID]
+
+		entry := GroupAssignmentEntry{
+			UserID:    item.UserID,
+			FirstName: student.FirstName,
+			LastName:  student.LastName,
+			GroupID:   item.GroupID,
+			Bid:       item.Bid,
+		}
+
+		enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(item.UserID, courseID)
+		switch err {
+		case nil:
+			entry.PreviousGroupID = enrollment.GroupID
+		case sql.ErrNoRows:
+		default:
+			return nil, err
+		}
+		if entry.PreviousGroupID != entry.GroupID {
+			result.Changed++
+		}
+
+		result.Entries = append(result.Entries, entry)
+		result.TotalBid += item.Bid
+		group2count[item.GroupID]++
+	}
+
+	for _, group := range groups {
+		result.Groups = append(result.Groups, GroupAssignmentCount{
+			GroupID:     group.ID,
+			Description: group.Description,
+			Students:    group2count[group.ID],
+		})
+	}
+	sort.Slice(result.Groups, func(i, j int) bool { return result.Groups[i].GroupID < result.Groups[j].GroupID })
+
+	return result, nil
+}
+
+// Apply replaces the group enrollments of all students in the course by the
+// computed assignment in a single transaction.
+func (ga *GroupAssignment) Apply(stores *Stores, courseID int64) error {
+	enrollments := []model.GroupEnrollment{}
+	for _, entry := range ga.Entries {
+		enrollments = append(enrollments, model.GroupEnrollment{
+			UserID:  entry.UserID,
+			GroupID: entry.GroupID,
+		})
+	}
+	return stores.Group.ReplaceGroupEnrollmentsOfCourse(courseID, enrollments)
+}
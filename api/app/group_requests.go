@@ -21,9 +21,14 @@ package app
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // GroupRequest is the request payload for course management.
@@ -34,6 +39,11 @@ type GroupRequest struct {
 	} `json:"tutor"`
 	// CourseID    int64  `json:"course_id"`
 	Description string `json:"description" example:"Gruppe fuer ersties am Montag im Raum C25435"`
+	// Capacity is the maximal number of students, 0 means unlimited.
+	Capacity int `json:"capacity" example:"20" minval:"0" required:"false"`
+	// Slots replace all weekly meetings of the group. Existing meetings are
+	// kept when this field is missing.
+	Slots []*GroupSlotRequest `json:"slots"`
 }
 
 // Bind preprocesses a GroupRequest.
@@ -58,17 +68,105 @@ func (body *GroupRequest) Validate() error {
 			&body.Description,
 			validation.Required,
 		),
+		validation.Field(
+			&body.Capacity,
+			validation.Min(0),
+		),
 	)
 	if err != nil {
 		return err
 	}
 
-	return validation.ValidateStruct(body.Tutor,
+	err = validation.ValidateStruct(body.Tutor,
 		validation.Field(
 			&body.Tutor.ID,
 			validation.Required,
 		),
 	)
+	if err != nil {
+		return err
+	}
+
+	for k, slot := range body.Slots {
+		if slot == nil {
+			return errors.New("missing \"slot\" data")
+		}
+		if err := slot.Validate(); err != nil {
+			return fmt.Errorf("slot %d: %s", k, err)
+		}
+	}
+
+	slots := body.SlotModels()
+	for i := range slots {
+		for j := i + 1; j < len(slots); j++ {
+			if slotsOverlap(slots[i], slots[j]) {
+				return fmt.Errorf("slot %d overlaps with slot %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// SlotModels converts all slots of the request into unsaved models.
+func (body *GroupRequest) SlotModels() []model.GroupSlot {
+	slots := []model.GroupSlot{}
+	for _, slot := range body.Slots {
+		slots = append(slots, model.GroupSlot{
+			Weekday:   slot.Weekday,
+			StartTime: normalizeClock(slot.StartTime),
+			EndTime:   normalizeClock(slot.EndTime),
+			Location:  strings.TrimSpace(slot.Location),
+			OnlineURL: strings.TrimSpace(slot.OnlineURL),
+		})
+	}
+	return slots
+}
+
+// GroupSlotRequest is a weekly meeting of a group. Weekdays start with 0 for
+// sunday, times are given as "15:04". Either a location or an online link is
+// required.
+type GroupSlotRequest struct {
+	Weekday   int    `json:"weekday" example:"1" minval:"0" maxval:"6"`
+	StartTime string `json:"start_time" example:"14:15"`
+	EndTime   string `json:"end_time" example:"15:45"`
+	Location  string `json:"location" example:"Sand 1, C215" required:"false"`
+	OnlineURL string `json:"online_url" example:"https://meet.uni-tuebingen.de/group-3" required:"false"`
+}
+
+func (body *GroupSlotRequest) Validate() error {
+	err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.Weekday,
+			validation.Min(0),
+			validation.Max(6),
+		),
+		validation.Field(
+			&body.StartTime,
+			validation.Required,
+			validation.Date(clockLayout),
+		),
+		validation.Field(
+			&body.EndTime,
+			validation.Required,
+			validation.Date(clockLayout),
+		),
+		validation.Field(
+			&body.OnlineURL,
+			is.URL,
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if normalizeClock(body.StartTime) >= normalizeClock(body.EndTime) {
+		return errors.New("start_time must be before end_time")
+	}
+
+	if strings.TrimSpace(body.Location) == "" && strings.TrimSpace(body.OnlineURL) == "" {
+		return errors.New("either location or online_url is required")
+	}
+	return nil
 }
 
 type GroupBidRequest struct {
@@ -112,3 +210,106 @@ func (body *GroupEnrollmentRequest) Validate() error {
 		),
 	)
 }
+
+// GroupAssignmentRequest are the bounds for distributing all students of a
+// course to its groups.
+type GroupAssignmentRequest struct {
+	MinPerGroup int  `json:"min_per_group" example:"10"`
+	MaxPerGroup int  `json:"max_per_group" example:"20"`
+	DryRun      bool `json:"dry_run" example:"true"`
+}
+
+// Bind preprocesses a GroupAssignmentRequest.
+func (body *GroupAssignmentRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"assignment\" data")
+	}
+	return body.Validate()
+}
+
+func (body *GroupAssignmentRequest) Validate() error {
+	err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.MinPerGroup,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.MaxPerGroup,
+			validation.Required,
+			validation.Min(1),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if body.MinPerGroup > body.MaxPerGroup {
+		return errors.New("min_per_group must not exceed max_per_group")
+	}
+	return nil
+}
+
+// GroupSwapRequest asks to swap the group with another student of the course.
+type GroupSwapRequest struct {
+	PartnerEmail string `json:"partner_email" example:"test@uni-tuebingen.de"`
+}
+
+// Bind preprocesses a GroupSwapRequest.
+func (body *GroupSwapRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"swap\" data")
+	}
+	return body.Validate()
+}
+
+func (body *GroupSwapRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.PartnerEmail,
+			validation.Required,
+			is.Email,
+		),
+	)
+}
+
+// GroupTutorRequest adds a tutor to a group or changes the role of a tutor.
+// Substitutes need validity dates.
+type GroupTutorRequest struct {
+	UserID     int64     `json:"user_id" example:"3"`
+	Role       int       `json:"role" example:"1" minval:"0" maxval:"2"`
+	ValidFrom  null.Time `json:"valid_from" example:"auto" required:"false"`
+	ValidUntil null.Time `json:"valid_until" example:"auto" required:"false"`
+}
+
+// Bind preprocesses a GroupTutorRequest.
+func (body *GroupTutorRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"tutor\" data")
+	}
+	return body.Validate()
+}
+
+func (body *GroupTutorRequest) Validate() error {
+	if body.Role == GroupTutorSubstitute && (!body.ValidFrom.Valid || !body.ValidUntil.Valid) {
+		return errors.New("substitutes need valid_from and valid_until")
+	}
+	if body.Role == GroupTutorLead && (body.ValidFrom.Valid || body.ValidUntil.Valid) {
+		return errors.New("the lead tutor cannot have validity dates")
+	}
+	if body.ValidFrom.Valid && body.ValidUntil.Valid &&
+		body.ValidUntil.Time.Before(body.ValidFrom.Time) {
+		return errors.New("valid_until must not be before valid_from")
+	}
+
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.UserID,
+			validation.Required,
+		),
+		validation.Field(
+			&body.Role,
+			validation.Min(GroupTutorLead),
+			validation.Max(GroupTutorSubstitute),
+		),
+	)
+}
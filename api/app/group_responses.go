@@ -21,6 +21,7 @@ package app
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/render"
 	"github.com/infomark-org/infomark/model"
@@ -32,6 +33,8 @@ type GroupResponse struct {
 	ID          int64  `json:"id" example:"9841"`
 	CourseID    int64  `json:"course_id" example:"1"`
 	Description string `json:"description" example:"Group every tuesday in room e43"`
+	Capacity    int    `json:"capacity" example:"20"`
+	Members     int    `json:"members" example:"17"`
 	// TutorID     int64  `json:"tutor_id" example:"12"`
 
 	// userResponse
@@ -48,10 +51,22 @@ type GroupResponse struct {
 		Subject       string `json:"subject" example:"bio informatics"`
 		Root          bool   `json:"root" example:"false"`
 	} `json:"tutor"`
+
+	Slots []GroupSlotResponse `json:"slots"`
+}
+
+// GroupSlotResponse is a weekly meeting of a group.
+type GroupSlotResponse struct {
+	ID        int64  `json:"id" example:"4"`
+	Weekday   int    `json:"weekday" example:"1" minval:"0" maxval:"6"`
+	StartTime string `json:"start_time" example:"14:15"`
+	EndTime   string `json:"end_time" example:"15:45"`
+	Location  string `json:"location" example:"Sand 1, C215"`
+	OnlineURL string `json:"online_url" example:"https://meet.uni-tuebingen.de/group-3"`
 }
 
 // newGroupResponse creates a response from a Group model.
-func (rs *GroupResource) newGroupResponse(p *model.Group, t *model.User) *GroupResponse {
+func (rs *GroupResource) newGroupResponse(p *model.Group, t *model.User, slots []model.GroupSlot) *GroupResponse {
 
 	tutor := &struct {
 		ID        int64       `json:"id" example:"1"`
@@ -76,17 +91,35 @@ func (rs *GroupResource) newGroupResponse(p *model.Group, t *model.User) *GroupR
 		Language:  t.Language,
 	}
 
+	slotsResponse := []GroupSlotResponse{}
+	for _, slot := range slots {
+		if slot.GroupID != p.ID {
+			continue
+		}
+		slotsResponse = append(slotsResponse, GroupSlotResponse{
+			ID:        slot.ID,
+			Weekday:   slot.Weekday,
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Location:  slot.Location,
+			OnlineURL: slot.OnlineURL,
+		})
+	}
+
 	return &GroupResponse{
 		ID: p.ID,
 		// TutorID:     p.TutorID,
 		Tutor:       tutor,
 		CourseID:    p.CourseID,
 		Description: p.Description,
+		Capacity:    p.Capacity,
+		Slots:       slotsResponse,
 	}
 }
 
-// newGroupListResponse creates a response from a list of Group models.
-func (rs *GroupResource) newGroupListResponse(Groups []model.GroupWithTutor) []render.Renderer {
+// newGroupListResponse creates a response from a list of Group models. The
+// slots may contain meetings of other groups.
+func (rs *GroupResource) newGroupListResponse(Groups []model.GroupWithTutor, slots []model.GroupSlot) []render.Renderer {
 	list := []render.Renderer{}
 	for k := range Groups {
 		// TODO(patwie): refactor this
@@ -103,8 +136,11 @@ func (rs *GroupResource) newGroupListResponse(Groups []model.GroupWithTutor) []r
 			ID:          Groups[k].ID,
 			CourseID:    Groups[k].CourseID,
 			Description: Groups[k].Description,
+			Capacity:    Groups[k].Capacity,
 		}
-		list = append(list, rs.newGroupResponse(group, tutor))
+		response := rs.newGroupResponse(group, tutor, slots)
+		response.Members = Groups[k].Members
+		list = append(list, response)
 	}
 	return list
 }
@@ -123,3 +159,118 @@ type GroupBidResponse struct {
 func (body *GroupBidResponse) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
+
+// GroupAssignmentResponse is the solution of distributing all students of a
+// course to its groups.
+type GroupAssignmentResponse struct {
+	DryRun      bool                   `json:"dry_run" example:"true"`
+	Applied     bool                   `json:"applied" example:"false"`
+	TotalBid    int                    `json:"total_bid" example:"1830"`
+	Changed     int                    `json:"changed" example:"12"`
+	Groups      []GroupAssignmentCount `json:"groups"`
+	Assignments []GroupAssignmentEntry `json:"assignments"`
+}
+
+// Render post-processes a GroupAssignmentResponse.
+func (body *GroupAssignmentResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func newGroupAssignmentResponse(p *GroupAssignment, dryRun bool, applied bool) *GroupAssignmentResponse {
+	return &GroupAssignmentResponse{
+		DryRun:      dryRun,
+		Applied:     applied,
+		TotalBid:    p.TotalBid,
+		Changed:     p.Changed,
+		Groups:      p.Groups,
+		Assignments: p.Entries,
+	}
+}
+
+// GroupJoinResponse is the outcome of joining a group.
+type GroupJoinResponse struct {
+	Enrolled   bool `json:"enrolled" example:"false"`
+	Waitlisted bool `json:"waitlisted" example:"true"`
+	Position   int  `json:"position" example:"3"`
+}
+
+// Render post-processes a GroupJoinResponse.
+func (body *GroupJoinResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GroupWaitlistResponse is the position of a student on the waitlist of a
+// group.
+type GroupWaitlistResponse struct {
+	GroupID  int64 `json:"group_id" example:"3"`
+	Position int   `json:"position" example:"3"`
+}
+
+// Render post-processes a GroupWaitlistResponse.
+func (body *GroupWaitlistResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GroupSwapResponse is a swap request from (outgoing) or to the identity. The
+// user is the other student.
+type GroupSwapResponse struct {
+	ID        int64     `json:"id" example:"4"`
+	CreatedAt time.Time `json:"created_at" example:"auto"`
+	Outgoing  bool      `json:"outgoing" example:"true"`
+	UserID    int64     `json:"user_id" example:"113"`
+	FirstName string    `json:"first_name" example:"Max"`
+	LastName  string    `json:"last_name" example:"Mustermensch"`
+	Email     string    `json:"email" example:"test@uni-tuebingen.de"`
+	GroupID   int64     `json:"group_id" example:"3"`
+}
+
+// Render post-processes a GroupSwapResponse.
+func (body *GroupSwapResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GroupSwapResultResponse tells whether a swap request has been applied.
+type GroupSwapResultResponse struct {
+	Swapped bool `json:"swapped" example:"false"`
+}
+
+// Render post-processes a GroupSwapResultResponse.
+func (body *GroupSwapResultResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GroupTutorResponse is a tutor of a group. Active tells whether a substitute
+// is currently within the validity dates.
+type GroupTutorResponse struct {
+	UserID     int64     `json:"user_id" example:"3"`
+	FirstName  string    `json:"first_name" example:"Max"`
+	LastName   string    `json:"last_name" example:"Mustermensch"`
+	Email      string    `json:"email" example:"test@uni-tuebingen.de"`
+	Role       int       `json:"role" example:"1" minval:"0" maxval:"2"`
+	ValidFrom  null.Time `json:"valid_from" example:"auto"`
+	ValidUntil null.Time `json:"valid_until" example:"auto"`
+	Active     bool      `json:"active" example:"true"`
+}
+
+// Render post-processes a GroupTutorResponse.
+func (body *GroupTutorResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGroupTutorListResponse creates a response from a list of GroupTutor models.
+func newGroupTutorListResponse(tutors []model.GroupTutor) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range tutors {
+		list = append(list, &GroupTutorResponse{
+			UserID:     tutors[k].UserID,
+			FirstName:  tutors[k].FirstName,
+			LastName:   tutors[k].LastName,
+			Email:      tutors[k].Email,
+			Role:       tutors[k].Role,
+			ValidFrom:  tutors[k].ValidFrom,
+			ValidUntil: tutors[k].ValidUntil,
+			Active:     tutors[k].Active,
+		})
+	}
+	return list
+}
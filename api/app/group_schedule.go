@@ -0,0 +1,137 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/model"
+)
+
+// clockLayout is the format of start and end times of group meetings.
+const clockLayout = "15:04"
+
+// normalizeClock pads times like "9:00" to "09:00" such that they can be
+// compared as strings.
+func normalizeClock(clock string) string {
+	t, err := time.Parse(clockLayout, strings.TrimSpace(clock))
+	if err != nil {
+		return clock
+	}
+	return t.Format(clockLayout)
+}
+
+// slotsOverlap reports whether two weekly meetings take place at the same time.
+func slotsOverlap(a model.GroupSlot, b model.GroupSlot) bool {
+	return a.Weekday == b.Weekday && a.StartTime < b.EndTime && b.StartTime < a.EndTime
+}
+
+func describeSlot(slot model.GroupSlot) string {
+	return fmt.Sprintf("%s %s-%s", time.Weekday(slot.Weekday), slot.StartTime, slot.EndTime)
+}
+
+// FindGroupSlotConflicts lists all meetings of other groups in the course which
+// take place at the same time as one of the given meetings and either share the
+// tutor or the location.
+func FindGroupSlotConflicts(stores *Stores, courseID int64, groupID int64, tutorID int64, slots []model.GroupSlot) ([]string, error) {
+	conflicts := []string{}
+	if len(slots) == 0 {
+		return conflicts, nil
+	}
+
+	groups, err := stores.Group.GroupsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	id2group := make(map[int64]model.GroupWithTutor)
+	for _, group := range groups {
+		id2group[group.ID] = group
+	}
+
+	others, err := stores.Group.GetSlotsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slot := range slots {
+		for _, other := range others {
+			if other.GroupID == groupID || !slotsOverlap(slot, other) {
+				continue
+			}
+			group := id2group[other.GroupID]
+			if group.TutorID == tutorID {
+				conflicts = append(conflicts, fmt.Sprintf("%s collides with group '%s' of the same tutor",
+					describeSlot(slot), group.Description))
+			}
+			if slot.Location != "" && strings.EqualFold(slot.Location, other.Location) {
+				conflicts = append(conflicts, fmt.Sprintf("%s collides with group '%s' in %s",
+					describeSlot(slot), group.Description, other.Location))
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// groupCalendarEvents creates a weekly recurring event for every meeting of a
+// group during the lecture period of the course.
+func groupCalendarEvents(course *model.Course, group *model.GroupWithTutor, slots []model.GroupSlot) []helper.CalendarEvent {
+	events := []helper.CalendarEvent{}
+
+	begin := time.Date(course.BeginsAt.Year(), course.BeginsAt.Month(), course.BeginsAt.Day(), 0, 0, 0, 0, time.UTC)
+	until := time.Date(course.EndsAt.Year(), course.EndsAt.Month(), course.EndsAt.Day(), 23, 59, 59, 0, time.UTC)
+
+	for _, slot := range slots {
+		startClock, err := time.Parse(clockLayout, slot.StartTime)
+		if err != nil {
+			continue
+		}
+		endClock, err := time.Parse(clockLayout, slot.EndTime)
+		if err != nil {
+			continue
+		}
+
+		day := begin.AddDate(0, 0, (slot.Weekday-int(begin.Weekday())+7)%7)
+		if day.After(until) {
+			continue
+		}
+
+		description := fmt.Sprintf("Tutor: %s %s", group.TutorFirstName, group.TutorLastName)
+		if slot.OnlineURL != "" {
+			description = fmt.Sprintf("%s\nOnline: %s", description, slot.OnlineURL)
+		}
+
+		events = append(events, helper.CalendarEvent{
+			UID:         fmt.Sprintf("group-slot-%d@infomark", slot.ID),
+			Start:       day.Add(time.Duration(startClock.Hour())*time.Hour + time.Duration(startClock.Minute())*time.Minute),
+			End:         day.Add(time.Duration(endClock.Hour())*time.Hour + time.Duration(endClock.Minute())*time.Minute),
+			WeeklyUntil: until,
+			Summary:     fmt.Sprintf("%s: %s", course.Name, group.Description),
+			Location:    slot.Location,
+			URL:         slot.OnlineURL,
+			Description: description,
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,54 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+)
+
+// Modes how students get into groups of a course.
+const (
+	// GroupEnrollmentByAdmin lets only admins enroll students into groups
+	// (directly or by solving the bids).
+	GroupEnrollmentByAdmin = 0
+	// GroupEnrollmentFirstCome lets students join groups until they are full.
+	GroupEnrollmentFirstCome = 1
+	// GroupEnrollmentWaitlist lets students join groups and puts them on the
+	// waitlist of full groups.
+	GroupEnrollmentWaitlist = 2
+)
+
+// checkGroupSelfEnrollment reports whether students can currently join groups
+// or swap them on their own.
+func checkGroupSelfEnrollment(course *model.Course, now time.Time) error {
+	if course.GroupEnrollmentMode == GroupEnrollmentByAdmin {
+		return errors.New("groups are assigned by the course admins")
+	}
+	if course.GroupEnrollmentBeginsAt.Valid && now.Before(course.GroupEnrollmentBeginsAt.Time) {
+		return errors.New("group enrollment has not started yet")
+	}
+	if course.GroupEnrollmentEndsAt.Valid && now.After(course.GroupEnrollmentEndsAt.Time) {
+		return errors.New("group enrollment is over")
+	}
+	return nil
+}
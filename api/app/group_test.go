@@ -23,11 +23,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
 )
 
 func TestGroup(t *testing.T) {
@@ -169,6 +172,213 @@ func TestGroup(t *testing.T) {
 			g.Assert(entryAfter.CourseID).Equal(int64(1))
 		})
 
+		g.It("Should create and update weekly meetings of a group", func() {
+			slot := helper.H{
+				"weekday":    1,
+				"start_time": "9:15",
+				"end_time":   "10:45",
+				"location":   "Sand 1, C215",
+			}
+			entrySent := helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "monday group",
+				"slots":       []helper.H{slot},
+			}
+
+			w := tape.Post("/api/v1/courses/1/groups", entrySent, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			entryReturn := &GroupResponse{}
+			err := json.NewDecoder(w.Body).Decode(&entryReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entryReturn.Slots)).Equal(1)
+			g.Assert(entryReturn.Slots[0].Weekday).Equal(1)
+			g.Assert(entryReturn.Slots[0].StartTime).Equal("09:15")
+			g.Assert(entryReturn.Slots[0].EndTime).Equal("10:45")
+			g.Assert(entryReturn.Slots[0].Location).Equal("Sand 1, C215")
+
+			// same tutor at the same time
+			w = tape.Post("/api/v1/courses/1/groups", helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "other group",
+				"slots": []helper.H{{
+					"weekday":    1,
+					"start_time": "10:00",
+					"end_time":   "12:00",
+					"online_url": "https://meet.uni-tuebingen.de/other",
+				}},
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// same room at the same time
+			w = tape.Post("/api/v1/courses/1/groups", helper.H{
+				"tutor":       helper.H{"id": 2},
+				"description": "other group",
+				"slots": []helper.H{{
+					"weekday":    1,
+					"start_time": "10:00",
+					"end_time":   "12:00",
+					"location":   "sand 1, c215",
+				}},
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// directly after is fine
+			w = tape.Post("/api/v1/courses/1/groups", helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "other group",
+				"slots": []helper.H{{
+					"weekday":    1,
+					"start_time": "10:45",
+					"end_time":   "12:00",
+					"location":   "Sand 1, C215",
+				}},
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			url := fmt.Sprintf("/api/v1/courses/1/groups/%d", entryReturn.ID)
+
+			// keep slots when they are not given
+			w = tape.Put(url, helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "renamed",
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			slots, err := stores.Group.GetSlots(entryReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(slots)).Equal(1)
+
+			// replace slots
+			w = tape.Put(url, helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "renamed",
+				"slots": []helper.H{
+					{"weekday": 2, "start_time": "08:00", "end_time": "10:00", "location": "A104"},
+					{"weekday": 4, "start_time": "08:00", "end_time": "10:00", "location": "A104"},
+				},
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			slots, err = stores.Group.GetSlots(entryReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(slots)).Equal(2)
+			g.Assert(slots[0].Weekday).Equal(2)
+			g.Assert(slots[1].Weekday).Equal(4)
+
+			w = tape.Get(url, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&entryReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entryReturn.Slots)).Equal(2)
+
+			// remove all slots
+			w = tape.Put(url, helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "renamed",
+				"slots":       []helper.H{},
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			slots, err = stores.Group.GetSlots(entryReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(slots)).Equal(0)
+		})
+
+		g.It("Should reject invalid weekly meetings", func() {
+			invalid := []helper.H{
+				{"weekday": 7, "start_time": "08:00", "end_time": "10:00", "location": "A104"},
+				{"weekday": 1, "start_time": "8 am", "end_time": "10:00", "location": "A104"},
+				{"weekday": 1, "start_time": "10:00", "end_time": "08:00", "location": "A104"},
+				{"weekday": 1, "start_time": "08:00", "end_time": "10:00"},
+				{"weekday": 1, "start_time": "08:00", "end_time": "10:00", "online_url": "no link"},
+			}
+
+			for _, slot := range invalid {
+				w := tape.Post("/api/v1/courses/1/groups", helper.H{
+					"tutor":       helper.H{"id": 1},
+					"description": "invalid",
+					"slots":       []helper.H{slot},
+				}, adminJWT)
+				g.Assert(w.Code).Equal(http.StatusBadRequest)
+			}
+
+			// overlapping slots within the same group
+			w := tape.Post("/api/v1/courses/1/groups", helper.H{
+				"tutor":       helper.H{"id": 1},
+				"description": "invalid",
+				"slots": []helper.H{
+					{"weekday": 1, "start_time": "08:00", "end_time": "10:00", "location": "A104"},
+					{"weekday": 1, "start_time": "09:00", "end_time": "11:00", "location": "A105"},
+				},
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should not create a group when its slots cannot be stored", func() {
+			entriesBefore, err := stores.Group.GetAll()
+			g.Assert(err).Equal(nil)
+
+			_, err = stores.Group.CreateWithSlots(&model.Group{
+				TutorID:  2,
+				CourseID: 1,
+			}, []model.GroupSlot{{Weekday: 9, StartTime: "08:00", EndTime: "10:00"}})
+			g.Assert(err == nil).IsFalse()
+
+			entriesAfter, err := stores.Group.GetAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entriesAfter)).Equal(len(entriesBefore))
+		})
+
+		g.It("Should export own groups as calendar", func() {
+			w := tape.Get("/api/v1/me/calendar.ics")
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			tutorGroups, err := stores.Group.GetOfTutor(tutorJWT.Claims.LoginID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(tutorGroups) > 0).IsTrue()
+
+			err = stores.Group.ReplaceSlots(tutorGroups[0].ID, []model.GroupSlot{{
+				Weekday:   3,
+				StartTime: "14:15",
+				EndTime:   "15:45",
+				Location:  "Sand 6, F122",
+			}})
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/me/calendar.ics", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar")).IsTrue()
+
+			content := w.Body.String()
+			g.Assert(strings.Contains(content, "BEGIN:VEVENT")).IsTrue()
+			g.Assert(strings.Contains(content, "LOCATION:Sand 6\\, F122")).IsTrue()
+			g.Assert(strings.Contains(content, "RRULE:FREQ=WEEKLY")).IsTrue()
+		})
+
+		g.It("Should export own groups by a secret calendar url", func() {
+			w := tape.Post("/api/v1/account/calendar_token", helper.H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			resp := &CalendarTokenResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(resp)).Equal(nil)
+
+			path := resp.URL[strings.Index(resp.URL, "/api/v1/calendar/"):]
+
+			// calendar clients cannot send any credentials
+			w = tape.Get(path)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar")).IsTrue()
+
+			w = tape.Get("/api/v1/calendar/unknown.ics")
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Delete("/api/v1/account/calendar_token", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get(path)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+		})
+
 		g.It("Should delete when valid access claims", func() {
 			entriesBefore, err := stores.Group.GetAll()
 			g.Assert(err).Equal(nil)
@@ -397,6 +607,367 @@ func TestGroup(t *testing.T) {
 			g.Assert(len(enrollmentsActual)).Equal(numberEnrollmentsExpected)
 		})
 
+		g.It("Should assign all students to groups according to their bids", func() {
+			url := "/api/v1/courses/1/groups/assignments"
+
+			numberStudents, err := DBGetInt(tape,
+				"SELECT count(*) FROM user_course WHERE course_id = $1 AND role = 0", 1)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post(url, H{"max_per_group": numberStudents})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Post(url, H{"max_per_group": numberStudents}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post(url, H{"max_per_group": numberStudents}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// 10 groups cannot hold all students
+			w = tape.Post(url, H{"max_per_group": 1}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post(url, H{"min_per_group": 3, "max_per_group": 2}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// the student only wants group 3
+			_, err = tape.DB.Exec("DELETE FROM group_bids WHERE user_id = 112")
+			g.Assert(err).Equal(nil)
+			for groupID := 1; groupID <= 10; groupID++ {
+				bid := 0
+				if groupID == 3 {
+					bid = 10
+				}
+				_, err = stores.Group.InsertBidOfUserForGroup(112, int64(groupID), bid)
+				g.Assert(err).Equal(nil)
+			}
+
+			before, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post(url, H{"max_per_group": numberStudents, "dry_run": true}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := GroupAssignmentResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.Applied).IsFalse()
+			g.Assert(len(result.Assignments)).Equal(numberStudents)
+			g.Assert(len(result.Groups)).Equal(10)
+
+			total := 0
+			for _, group := range result.Groups {
+				total += group.Students
+			}
+			g.Assert(total).Equal(numberStudents)
+
+			for _, entry := range result.Assignments {
+				if entry.UserID == 112 {
+					g.Assert(entry.GroupID).Equal(int64(3))
+					g.Assert(entry.Bid).Equal(10)
+					g.Assert(entry.PreviousGroupID).Equal(before.GroupID)
+				}
+			}
+
+			after, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(after.GroupID).Equal(before.GroupID)
+
+			w = tape.Post(url, H{"max_per_group": numberStudents}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			after, err = stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(after.GroupID).Equal(int64(3))
+
+			numberEnrollments, err := DBGetInt(tape, `
+SELECT count(*) FROM user_group ug
+INNER JOIN groups g ON g.id = ug.group_id
+INNER JOIN user_course uc ON uc.user_id = ug.user_id AND uc.course_id = g.course_id
+WHERE g.course_id = $1 AND uc.role = 0`, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(numberEnrollments).Equal(numberStudents)
+		})
+
+		g.It("Should let students join groups first come first served", func() {
+			own, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+
+			groups, err := stores.Group.GroupsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			target := groups[0]
+			if target.ID == own.GroupID {
+				target = groups[1]
+			}
+
+			url := fmt.Sprintf("/api/v1/courses/1/groups/%d/join", target.ID)
+
+			// only admins assign groups by default
+			w := tape.Post(url, H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			_, err = tape.DB.Exec("UPDATE courses SET group_enrollment_mode = 1 WHERE id = 1")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post(url, H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// group is full
+			_, err = tape.DB.Exec("UPDATE groups SET capacity = $2 WHERE id = $1", target.ID, target.Members)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post(url, H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// admins cannot exceed the capacity either
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/groups/%d/enrollments", target.ID),
+				H{"user_id": 112}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// phase is over
+			_, err = tape.DB.Exec("UPDATE groups SET capacity = $2 WHERE id = $1", target.ID, target.Members+1)
+			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec("UPDATE courses SET group_enrollment_ends_at = now() - interval '1 day' WHERE id = 1")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post(url, H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			_, err = tape.DB.Exec("UPDATE courses SET group_enrollment_ends_at = NULL WHERE id = 1")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post(url, H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := GroupJoinResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.Enrolled).IsTrue()
+
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(target.ID)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/groups/%d", target.ID), studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			groupReturn := GroupResponse{}
+			err = json.NewDecoder(w.Body).Decode(&groupReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(groupReturn.Capacity).Equal(target.Members + 1)
+			g.Assert(groupReturn.Members).Equal(target.Members + 1)
+
+			// leave the group again
+			w = tape.Delete("/api/v1/courses/1/groups/own", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			_, err = stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should move students from the waitlist into free seats", func() {
+			own, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+
+			groups, err := stores.Group.GroupsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			target := groups[0]
+			if target.ID == own.GroupID {
+				target = groups[1]
+			}
+
+			members, err := stores.Group.GetMembers(target.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 0).IsTrue()
+
+			_, err = tape.DB.Exec("UPDATE courses SET group_enrollment_mode = 2 WHERE id = 1")
+			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec("UPDATE groups SET capacity = $2 WHERE id = $1", target.ID, len(members))
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1/groups/waitlist", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/groups/%d/join", target.ID), H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := GroupJoinResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.Enrolled).IsFalse()
+			g.Assert(result.Waitlisted).IsTrue()
+			g.Assert(result.Position).Equal(1)
+
+			w = tape.Get("/api/v1/courses/1/groups/waitlist", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			waitlist := GroupWaitlistResponse{}
+			err = json.NewDecoder(w.Body).Decode(&waitlist)
+			g.Assert(err).Equal(nil)
+			g.Assert(waitlist.GroupID).Equal(target.ID)
+			g.Assert(waitlist.Position).Equal(1)
+
+			// still in the old group
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(own.GroupID)
+
+			// a member leaves and frees a seat
+			memberJWT := tape.NewJWTRequest(members[0].ID, false)
+			w = tape.Delete("/api/v1/courses/1/groups/own", memberJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			enrollment, err = stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(target.ID)
+
+			w = tape.Get("/api/v1/courses/1/groups/waitlist", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+		})
+
+		g.It("Should swap groups when both students agree", func() {
+			own, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+
+			groups, err := stores.Group.GroupsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			other := groups[0]
+			if other.ID == own.GroupID {
+				other = groups[1]
+			}
+
+			members, err := stores.Group.GetMembers(other.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 0).IsTrue()
+			partner := members[0]
+			partnerJWT := tape.NewJWTRequest(partner.ID, false)
+
+			student, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/groups/swaps", H{"partner_email": partner.Email}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			_, err = tape.DB.Exec("UPDATE courses SET group_enrollment_mode = 1 WHERE id = 1")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/courses/1/groups/swaps", H{"partner_email": student.Email}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/groups/swaps", H{"partner_email": partner.Email}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			result := GroupSwapResultResponse{}
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.Swapped).IsFalse()
+
+			w = tape.Get("/api/v1/courses/1/groups/swaps", partnerJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			swaps := []GroupSwapResponse{}
+			err = json.NewDecoder(w.Body).Decode(&swaps)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(swaps)).Equal(1)
+			g.Assert(swaps[0].Outgoing).IsFalse()
+			g.Assert(swaps[0].UserID).Equal(int64(112))
+			g.Assert(swaps[0].GroupID).Equal(own.GroupID)
+
+			w = tape.Post("/api/v1/courses/1/groups/swaps", H{"partner_email": student.Email}, partnerJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&result)
+			g.Assert(err).Equal(nil)
+			g.Assert(result.Swapped).IsTrue()
+
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(other.ID)
+
+			enrollment, err = stores.Group.GetGroupEnrollmentOfUserInCourse(partner.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(own.GroupID)
+
+			swapsAfter, err := stores.Group.GetGroupSwapsOfUser(1, 112)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(swapsAfter)).Equal(0)
+		})
+
+		g.It("Should let co-tutors and substitutes tutor a group", func() {
+			group, err := stores.Group.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(group.TutorID).Equal(int64(1))
+
+			missingBefore, err := stores.Grade.GetAllMissingGrades(1, 2, 0)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/groups/1/tutors", H{"user_id": 2, "role": 2}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/groups/1/tutors", H{"user_id": 2, "role": 2,
+				"valid_from": NowUTC().Add(-48 * time.Hour), "valid_until": NowUTC().Add(-24 * time.Hour)}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/groups/1/tutors", H{"user_id": 2, "role": 2,
+				"valid_from": NowUTC().Add(-48 * time.Hour), "valid_until": NowUTC().Add(-24 * time.Hour)}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			// an expired substitute is no tutor of the group
+			isTutor, err := stores.Group.IsTutorOfGroup(1, 2)
+			g.Assert(err).Equal(nil)
+			g.Assert(isTutor).IsFalse()
+
+			w = tape.Get("/api/v1/courses/1/groups/1/tutors", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			tutors := []GroupTutorResponse{}
+			err = json.NewDecoder(w.Body).Decode(&tutors)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(tutors)).Equal(2)
+			g.Assert(tutors[0].UserID).Equal(int64(1))
+			g.Assert(tutors[0].Role).Equal(GroupTutorLead)
+			g.Assert(tutors[1].UserID).Equal(int64(2))
+			g.Assert(tutors[1].Role).Equal(GroupTutorSubstitute)
+			g.Assert(tutors[1].Active).IsFalse()
+
+			w = tape.Post("/api/v1/courses/1/groups/1/tutors", H{"user_id": 2, "role": 1}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			isTutor, err = stores.Group.IsTutorOfGroup(1, 2)
+			g.Assert(err).Equal(nil)
+			g.Assert(isTutor).IsTrue()
+
+			w = tape.Get("/api/v1/courses/1/groups/own", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			own := []GroupResponse{}
+			err = json.NewDecoder(w.Body).Decode(&own)
+			g.Assert(err).Equal(nil)
+			found := false
+			for _, entry := range own {
+				if entry.ID == 1 {
+					found = true
+				}
+			}
+			g.Assert(found).IsTrue()
+
+			missingAfter, err := stores.Grade.GetAllMissingGrades(1, 2, 0)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(missingAfter) > len(missingBefore)).IsTrue()
+
+			// the lead tutor can only be replaced
+			w = tape.Delete("/api/v1/courses/1/groups/1/tutors/1", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Delete("/api/v1/courses/1/groups/1/tutors/2", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			isTutor, err = stores.Group.IsTutorOfGroup(1, 2)
+			g.Assert(err).Equal(nil)
+			g.Assert(isTutor).IsFalse()
+
+			w = tape.Post("/api/v1/courses/1/groups/1/tutors", H{"user_id": 2, "role": 0}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			group, err = stores.Group.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(group.TutorID).Equal(int64(2))
+		})
+
 		g.AfterEach(func() {
 			tape.AfterEach()
 		})
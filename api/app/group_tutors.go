@@ -0,0 +1,51 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// Roles of a tutor within a group. The lead tutor is stored in the group
+// itself and is responsible for it by default, e.g. for the workload of the
+// grading queue. Co-tutors and substitutes share the groups of the lead
+// tutor, substitutes only between their validity dates.
+const (
+	GroupTutorLead       = 0
+	GroupTutorCo         = 1
+	GroupTutorSubstitute = 2
+)
+
+// mayTutorGroup reports whether the identity acts as tutor of a group, i.e. is
+// an admin of the course or currently a tutor of the group.
+func mayTutorGroup(stores *Stores, r *http.Request, group *model.Group) (bool, error) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if givenRole == authorize.ADMIN {
+		return true, nil
+	}
+	return stores.Group.IsTutorOfGroup(group.ID, accessClaims.LoginID)
+}
@@ -0,0 +1,137 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestImpersonation(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+
+	impersonate := func(userID string) *ImpersonationResponse {
+		w := tape.Post("/api/v1/users/"+userID+"/impersonate", H{}, tape.NewJWTRequest(1, true))
+		g.Assert(w.Code).Equal(http.StatusCreated)
+		resp := &ImpersonationResponse{}
+		g.Assert(json.NewDecoder(w.Body).Decode(resp)).Equal(nil)
+		return resp
+	}
+
+	g.Describe("Impersonation", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+		})
+
+		g.It("Should be possible for root users only", func() {
+			w = tape.Post("/api/v1/users/112/impersonate", H{}, tape.NewJWTRequest(2, false))
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// root users cannot hide behind each other
+			w = tape.Post("/api/v1/users/1/impersonate", H{}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should act as the user", func() {
+			resp := impersonate("112")
+			g.Assert(resp.UserID).Equal(int64(112))
+
+			claims := &authenticate.AccessClaims{}
+			err := claims.ParseAccessClaimsFromToken(configuration.Configuration.Server.Authentication.JWT.Secret, resp.Access.Token)
+			g.Assert(err).Equal(nil)
+			g.Assert(claims.LoginID).Equal(int64(112))
+			g.Assert(claims.Root).IsFalse()
+			g.Assert(claims.ImpersonatorID).Equal(int64(1))
+
+			w = tape.Get("/api/v1/me", BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+			user := &UserResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(user)).Equal(nil)
+			g.Assert(user.ID).Equal(int64(112))
+
+			w = tape.Get("/api/v1/account/enrollments", BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should block changes of the account", func() {
+			resp := impersonate("112")
+
+			w = tape.Patch("/api/v1/account", H{
+				"account":            H{"plain_password": "new_password"},
+				"old_plain_password": "test",
+			}, BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put("/api/v1/me", H{"first_name": "Max"}, BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/account/tokens", H{"name": "script", "scope": "read"}, BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete("/api/v1/account/avatar", BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should not change anything", func() {
+			resp := impersonate("112")
+
+			filename := fmt.Sprintf("%s/empty.zip", configuration.Configuration.Server.Debugging.Fixtures)
+			w, err := tape.Upload("/api/v1/courses/1/tasks/1/submission", filename, "application/zip", BearerRequest{resp.Access.Token})
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/enrollments", H{}, BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should end with the logins of the user", func() {
+			resp := impersonate("112")
+
+			w = tape.Get("/api/v1/me", BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			g.Assert(RevokeLoginSessions(NewStores(tape.DB), 112, 0)).Equal(nil)
+
+			w = tape.Get("/api/v1/me", BearerRequest{resp.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
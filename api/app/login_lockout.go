@@ -0,0 +1,270 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// LoginBlockedError reports that an account cannot try to log in right now
+// because of previous failed logins.
+type LoginBlockedError struct {
+	Until  time.Time
+	Locked bool
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account is locked after too many failed logins until %s", e.Until.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("too many failed logins, try again at %s", e.Until.Format(time.RFC3339))
+}
+
+// loginDelay is the time to wait after the given number of failed logins. It
+// doubles with every failure beyond the free attempts.
+func loginDelay(failedLogins int) time.Duration {
+	config := &configuration.Configuration.Server.Authentication.Lockout
+
+	delay := time.Duration(0)
+	for k := config.FreeAttempts; k < failedLogins; k++ {
+		if delay == 0 {
+			delay = config.Delay
+		} else {
+			delay *= 2
+		}
+		// waiting never takes longer than a lockout
+		if delay >= config.Duration {
+			return config.Duration
+		}
+	}
+	return delay
+}
+
+// LoginBlockedUntil returns the time until which an account cannot try to log
+// in, it is nil if the account can try now.
+func LoginBlockedUntil(lockout *model.LoginLockout, now time.Time) *LoginBlockedError {
+	if lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(now) {
+		return &LoginBlockedError{Until: lockout.LockedUntil.Time, Locked: true}
+	}
+
+	next := lockout.LastFailedAt.Add(loginDelay(lockout.FailedLogins))
+	if next.After(now) {
+		return &LoginBlockedError{Until: next}
+	}
+	return nil
+}
+
+// CheckLoginLockout returns a LoginBlockedError if the account of an email
+// address cannot try to log in right now.
+func CheckLoginLockout(stores *Stores, emailAddress string) error {
+	if !configuration.Configuration.Server.Authentication.Lockout.Enabled {
+		return nil
+	}
+
+	user, err := stores.User.FindByEmail(emailAddress)
+	if err != nil {
+		// there is nothing to protect
+		return nil
+	}
+
+	lockout, err := stores.LoginLockout.Get(user.ID)
+	if err != nil {
+		// no failed logins
+		return nil
+	}
+
+	if blocked := LoginBlockedUntil(lockout, NowUTC()); blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// RecordFailedLogin counts a failed login of the account of an email address.
+// Reaching the threshold locks the account for a while and informs the user
+// by email.
+func RecordFailedLogin(stores *Stores, emailAddress string, r *http.Request) error {
+	config := &configuration.Configuration.Server.Authentication.Lockout
+	if !config.Enabled {
+		return nil
+	}
+
+	user, err := stores.User.FindByEmail(emailAddress)
+	if err != nil {
+		return nil
+	}
+
+	now := NowUTC()
+	lockout, err := stores.LoginLockout.RecordFailure(user.ID, now, r.RemoteAddr, now.Add(-config.ResetAfter))
+	if err != nil {
+		return err
+	}
+
+	if lockout.FailedLogins < config.Threshold {
+		return nil
+	}
+
+	// after a lockout each further failure locks the account again
+	lockedUntil := now.Add(config.Duration)
+	if err := stores.LoginLockout.Lock(user.ID, lockedUntil); err != nil {
+		return err
+	}
+
+	if lockout.FailedLogins == config.Threshold {
+		msg, err := email.NewEmailFromTemplate(
+			configuration.Configuration.Server.Email.From,
+			user.Email,
+			"Suspicious login attempts",
+			email.AccountLockedTemplateEN,
+			map[string]string{
+				"first_name":         user.FirstName,
+				"last_name":          user.LastName,
+				"failed_logins":      strconv.Itoa(lockout.FailedLogins),
+				"address":            r.RemoteAddr,
+				"locked_until":       lockedUntil.Format("2006-01-02 15:04 MST"),
+				"reset_password_url": fmt.Sprintf("%s/#/password_reset", configuration.Configuration.Server.ExternalURL()),
+			})
+		if err != nil {
+			return err
+		}
+		email.OutgoingEmailsChannel <- msg
+	}
+
+	return nil
+}
+
+// ClearFailedLogins forgets the failed logins of an account, e.g. after a
+// successful login.
+func ClearFailedLogins(stores *Stores, userID int64) error {
+	return stores.LoginLockout.Clear(userID)
+}
+
+// LoginLockoutResource specifies handler to inspect the failed logins of accounts.
+type LoginLockoutResource struct {
+	Stores *Stores
+}
+
+// NewLoginLockoutResource create and returns a LoginLockoutResource.
+func NewLoginLockoutResource(stores *Stores) *LoginLockoutResource {
+	return &LoginLockoutResource{
+		Stores: stores,
+	}
+}
+
+// IndexHandler is public endpoint for
+// URL: /users/lockouts
+// METHOD: get
+// TAG: users
+// RESPONSE: 200,LoginLockoutResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all accounts with recent failed logins
+func (rs *LoginLockoutResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if !accessClaims.Root {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	lockouts, err := rs.Stores.LoginLockout.GetAll()
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newLoginLockoutListResponse(lockouts, NowUTC())); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// GetHandler is public endpoint for
+// URL: /users/{user_id}/lockout
+// URLPARAM: user_id,integer
+// METHOD: get
+// TAG: users
+// RESPONSE: 200,LoginLockoutResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// RESPONSE: 404,NotFound
+// SUMMARY:  recent failed logins of a user
+func (rs *LoginLockoutResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if !accessClaims.Root {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	lockout, err := rs.Stores.LoginLockout.Get(user.ID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	if err := render.Render(w, r, newLoginLockoutResponse(lockout, NowUTC())); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /users/{user_id}/lockout
+// URLPARAM: user_id,integer
+// METHOD: delete
+// TAG: users
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  unlock a user and forget the failed logins
+func (rs *LoginLockoutResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if !accessClaims.Root {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if err := ClearFailedLogins(rs.Stores, user.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
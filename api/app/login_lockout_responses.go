@@ -0,0 +1,81 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// LoginLockoutResponse is the response payload for the failed logins of a user.
+type LoginLockoutResponse struct {
+	UserID            int64     `json:"user_id" example:"112"`
+	FirstName         string    `json:"first_name" example:"Max"`
+	LastName          string    `json:"last_name" example:"Mustermensch"`
+	Email             string    `json:"email" example:"test@uni-tuebingen.de"`
+	FailedLogins      int       `json:"failed_logins" example:"4"`
+	LastFailedAt      time.Time `json:"last_failed_at" example:"auto"`
+	LastFailedAddress string    `json:"last_failed_address" example:"1.2.3.4"`
+	LockedUntil       null.Time `json:"locked_until" example:"auto"`
+	// BlockedUntil is the time of the next possible login, either because of
+	// a lockout or a delay. It is null if the user can log in now.
+	BlockedUntil null.Time `json:"blocked_until" example:"auto"`
+}
+
+// Render post-processes a LoginLockoutResponse.
+func (body *LoginLockoutResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newLoginLockoutResponse creates a response from a LoginLockout model.
+func newLoginLockoutResponse(p *model.LoginLockout, now time.Time) *LoginLockoutResponse {
+	return &LoginLockoutResponse{
+		UserID:            p.UserID,
+		FirstName:         p.FirstName,
+		LastName:          p.LastName,
+		Email:             p.Email,
+		FailedLogins:      p.FailedLogins,
+		LastFailedAt:      p.LastFailedAt,
+		LastFailedAddress: p.LastFailedAddress,
+		LockedUntil:       p.LockedUntil,
+		BlockedUntil:      newBlockedUntil(p, now),
+	}
+}
+
+// newLoginLockoutListResponse creates a response from a list of LoginLockout models.
+func newLoginLockoutListResponse(lockouts []model.LoginLockout, now time.Time) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range lockouts {
+		list = append(list, newLoginLockoutResponse(&lockouts[k], now))
+	}
+	return list
+}
+
+// newBlockedUntil is the end of a delay or lockout as shown in responses.
+func newBlockedUntil(lockout *model.LoginLockout, now time.Time) null.Time {
+	if blocked := LoginBlockedUntil(lockout, now); blocked != nil {
+		return null.TimeFrom(blocked.Until)
+	}
+	return null.Time{}
+}
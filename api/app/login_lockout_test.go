@@ -0,0 +1,189 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestLoginLockout(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+	go email.BackgroundSend(email.OutgoingEmailsChannel)
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+	var stores *Stores
+
+	lockoutConfig := &configuration.Configuration.Server.Authentication.Lockout
+	defaultLockoutConfig := *lockoutConfig
+
+	login := func(password string) *httptest.ResponseRecorder {
+		return tape.Post("/api/v1/auth/sessions", H{
+			"email":          "test@uni-tuebingen.de",
+			"plain_password": password,
+		})
+	}
+
+	g.Describe("LoginLockout", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.AfterEach(func() {
+			*lockoutConfig = defaultLockoutConfig
+		})
+
+		g.It("Should double the delay beyond the free attempts", func() {
+			lockoutConfig.FreeAttempts = 3
+			lockoutConfig.Delay = time.Second
+			lockoutConfig.Duration = 5 * time.Second
+
+			g.Assert(loginDelay(0)).Equal(time.Duration(0))
+			g.Assert(loginDelay(3)).Equal(time.Duration(0))
+			g.Assert(loginDelay(4)).Equal(time.Second)
+			g.Assert(loginDelay(5)).Equal(2 * time.Second)
+			g.Assert(loginDelay(6)).Equal(4 * time.Second)
+			g.Assert(loginDelay(7)).Equal(5 * time.Second)
+			g.Assert(loginDelay(100)).Equal(5 * time.Second)
+		})
+
+		g.It("Should delay logins after the free attempts", func() {
+			lockoutConfig.FreeAttempts = 2
+			lockoutConfig.Delay = time.Hour
+
+			w = login("wrong")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			w = login("wrong")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			w = login("test")
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// a successful login forgets the failures
+			w = login("wrong")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			w = login("wrong")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			w = login("wrong")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// even the correct password has to wait
+			w = login("test")
+			g.Assert(w.Code).Equal(http.StatusTooManyRequests)
+			g.Assert(w.Header().Get("Retry-After") != "").IsTrue()
+
+			lockout, err := stores.LoginLockout.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(lockout.FailedLogins).Equal(3)
+			g.Assert(lockout.LockedUntil.Valid).IsFalse()
+		})
+
+		g.It("Should lock the account after the threshold", func() {
+			lockoutConfig.FreeAttempts = 10
+			lockoutConfig.Threshold = 3
+
+			for i := 0; i < 3; i++ {
+				w = login("wrong")
+				g.Assert(w.Code).Equal(http.StatusBadRequest)
+			}
+
+			w = login("test")
+			g.Assert(w.Code).Equal(http.StatusTooManyRequests)
+
+			w = tape.Post("/api/v1/auth/token", H{
+				"email":          "test@uni-tuebingen.de",
+				"plain_password": "test",
+			})
+			g.Assert(w.Code).Equal(http.StatusTooManyRequests)
+
+			lockout, err := stores.LoginLockout.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(lockout.LockedUntil.Valid).IsTrue()
+			g.Assert(lockout.LastFailedAddress != "").IsTrue()
+		})
+
+		g.It("Should not count failures of other accounts", func() {
+			lockoutConfig.FreeAttempts = 0
+			lockoutConfig.Delay = time.Hour
+
+			w = tape.Post("/api/v1/auth/sessions", H{
+				"email":          "not-existing@uni-tuebingen.de",
+				"plain_password": "test",
+			})
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = login("test")
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should be inspected and cleared by root only", func() {
+			lockoutConfig.FreeAttempts = 10
+			lockoutConfig.Threshold = 1
+
+			w = login("wrong")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get("/api/v1/users/lockouts", tape.NewJWTRequest(2, false))
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/users/lockouts", tape.NewJWTRequest(2, true))
+			g.Assert(w.Code).Equal(http.StatusOK)
+			lockouts := []LoginLockoutResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(&lockouts)).Equal(nil)
+			g.Assert(len(lockouts)).Equal(1)
+			g.Assert(lockouts[0].UserID).Equal(int64(1))
+			g.Assert(lockouts[0].Email).Equal("test@uni-tuebingen.de")
+			g.Assert(lockouts[0].BlockedUntil.Valid).IsTrue()
+
+			w = tape.Get("/api/v1/users/1/lockout", tape.NewJWTRequest(2, true))
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Delete("/api/v1/users/1/lockout", tape.NewJWTRequest(2, false))
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete("/api/v1/users/1/lockout", tape.NewJWTRequest(2, true))
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/users/1/lockout", tape.NewJWTRequest(2, true))
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = login("test")
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -0,0 +1,249 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+var (
+	// ErrLoginSessionInvalid is returned for revoked or expired sessions.
+	ErrLoginSessionInvalid = errors.New("session was revoked or expired")
+	// ErrRefreshTokenReused is returned when a refresh token was used twice.
+	ErrRefreshTokenReused = errors.New("refresh token has been used before, the session is revoked")
+)
+
+// loginSessionTouchInterval limits how often the last usage of a session is
+// written to the database.
+const loginSessionTouchInterval = time.Minute
+
+// LoginSessionResource specifies handler to manage the logins of an account.
+type LoginSessionResource struct {
+	Stores *Stores
+}
+
+// NewLoginSessionResource create and returns a LoginSessionResource.
+func NewLoginSessionResource(stores *Stores) *LoginSessionResource {
+	return &LoginSessionResource{
+		Stores: stores,
+	}
+}
+
+// loginSessionLifetime is the time a session of the given kind lasts without
+// being used.
+func loginSessionLifetime(kind string) time.Duration {
+	config := &configuration.Configuration.Server.Authentication
+	if kind == model.LoginSessionRefresh {
+		return config.JWT.RefreshExpiry
+	}
+	if kind == model.LoginSessionImpersonation {
+		return config.JWT.ImpersonationExpiry
+	}
+	if config.Session.Cookies.Lifetime == 0 {
+		// the default of the cookie sessions
+		return 24 * time.Hour
+	}
+	return config.Session.Cookies.Lifetime
+}
+
+// StartLoginSession records a new login of a user.
+func StartLoginSession(stores *Stores, userID int64, kind string, r *http.Request) (*model.LoginSession, error) {
+	now := NowUTC()
+
+	// sessions which expired are of no interest anymore
+	if err := stores.LoginSession.DeleteExpired(now); err != nil {
+		return nil, err
+	}
+
+	return stores.LoginSession.Create(&model.LoginSession{
+		UserID:     userID,
+		Kind:       kind,
+		TokenID:    auth.GenerateToken(16),
+		UserAgent:  r.UserAgent(),
+		Address:    r.RemoteAddr,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(loginSessionLifetime(kind)),
+	})
+}
+
+// RotateLoginSession replaces the refresh token of a session by a new one. Each
+// refresh token can be used once. A second usage means that the token has been
+// stolen, so the whole session is revoked as we cannot tell the thief and the
+// user apart.
+func RotateLoginSession(stores *Stores, claims *authenticate.RefreshClaims) (*model.LoginSession, error) {
+	now := NowUTC()
+
+	session, err := stores.LoginSession.Get(claims.SessionID)
+	if err != nil || session.UserID != claims.LoginID || session.Kind != model.LoginSessionRefresh {
+		return nil, ErrLoginSessionInvalid
+	}
+	if !session.ExpiresAt.After(now) {
+		return nil, ErrLoginSessionInvalid
+	}
+
+	reused := session.TokenID != claims.Id
+	if !reused {
+		tokenID := auth.GenerateToken(16)
+		rotated, err := stores.LoginSession.Rotate(session.ID, claims.Id, tokenID, now, now.Add(loginSessionLifetime(session.Kind)))
+		if err != nil {
+			return nil, err
+		}
+		// another request with the same token has been faster
+		reused = !rotated
+		session.TokenID = tokenID
+	}
+
+	if reused {
+		if err := stores.LoginSession.Delete(session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return session, nil
+}
+
+// RevokeLoginSessions ends all logins of a user except the given session,
+// which can be 0 to end all of them.
+func RevokeLoginSessions(stores *Stores, userID int64, exceptSessionID int64) error {
+	return stores.LoginSession.DeleteAllOfUser(userID, exceptSessionID)
+}
+
+// RevokeCredentials ends all logins of a user except the given session and
+// revokes all personal api tokens, which might have been created by whoever
+// knew the old password. Tokens of testing jobs act as the system and not as
+// the user, so they are not affected.
+func RevokeCredentials(stores *Stores, userID int64, exceptSessionID int64) error {
+	if err := RevokeLoginSessions(stores, userID, exceptSessionID); err != nil {
+		return err
+	}
+	return stores.PersonalToken.DeleteAllOfUser(userID)
+}
+
+// ValidateSession checks that the session of a request has been neither
+// revoked nor expired and records its usage.
+func (rs *LoginSessionResource) ValidateSession(sessionID int64, loginID int64) error {
+	now := NowUTC()
+
+	session, err := rs.Stores.LoginSession.Get(sessionID)
+	if err != nil || session.UserID != loginID || !session.ExpiresAt.After(now) {
+		return ErrLoginSessionInvalid
+	}
+
+	if now.Sub(session.LastUsedAt) > loginSessionTouchInterval {
+		return rs.Stores.LoginSession.Touch(session.ID, now)
+	}
+	return nil
+}
+
+// IndexHandler is public endpoint for
+// URL: /account/sessions
+// METHOD: get
+// TAG: account
+// RESPONSE: 200,LoginSessionResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all active logins of the request identity
+func (rs *LoginSessionResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	sessions, err := rs.Stores.LoginSession.SessionsOfUser(accessClaims.LoginID, NowUTC())
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newLoginSessionListResponse(sessions, accessClaims.SessionID)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /account/sessions/{session_id}
+// URLPARAM: session_id,integer
+// METHOD: delete
+// TAG: account
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// RESPONSE: 404,NotFound
+// SUMMARY:  revoke a login
+// DESCRIPTION:
+// The cookie or refresh token of the login cannot be used anymore. Access tokens
+// issued for the login are rejected as well.
+func (rs *LoginSessionResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "session_id"), 10, 64)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	session, err := rs.Stores.LoginSession.Get(sessionID)
+	// sessions of other users are reported as missing
+	if err != nil || session.UserID != accessClaims.LoginID {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	if err := rs.Stores.LoginSession.Delete(session.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteOthersHandler is public endpoint for
+// URL: /account/sessions
+// METHOD: delete
+// TAG: account
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  revoke all logins except the one of the request
+func (rs *LoginSessionResource) DeleteOthersHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if err := RevokeLoginSessions(rs.Stores, accessClaims.LoginID, accessClaims.SessionID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
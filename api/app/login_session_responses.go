@@ -0,0 +1,69 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+)
+
+// LoginSessionResponse is the response payload for a login of an account.
+type LoginSessionResponse struct {
+	ID         int64     `json:"id" example:"14"`
+	Kind       string    `json:"kind" example:"cookie"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	Address    string    `json:"address" example:"1.2.3.4"`
+	CreatedAt  time.Time `json:"created_at" example:"auto"`
+	LastUsedAt time.Time `json:"last_used_at" example:"auto"`
+	ExpiresAt  time.Time `json:"expires_at" example:"auto"`
+	// Current marks the login of the request.
+	Current bool `json:"current" example:"true"`
+}
+
+// Render post-processes a LoginSessionResponse.
+func (body *LoginSessionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newLoginSessionResponse creates a response from a LoginSession model.
+func newLoginSessionResponse(p *model.LoginSession, currentSessionID int64) *LoginSessionResponse {
+	return &LoginSessionResponse{
+		ID:         p.ID,
+		Kind:       p.Kind,
+		UserAgent:  p.UserAgent,
+		Address:    p.Address,
+		CreatedAt:  p.CreatedAt,
+		LastUsedAt: p.LastUsedAt,
+		ExpiresAt:  p.ExpiresAt,
+		Current:    p.ID == currentSessionID,
+	}
+}
+
+// newLoginSessionListResponse creates a response from a list of LoginSession models.
+func newLoginSessionListResponse(sessions []model.LoginSession, currentSessionID int64) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range sessions {
+		list = append(list, newLoginSessionResponse(&sessions[k], currentSessionID))
+	}
+	return list
+}
@@ -0,0 +1,267 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	otape "github.com/infomark-org/infomark/tape"
+)
+
+func TestLoginSession(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+	var stores *Stores
+
+	requestTokens := func(modifiers ...otape.RequestModifier) *AuthResponse {
+		data := H{}
+		if len(modifiers) == 0 {
+			data = H{"email": "test@uni-tuebingen.de", "plain_password": "test"}
+		}
+		w := tape.Post("/api/v1/auth/token", data, modifiers...)
+		g.Assert(w.Code).Equal(http.StatusOK)
+		tokens := &AuthResponse{}
+		g.Assert(json.NewDecoder(w.Body).Decode(tokens)).Equal(nil)
+		return tokens
+	}
+
+	login := func() CookieRequest {
+		w := tape.Post("/api/v1/auth/sessions", H{"email": "test@uni-tuebingen.de", "plain_password": "test"})
+		g.Assert(w.Code).Equal(http.StatusOK)
+		cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+		cookies.Store(w)
+		return cookies
+	}
+
+	sessions := func(modifier otape.RequestModifier) []LoginSessionResponse {
+		w := tape.Get("/api/v1/account/sessions", modifier)
+		g.Assert(w.Code).Equal(http.StatusOK)
+		list := []LoginSessionResponse{}
+		g.Assert(json.NewDecoder(w.Body).Decode(&list)).Equal(nil)
+		return list
+	}
+
+	g.Describe("LoginSession", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should rotate refresh tokens", func() {
+			first := requestTokens()
+			second := requestTokens(BearerRequest{first.Refresh.Token})
+			g.Assert(second.Refresh.Token != "").IsTrue()
+			g.Assert(second.Refresh.Token != first.Refresh.Token).IsTrue()
+
+			third := requestTokens(BearerRequest{second.Refresh.Token})
+			g.Assert(third.Access.Token != "").IsTrue()
+
+			w = tape.Get("/api/v1/me", BearerRequest{third.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should revoke the session when a refresh token is reused", func() {
+			first := requestTokens()
+			second := requestTokens(BearerRequest{first.Refresh.Token})
+
+			w = tape.Post("/api/v1/auth/token", H{}, BearerRequest{first.Refresh.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// the legitimate chain ends as well
+			w = tape.Post("/api/v1/auth/token", H{}, BearerRequest{second.Refresh.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/me", BearerRequest{second.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.It("Should list the logins", func() {
+			tokens := requestTokens()
+			cookies := login()
+
+			list := sessions(cookies)
+			g.Assert(len(list)).Equal(2)
+
+			current := 0
+			for _, session := range list {
+				if session.Current {
+					current++
+					g.Assert(session.Kind).Equal("cookie")
+				}
+			}
+			g.Assert(current).Equal(1)
+
+			// other users do not see them
+			g.Assert(len(sessions(tape.NewJWTRequest(2, false)))).Equal(0)
+
+			w = tape.Get("/api/v1/me", BearerRequest{tokens.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should revoke a login", func() {
+			tokens := requestTokens()
+			cookies := login()
+
+			var sessionID int64
+			for _, session := range sessions(cookies) {
+				if session.Kind == "refresh" {
+					sessionID = session.ID
+				}
+			}
+
+			url := fmt.Sprintf("/api/v1/account/sessions/%d", sessionID)
+			w = tape.Delete(url, tape.NewJWTRequest(2, false))
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Delete(url, cookies)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/me", BearerRequest{tokens.Access.Token})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Post("/api/v1/auth/token", H{}, BearerRequest{tokens.Refresh.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should revoke all other logins", func() {
+			other := login()
+			cookies := login()
+
+			w = tape.Delete("/api/v1/account/sessions", cookies)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/me", other)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should end the login on logout", func() {
+			cookies := login()
+			stale := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			for name, cookie := range cookies.Cookies {
+				stale.Cookies[name] = cookie
+			}
+
+			w = tape.Delete("/api/v1/auth/sessions", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// a copy of the cookie is useless
+			w = tape.Get("/api/v1/me", stale)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.It("Should revoke all logins on a password reset", func() {
+			cookies := login()
+			tokens := requestTokens()
+
+			w = tape.Post("/api/v1/account/tokens", H{"name": "script", "scope": "read"}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			personalToken := &PersonalTokenResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(personalToken)).Equal(nil)
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			user.ResetPasswordToken.SetValid("reset")
+			g.Assert(stores.User.Update(user)).Equal(nil)
+
+			w = tape.Post("/api/v1/auth/update_password", H{
+				"reset_password_token": "reset",
+				"plain_password":       "new_password",
+				"email":                "test@uni-tuebingen.de",
+			})
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Post("/api/v1/auth/token", H{}, BearerRequest{tokens.Refresh.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/me", BearerRequest{personalToken.Token})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.It("Should keep the current login when changing the password", func() {
+			other := login()
+			cookies := login()
+
+			w = tape.Patch("/api/v1/account", H{
+				"account":            H{"plain_password": "new_password"},
+				"old_plain_password": "test",
+			}, cookies)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/me", other)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should revoke all logins when an admin sets the password", func() {
+			student, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/auth/sessions", H{"email": student.Email, "plain_password": "test"})
+			g.Assert(w.Code).Equal(http.StatusOK)
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			cookies.Store(w)
+
+			data := H{
+				"first_name":     student.FirstName,
+				"last_name":      student.LastName,
+				"email":          student.Email,
+				"student_number": student.StudentNumber,
+				"semester":       student.Semester,
+				"subject":        student.Subject,
+				"language":       student.Language,
+				"plain_password": "new_password",
+			}
+			w = tape.Put("/api/v1/users/112", data, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -0,0 +1,119 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// ErrOIDCEmailNotVerified is returned when an identity without a verified email
+// address would be linked to or create an account.
+var ErrOIDCEmailNotVerified = errors.New("identity provider has not verified the email address")
+
+// ErrOIDCMultiFactorRequired is returned when an account with two-factor
+// authentication logs in without a second factor at the identity provider.
+var ErrOIDCMultiFactorRequired = errors.New("identity provider did not check a second factor, log in with password and two-factor code instead")
+
+// ProvisionOIDCUser returns the account of a single sign-on identity. Accounts
+// are linked by the subject of the identity and, on first login, by the email
+// address. Unknown identities get a new confirmed account. Both require the
+// identity provider to have verified the email address. Profile fields are
+// refreshed from the identity provider on every login. Root privileges are
+// granted when mapped, but never revoked here. Accounts with two-factor
+// authentication are only linked or updated when the identity provider checked
+// a second factor.
+func ProvisionOIDCUser(stores *Stores, identity *authenticate.OIDCIdentity) (*model.User, error) {
+	user, err := stores.User.FindByOIDCSubject(identity.Subject)
+	if err == sql.ErrNoRows {
+		// anybody could claim the address of an existing account
+		if !identity.EmailVerified {
+			return nil, ErrOIDCEmailNotVerified
+		}
+
+		user, err = stores.User.FindByEmail(identity.Email)
+		if err == sql.ErrNoRows {
+			return createOIDCUser(stores, identity)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user.OIDCSubject.Valid {
+			return nil, fmt.Errorf("account '%s' is linked to another identity", user.Email)
+		}
+		user.OIDCSubject = null.StringFrom(identity.Subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the second factor of the account cannot be checked here
+	if user.TOTPEnabled && !identity.MultiFactor {
+		return nil, ErrOIDCMultiFactorRequired
+	}
+
+	if identity.FirstName != "" {
+		user.FirstName = identity.FirstName
+	}
+	if identity.LastName != "" {
+		user.LastName = identity.LastName
+	}
+	if identity.StudentNumber != "" {
+		user.StudentNumber = identity.StudentNumber
+	}
+	if identity.EmailVerified {
+		user.ConfirmEmailToken = null.String{}
+	}
+	user.Root = user.Root || identity.Root
+
+	if err := stores.User.Update(user); err != nil {
+		return nil, err
+	}
+	return stores.User.Get(user.ID)
+}
+
+func createOIDCUser(stores *Stores, identity *authenticate.OIDCIdentity) (*model.User, error) {
+	if identity.FirstName == "" || identity.LastName == "" {
+		return nil, errors.New("identity provider did not send a name")
+	}
+
+	// the password is never used, but must not be guessable
+	encryptedPassword, err := HashPassword(auth.GenerateToken(32))
+	if err != nil {
+		return nil, err
+	}
+
+	return stores.User.Create(&model.User{
+		FirstName:         identity.FirstName,
+		LastName:          identity.LastName,
+		Email:             identity.Email,
+		StudentNumber:     identity.StudentNumber,
+		Language:          "en",
+		EncryptedPassword: encryptedPassword,
+		Root:              identity.Root,
+		OIDCSubject:       null.StringFrom(identity.Subject),
+	})
+}
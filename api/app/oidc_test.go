@@ -0,0 +1,288 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/auth/authenticate/oidctest"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// CookieRequest sends the cookies set by previous responses.
+type CookieRequest struct {
+	Cookies map[string]*http.Cookie
+}
+
+func (t CookieRequest) Modify(r *http.Request) {
+	for _, cookie := range t.Cookies {
+		r.AddCookie(cookie)
+	}
+}
+
+// Store keeps the last cookie of each name set by the response.
+func (t CookieRequest) Store(w *httptest.ResponseRecorder) {
+	for _, cookie := range w.Result().Cookies() {
+		t.Cookies[cookie.Name] = cookie
+	}
+}
+
+func TestOIDC(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+	var stores *Stores
+	var issuer *oidctest.Issuer
+
+	oidcConfig := &configuration.Configuration.Server.Authentication.OIDC
+
+	// login runs the flow until the callback of the identity provider has been
+	// handled and returns the response of the callback.
+	login := func(cookies CookieRequest) *httptest.ResponseRecorder {
+		w := tape.Get("/api/v1/auth/oidc/login", cookies)
+		g.Assert(w.Code).Equal(http.StatusFound)
+		cookies.Store(w)
+
+		client := &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := client.Get(w.Header().Get("Location"))
+		g.Assert(err).Equal(nil)
+		resp.Body.Close()
+		g.Assert(resp.StatusCode).Equal(http.StatusFound)
+
+		callback, err := url.Parse(resp.Header.Get("Location"))
+		g.Assert(err).Equal(nil)
+		g.Assert(callback.Path).Equal("/api/v1/auth/oidc/callback")
+
+		w = tape.Get(callback.RequestURI(), cookies)
+		cookies.Store(w)
+		return w
+	}
+
+	me := func(cookies CookieRequest) map[string]interface{} {
+		w := tape.Get("/api/v1/me", cookies)
+		g.Assert(w.Code).Equal(http.StatusOK)
+		result := map[string]interface{}{}
+		err := json.NewDecoder(w.Body).Decode(&result)
+		g.Assert(err).Equal(nil)
+		return result
+	}
+
+	g.Describe("OIDC", func() {
+
+		g.BeforeEach(func() {
+			issuer = oidctest.NewIssuer("infomark", "secret")
+			issuer.Claims = map[string]interface{}{
+				"sub":            "idp-4711",
+				"email":          "sso.user@uni-tuebingen.de",
+				"email_verified": true,
+				"given_name":     "Sso",
+				"family_name":    "User",
+				"matrikel":       "4012345",
+				"groups":         []interface{}{"students"},
+			}
+
+			oidcConfig.Enabled = true
+			oidcConfig.Issuer = issuer.URL()
+			oidcConfig.ClientID = "infomark"
+			oidcConfig.ClientSecret = "secret"
+			oidcConfig.Claims.StudentNumber = "matrikel"
+			oidcConfig.RootValues = []string{"infomark-admins"}
+
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.AfterEach(func() {
+			oidcConfig.Enabled = false
+			oidcConfig.Claims.StudentNumber = ""
+			oidcConfig.RootValues = []string{}
+			issuer.Close()
+		})
+
+		g.It("Should be unavailable when disabled", func() {
+			oidcConfig.Enabled = false
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+
+			w = tape.Get("/api/v1/auth/oidc/login")
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Get("/api/v1/auth/oidc/callback?code=a&state=b")
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+		})
+
+		g.It("Should create an account for unknown identities", func() {
+			_, err := stores.User.FindByEmail("sso.user@uni-tuebingen.de")
+			g.Assert(err == nil).IsFalse()
+
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = login(cookies)
+			g.Assert(w.Code).Equal(http.StatusFound)
+
+			user, err := stores.User.FindByOIDCSubject("idp-4711")
+			g.Assert(err).Equal(nil)
+			g.Assert(user.Email).Equal("sso.user@uni-tuebingen.de")
+			g.Assert(user.FirstName).Equal("Sso")
+			g.Assert(user.LastName).Equal("User")
+			g.Assert(user.StudentNumber).Equal("4012345")
+			g.Assert(user.Root).Equal(false)
+			g.Assert(user.ConfirmEmailToken.Valid).Equal(false)
+
+			g.Assert(me(cookies)["email"]).Equal("sso.user@uni-tuebingen.de")
+		})
+
+		g.It("Should link existing accounts by email and map groups to root", func() {
+			userBefore, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(userBefore.Root).Equal(false)
+			g.Assert(userBefore.OIDCSubject.Valid).Equal(false)
+
+			issuer.Claims["email"] = userBefore.Email
+			issuer.Claims["groups"] = []interface{}{"students", "infomark-admins"}
+
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = login(cookies)
+			g.Assert(w.Code).Equal(http.StatusFound)
+
+			userAfter, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(userAfter.OIDCSubject.String).Equal("idp-4711")
+			g.Assert(userAfter.Root).Equal(true)
+			g.Assert(userAfter.FirstName).Equal("Sso")
+
+			g.Assert(me(cookies)["id"]).Equal(float64(112))
+
+			// the second login uses the subject
+			issuer.Claims["email"] = "changed@uni-tuebingen.de"
+			w = login(CookieRequest{Cookies: map[string]*http.Cookie{}})
+			g.Assert(w.Code).Equal(http.StatusFound)
+
+			userAfter, err = stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(userAfter.Email).Equal(userBefore.Email)
+		})
+
+		g.It("Should not link or create accounts with unverified email addresses", func() {
+			userBefore, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+
+			issuer.Claims["email"] = userBefore.Email
+			issuer.Claims["email_verified"] = false
+
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = login(cookies)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			userAfter, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(userAfter.OIDCSubject.Valid).Equal(false)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			// neither for unknown addresses
+			issuer.Claims["email"] = "sso.user@uni-tuebingen.de"
+			w = login(CookieRequest{Cookies: map[string]*http.Cookie{}})
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			_, err = stores.User.FindByEmail("sso.user@uni-tuebingen.de")
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should require a second factor for accounts with two-factor authentication", func() {
+			user, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			user.TOTPEnabled = true
+			user.TOTPSecret = null.StringFrom("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+			g.Assert(stores.User.Update(user)).Equal(nil)
+
+			issuer.Claims["email"] = user.Email
+
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = login(cookies)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get("/api/v1/me", cookies)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			// the rejected login neither links nor updates the account
+			userAfter, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(userAfter.OIDCSubject.Valid).Equal(false)
+			g.Assert(userAfter.FirstName).Equal(user.FirstName)
+
+			issuer.Claims["amr"] = []interface{}{"pwd", "mfa"}
+			cookies = CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = login(cookies)
+			g.Assert(w.Code).Equal(http.StatusFound)
+			g.Assert(me(cookies)["id"]).Equal(float64(112))
+		})
+
+		g.It("Should restrict staff without two-factor authentication to the setup", func() {
+			twoFactorConfig := &configuration.Configuration.Server.Authentication.TwoFactor
+			twoFactorConfig.RequiredForStaff = true
+			defer func() { twoFactorConfig.RequiredForStaff = false }()
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			issuer.Claims["email"] = user.Email
+
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = login(cookies)
+			g.Assert(w.Code).Equal(http.StatusFound)
+
+			w = tape.Get("/api/v1/courses", cookies)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/account/two_factor", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should reject a callback with a wrong state", func() {
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			w = tape.Get("/api/v1/auth/oidc/login", cookies)
+			g.Assert(w.Code).Equal(http.StatusFound)
+			cookies.Store(w)
+
+			w = tape.Get("/api/v1/auth/oidc/callback?code=forged&state=forged", cookies)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should reject a callback without a login in progress", func() {
+			w = tape.Get("/api/v1/auth/oidc/callback?code=forged&state=forged")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+	})
+}
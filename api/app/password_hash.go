@@ -0,0 +1,77 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/model"
+	"github.com/sirupsen/logrus"
+)
+
+// CurrentPasswordHasher returns the password hasher from the configuration.
+func CurrentPasswordHasher() *auth.PasswordHasher {
+	config := configuration.Configuration.Server.Authentication.Password.Hashing
+
+	return &auth.PasswordHasher{
+		Algorithm:  config.Algorithm,
+		BcryptCost: config.BcryptCost,
+		Argon2id: auth.Argon2idParameters{
+			Memory:      config.Argon2id.Memory,
+			Iterations:  config.Argon2id.Iterations,
+			Parallelism: config.Argon2id.Parallelism,
+			SaltLength:  config.Argon2id.SaltLength,
+			KeyLength:   config.Argon2id.KeyLength,
+		},
+	}
+}
+
+// HashPassword securely hashes a plain password with the configured algorithm.
+func HashPassword(plainPassword string) (string, error) {
+	return CurrentPasswordHasher().Hash(plainPassword)
+}
+
+// RehashPassword replaces the password hash of a user after a successful
+// login when it was created with an outdated algorithm or parameters. Failures
+// are only logged, as the login itself is fine.
+func RehashPassword(stores *Stores, user *model.User, plainPassword string) {
+	hasher := CurrentPasswordHasher()
+	if !hasher.NeedsRehash(user.EncryptedPassword) {
+		return
+	}
+	// directory logins keep their unusable placeholder hash
+	if !auth.CheckPasswordHash(plainPassword, user.EncryptedPassword) {
+		return
+	}
+
+	encryptedPassword, err := hasher.Hash(plainPassword)
+	if err == nil {
+		err = stores.User.UpdatePasswordHash(user.ID, encryptedPassword)
+	}
+	if err != nil {
+		logrus.StandardLogger().WithFields(logrus.Fields{
+			"module":  "password",
+			"user_id": user.ID,
+		}).Warnf("cannot rehash password: %v", err)
+		return
+	}
+
+	user.EncryptedPassword = encryptedPassword
+}
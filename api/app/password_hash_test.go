@@ -0,0 +1,144 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHash(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	hashingConfig := &configuration.Configuration.Server.Authentication.Password.Hashing
+	defaultHashingConfig := *hashingConfig
+
+	login := func(password string) int {
+		w := tape.Post("/api/v1/auth/sessions", H{
+			"email":          "test@uni-tuebingen.de",
+			"plain_password": password,
+		})
+		return w.Code
+	}
+
+	g.Describe("PasswordHash", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.AfterEach(func() {
+			*hashingConfig = defaultHashingConfig
+			tape.AfterEach()
+		})
+
+		g.It("Should rehash outdated hashes on login", func() {
+			hashingConfig.Algorithm = auth.HashAlgorithmArgon2id
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(auth.HashAlgorithm(user.EncryptedPassword)).Equal(auth.HashAlgorithmBcrypt)
+
+			g.Assert(login("test")).Equal(http.StatusOK)
+
+			user, err = stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.HasPrefix(user.EncryptedPassword, "$argon2id$v=19$m=19456,t=2,p=1$")).IsTrue()
+			g.Assert(auth.CheckPasswordHash("test", user.EncryptedPassword)).IsTrue()
+
+			// stronger parameters are applied on the next login
+			hashingConfig.Argon2id.Iterations = 3
+			g.Assert(login("test")).Equal(http.StatusOK)
+
+			user, err = stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.HasPrefix(user.EncryptedPassword, "$argon2id$v=19$m=19456,t=3,p=1$")).IsTrue()
+		})
+
+		g.It("Should switch back to bcrypt", func() {
+			hashingConfig.Algorithm = auth.HashAlgorithmBcrypt
+			hashingConfig.BcryptCost = 4
+
+			g.Assert(login("test")).Equal(http.StatusOK)
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			cost, err := bcrypt.Cost([]byte(user.EncryptedPassword))
+			g.Assert(err).Equal(nil)
+			g.Assert(cost).Equal(4)
+		})
+
+		g.It("Should not rehash on failed logins", func() {
+			before, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+
+			g.Assert(login("wrong")).Equal(http.StatusBadRequest)
+
+			after, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(after.EncryptedPassword).Equal(before.EncryptedPassword)
+		})
+
+		g.It("Should not rehash before the second factor", func() {
+			hashingConfig.Algorithm = auth.HashAlgorithmArgon2id
+
+			before, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			before.TOTPSecret.SetValid("JBSWY3DPEHPK3PXP")
+			before.TOTPEnabled = true
+			g.Assert(stores.User.Update(before)).Equal(nil)
+
+			g.Assert(login("test")).Equal(http.StatusBadRequest)
+
+			after, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(after.EncryptedPassword).Equal(before.EncryptedPassword)
+		})
+
+		g.It("Should hash new passwords with the configured algorithm", func() {
+			hashingConfig.Algorithm = auth.HashAlgorithmArgon2id
+
+			w := tape.Patch("/api/v1/account", H{
+				"account":            H{"plain_password": "Sur4-Gravel-Tiger"},
+				"old_plain_password": "test",
+			}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(auth.HashAlgorithm(user.EncryptedPassword)).Equal(auth.HashAlgorithmArgon2id)
+		})
+	})
+}
@@ -0,0 +1,99 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/configuration"
+)
+
+// commonPasswords caches the list of common passwords of the configured file.
+var commonPasswords struct {
+	sync.Mutex
+	file string
+	list map[string]bool
+}
+
+// loadCommonPasswords returns the bundled list of common passwords or the one
+// from the given file. The file is only read again when the path changes.
+func loadCommonPasswords(file string) (map[string]bool, error) {
+	commonPasswords.Lock()
+	defer commonPasswords.Unlock()
+
+	if commonPasswords.list != nil && commonPasswords.file == file {
+		return commonPasswords.list, nil
+	}
+
+	list := auth.BundledCommonPasswords()
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read list of common passwords: %w", err)
+		}
+		defer f.Close()
+
+		list, err = auth.ReadCommonPasswords(f)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read list of common passwords: %w", err)
+		}
+	}
+
+	commonPasswords.file = file
+	commonPasswords.list = list
+	return list, nil
+}
+
+// CurrentPasswordPolicy returns the password policy from the configuration.
+func CurrentPasswordPolicy() (*auth.PasswordPolicy, error) {
+	config := configuration.Configuration.Server.Authentication.Password
+
+	common, err := loadCommonPasswords(config.CommonPasswordsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.PasswordPolicy{
+		MinLength:        config.MinLength,
+		MaxLength:        config.MaxLength,
+		CharacterClasses: config.CharacterClasses,
+		BanPersonalData:  config.BanPersonalData,
+		Common:           common,
+	}, nil
+}
+
+// ValidateNewPassword checks a password a user wants to set against the
+// password policy. Personal data are the names and the email address of the
+// user. Violations are reported for the field "plain_password".
+func ValidateNewPassword(password string, personal ...string) error {
+	policy, err := CurrentPasswordPolicy()
+	if err != nil {
+		return err
+	}
+
+	if err := policy.Check(password, personal...); err != nil {
+		return validation.Errors{"plain_password": err}
+	}
+	return nil
+}
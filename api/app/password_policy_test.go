@@ -0,0 +1,157 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	passwordConfig := &configuration.Configuration.Server.Authentication.Password
+	defaultPasswordConfig := *passwordConfig
+
+	createAccount := func(password string) map[string]map[string]string {
+		w := tape.Post("/api/v1/account", H{
+			"user": H{
+				"first_name":     "Max",
+				"last_name":      "Mustermensch",
+				"email":          "max@mensch.com",
+				"student_number": "0815",
+				"semester":       2,
+				"subject":        "bio2",
+				"language":       "de",
+			},
+			"account": H{
+				"email":          "max@mensch.com",
+				"plain_password": password,
+			},
+		})
+		if w.Code == http.StatusCreated {
+			return nil
+		}
+		g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+		resp := struct {
+			Errors map[string]map[string]string `json:"errors"`
+		}{}
+		g.Assert(json.NewDecoder(w.Body).Decode(&resp)).Equal(nil)
+		return resp.Errors
+	}
+
+	g.Describe("PasswordPolicy", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.AfterEach(func() {
+			*passwordConfig = defaultPasswordConfig
+		})
+
+		g.It("Should report violations per rule", func() {
+			passwordConfig.CharacterClasses = 3
+
+			errs := createAccount("Mustermensch")
+			g.Assert(len(errs["plain_password"])).Equal(2)
+			g.Assert(errs["plain_password"]["personal_data"] != "").IsTrue()
+			g.Assert(errs["plain_password"]["character_classes"] != "").IsTrue()
+
+			g.Assert(createAccount("Sur4-Gravel-Tiger") == nil).IsTrue()
+		})
+
+		g.It("Should reject common passwords", func() {
+			errs := createAccount("Password123")
+			g.Assert(errs["plain_password"]["common"] != "").IsTrue()
+
+			_, err := stores.User.FindByEmail("max@mensch.com")
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("Should read common passwords from the configured file", func() {
+			file := filepath.Join(t.TempDir(), "common.txt")
+			g.Assert(os.WriteFile(file, []byte("gravel-tiger\n"), 0644)).Equal(nil)
+			passwordConfig.CommonPasswordsFile = file
+
+			errs := createAccount("Gravel-Tiger")
+			g.Assert(errs["plain_password"]["common"] != "").IsTrue()
+
+			// the bundled list is replaced
+			g.Assert(createAccount("Password123") == nil).IsTrue()
+		})
+
+		g.It("Should be applied when the password is changed", func() {
+			w := tape.Patch("/api/v1/account", H{
+				"account":            H{"plain_password": "Password123"},
+				"old_plain_password": "test",
+			}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(auth.CheckPasswordHash("test", user.EncryptedPassword)).IsTrue()
+
+			user.LastName = "Mustermann"
+			g.Assert(stores.User.Update(user)).Equal(nil)
+
+			// the name of the user is only known to the handler
+			w = tape.Patch("/api/v1/account", H{
+				"account":            H{"plain_password": "Mustermann-1"},
+				"old_plain_password": "test",
+			}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should be applied when the password is reset", func() {
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			user.ResetPasswordToken.SetValid("ResetToken")
+			g.Assert(stores.User.Update(user)).Equal(nil)
+
+			w := tape.Post("/api/v1/auth/update_password", H{
+				"email":                user.Email,
+				"reset_password_token": "ResetToken",
+				"plain_password":       "qwertz123",
+			})
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			user, err = stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(auth.CheckPasswordHash("test", user.EncryptedPassword)).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,219 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// PersonalTokenResource specifies personal api token management handler.
+type PersonalTokenResource struct {
+	Stores *Stores
+}
+
+// NewPersonalTokenResource create and returns a PersonalTokenResource.
+func NewPersonalTokenResource(stores *Stores) *PersonalTokenResource {
+	return &PersonalTokenResource{
+		Stores: stores,
+	}
+}
+
+// hashToken returns the representation of a token in the database. Tokens are
+// random, so a plain sha256 is sufficient and allows a lookup.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ResolvePersonalToken looks up the claims of a personal api token and records
+// its usage.
+func (rs *PersonalTokenResource) ResolvePersonalToken(token string) (*authenticate.AccessClaims, error) {
+	p, err := rs.Stores.PersonalToken.FindByHash(hashToken(token))
+	if err != nil {
+		return nil, errors.New("unknown personal token")
+	}
+
+	now := NowUTC()
+	if p.ExpiresAt.Valid && !p.ExpiresAt.Time.After(now) {
+		return nil, errors.New("personal token expired")
+	}
+
+	user, err := rs.Stores.User.Get(p.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a course admin token acts by the course role of its owner only, even
+	// when the owner is root
+	root := user.Root && p.Scope != authenticate.ScopeCourseAdmin
+
+	if err := rs.Stores.PersonalToken.Touch(p.ID, now); err != nil {
+		return nil, err
+	}
+
+	claims := authenticate.NewAccessClaims(user.ID, root)
+	claims.Scope = &authenticate.TokenScope{
+		Kind:     p.Scope,
+		CourseID: p.CourseID.Int64,
+	}
+	return &claims, nil
+}
+
+// IndexHandler is public endpoint for
+// URL: /account/tokens
+// METHOD: get
+// TAG: account
+// RESPONSE: 200,PersonalTokenResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all personal api tokens of the request identity
+func (rs *PersonalTokenResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	tokens, err := rs.Stores.PersonalToken.TokensOfUser(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newPersonalTokenListResponse(tokens)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /account/tokens
+// METHOD: post
+// TAG: account
+// REQUEST: PersonalTokenRequest
+// RESPONSE: 201,PersonalTokenResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a personal api token
+// DESCRIPTION:
+// The token is part of the response only once and is sent as "Authorization: Bearer <token>".
+// The scope "read" allows GET requests, "grading" additionally allows to edit single grades
+// and "course_admin" acts as the course admin of the given course_id. Read and grading
+// tokens can be limited to a course by a course_id as well. Tokens can never manage the account.
+func (rs *PersonalTokenResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &PersonalTokenRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if data.CourseID.Valid {
+		if _, err := rs.Stores.Course.Get(data.CourseID.Int64); err != nil {
+			render.Render(w, r, ErrBadRequestWithDetails(errors.New("course does not exist")))
+			return
+		}
+
+		role, err := rs.Stores.Course.RoleInCourse(accessClaims.LoginID, data.CourseID.Int64)
+		if err != nil {
+			role = authorize.NOCOURSEROLE
+		}
+		if data.Scope == authenticate.ScopeCourseAdmin && role != authorize.ADMIN {
+			render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not an admin of this course")))
+			return
+		}
+		if role == authorize.NOCOURSEROLE && !accessClaims.Root {
+			render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not enrolled in this course")))
+			return
+		}
+	}
+
+	token := authenticate.PersonalTokenPrefix + auth.GenerateToken(24)
+
+	p, err := rs.Stores.PersonalToken.Create(&model.PersonalToken{
+		UserID:    accessClaims.LoginID,
+		Name:      data.Name,
+		TokenHash: hashToken(token),
+		Scope:     data.Scope,
+		CourseID:  data.CourseID,
+		ExpiresAt: data.ExpiresAt,
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	resp := newPersonalTokenResponse(p)
+	resp.Token = token
+	if err := render.Render(w, r, resp); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// DeleteHandler is public endpoint for
+// URL: /account/tokens/{token_id}
+// URLPARAM: token_id,integer
+// METHOD: delete
+// TAG: account
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// RESPONSE: 404,NotFound
+// SUMMARY:  revoke a personal api token
+func (rs *PersonalTokenResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	tokenID, err := strconv.ParseInt(chi.URLParam(r, "token_id"), 10, 64)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	p, err := rs.Stores.PersonalToken.Get(tokenID)
+	// tokens of other users are reported as missing
+	if err != nil || p.UserID != accessClaims.LoginID {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	if err := rs.Stores.PersonalToken.Delete(p.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// PersonalTokenRequest creates a personal api token. A missing expiry date
+// means the token is valid until it is revoked.
+type PersonalTokenRequest struct {
+	Name      string    `json:"name" example:"grading script"`
+	Scope     string    `json:"scope" example:"grading"`
+	CourseID  null.Int  `json:"course_id" example:"1" required:"false"`
+	ExpiresAt null.Time `json:"expires_at" example:"auto" required:"false"`
+}
+
+// Bind preprocesses a PersonalTokenRequest.
+func (body *PersonalTokenRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"token\" data")
+	}
+	return body.Validate()
+}
+
+func (body *PersonalTokenRequest) Validate() error {
+	if body.Scope == authenticate.ScopeCourseAdmin && !body.CourseID.Valid {
+		return errors.New("scope course_admin requires a course_id")
+	}
+	if body.ExpiresAt.Valid && body.ExpiresAt.Time.Before(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Name,
+			validation.Required,
+			validation.Length(1, 100),
+		),
+		validation.Field(
+			&body.Scope,
+			validation.Required,
+			validation.In(
+				authenticate.ScopeRead,
+				authenticate.ScopeGrading,
+				authenticate.ScopeCourseAdmin,
+			),
+		),
+	)
+}
@@ -0,0 +1,69 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// PersonalTokenResponse is the response payload for a personal api token.
+// The token itself is only part of the response when it is created.
+type PersonalTokenResponse struct {
+	ID         int64     `json:"id" example:"3"`
+	Name       string    `json:"name" example:"grading script"`
+	Scope      string    `json:"scope" example:"grading"`
+	CourseID   null.Int  `json:"course_id" example:"1"`
+	CreatedAt  time.Time `json:"created_at" example:"auto"`
+	ExpiresAt  null.Time `json:"expires_at" example:"auto"`
+	LastUsedAt null.Time `json:"last_used_at" example:"auto"`
+	Token      string    `json:"token,omitempty" example:"imp_9c2f..." required:"false"`
+}
+
+// Render post-processes a PersonalTokenResponse.
+func (body *PersonalTokenResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newPersonalTokenResponse creates a response from a PersonalToken model.
+func newPersonalTokenResponse(p *model.PersonalToken) *PersonalTokenResponse {
+	return &PersonalTokenResponse{
+		ID:         p.ID,
+		Name:       p.Name,
+		Scope:      p.Scope,
+		CourseID:   p.CourseID,
+		CreatedAt:  p.CreatedAt,
+		ExpiresAt:  p.ExpiresAt,
+		LastUsedAt: p.LastUsedAt,
+	}
+}
+
+// newPersonalTokenListResponse creates a response from a list of PersonalToken models.
+func newPersonalTokenListResponse(tokens []model.PersonalToken) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range tokens {
+		list = append(list, newPersonalTokenResponse(&tokens[k]))
+	}
+	return list
+}
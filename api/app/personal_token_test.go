@@ -0,0 +1,178 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+)
+
+type BearerRequest struct {
+	Token string
+}
+
+func (t BearerRequest) Modify(r *http.Request) {
+	r.Header.Add("Authorization", "Bearer "+t.Token)
+}
+
+func TestPersonalToken(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+	var stores *Stores
+
+	create := func(loginID int64, data H) *PersonalTokenResponse {
+		w := tape.Post("/api/v1/account/tokens", data, tape.NewJWTRequest(loginID, loginID == 1))
+		g.Assert(w.Code).Equal(http.StatusCreated)
+		token := &PersonalTokenResponse{}
+		g.Assert(json.NewDecoder(w.Body).Decode(token)).Equal(nil)
+		return token
+	}
+
+	g.Describe("PersonalToken", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should require a valid scope", func() {
+			w = tape.Post("/api/v1/account/tokens", H{"name": "script", "scope": "everything"}, tape.NewJWTRequest(112, false))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/account/tokens", H{"name": "script", "scope": "course_admin"}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should show the token only once", func() {
+			token := create(112, H{"name": "script", "scope": "read"})
+			g.Assert(token.Token != "").IsTrue()
+
+			w = tape.Get("/api/v1/account/tokens", tape.NewJWTRequest(112, false))
+			g.Assert(w.Code).Equal(http.StatusOK)
+			tokens := []PersonalTokenResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(&tokens)).Equal(nil)
+			g.Assert(len(tokens)).Equal(1)
+			g.Assert(tokens[0].ID).Equal(token.ID)
+			g.Assert(tokens[0].Token).Equal("")
+
+			stored, err := stores.PersonalToken.Get(token.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(stored.TokenHash == token.Token).IsFalse()
+		})
+
+		g.It("Should authenticate requests within the scope", func() {
+			token := create(112, H{"name": "script", "scope": "read"})
+
+			w = tape.Get("/api/v1/me", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+			user := &UserResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(user)).Equal(nil)
+			g.Assert(user.ID).Equal(int64(112))
+
+			stored, err := stores.PersonalToken.Get(token.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(stored.LastUsedAt.Valid).IsTrue()
+
+			w = tape.Put("/api/v1/me", H{"first_name": "Max"}, BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should never manage the account", func() {
+			token := create(1, H{"name": "script", "scope": "course_admin", "course_id": 1})
+
+			w = tape.Post("/api/v1/account/tokens", H{"name": "other", "scope": "read"}, BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete("/api/v1/account/tokens/1", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should limit course tokens to the course", func() {
+			token := create(1, H{"name": "script", "scope": "course_admin", "course_id": 1})
+
+			w = tape.Get("/api/v1/courses/1/enrollments", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get("/api/v1/courses/2/enrollments", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should let grading tokens change nothing but single grades", func() {
+			token := create(1, H{"name": "script", "scope": "grading", "course_id": 1})
+
+			w = tape.Get("/api/v1/courses/1/grades/workload", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Post("/api/v1/courses/1/grades/assign", H{}, BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/grades/pool/claim", H{}, BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should require the course admin role for course admin tokens", func() {
+			w = tape.Post("/api/v1/account/tokens", H{"name": "script", "scope": "course_admin", "course_id": 1}, tape.NewJWTRequest(2, false))
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should reject unknown and expired tokens", func() {
+			w = tape.Get("/api/v1/me", BearerRequest{"imp_unknown"})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			token := create(112, H{"name": "script", "scope": "read", "expires_at": time.Now().Add(time.Hour)})
+			_, err := tape.DB.Exec("UPDATE personal_tokens SET expires_at = $2 WHERE id = $1", token.ID, time.Now().Add(-time.Minute))
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/me", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.It("Should revoke own tokens only", func() {
+			token := create(112, H{"name": "script", "scope": "read"})
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/account/tokens/%d", token.ID), tape.NewJWTRequest(113, false))
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/account/tokens/%d", token.ID), tape.NewJWTRequest(112, false))
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/me", BearerRequest{token.Token})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
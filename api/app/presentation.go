@@ -0,0 +1,390 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// PresentationResource specifies presentation management handler.
+type PresentationResource struct {
+	Stores *Stores
+}
+
+// NewPresentationResource create and returns a PresentationResource.
+func NewPresentationResource(stores *Stores) *PresentationResource {
+	return &PresentationResource{
+		Stores: stores,
+	}
+}
+
+// PickPresenter draws one of the candidates at random. A student who already
+// presented n times is drawn with a weight of 1/(n+1), such that students who
+// rarely presented are picked more often. Returns 0 without candidates.
+func PickPresenter(candidates []int64, counts map[int64]int, rnd *rand.Rand) int64 {
+	if len(candidates) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	weights := make([]float64, len(candidates))
+	for k, userID := range candidates {
+		weights[k] = 1 / float64(counts[userID]+1)
+		total += weights[k]
+	}
+
+	target := rnd.Float64() * total
+	for k, weight := range weights {
+		if target < weight {
+			return candidates[k]
+		}
+		target -= weight
+	}
+	return candidates[len(candidates)-1]
+}
+
+// getPresentationCounts returns the number of presentations of each student in
+// the course.
+func getPresentationCounts(stores *Stores, courseID int64) (map[int64]int, error) {
+	entries, err := stores.Presentation.CountsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int64]int)
+	for _, entry := range entries {
+		counts[entry.UserID] = entry.Count
+	}
+	return counts, nil
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/presentations
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: get
+// TAG: presentations
+// RESPONSE: 200,PresentationResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all presentations in a group
+func (rs *PresentationResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	presentations, err := rs.Stores.Presentation.PresentationsOfGroup(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newPresentationListResponse(presentations)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/presentations
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: presentations
+// REQUEST: PresentationRequest
+// RESPONSE: 201,PresentationResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  record that a student presented the solution of a task
+func (rs *PresentationResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	data := &PresentationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	taskCourse, err := rs.Stores.Task.IdentifyCourseOfTask(data.TaskID)
+	if err != nil || taskCourse.ID != course.ID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("task does not belong to the course")))
+		return
+	}
+
+	enrollment, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(data.UserID, course.ID)
+	if err != nil || enrollment.GroupID != group.ID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("user is not a member of this group")))
+		return
+	}
+
+	presentation, err := rs.Stores.Presentation.Create(&model.Presentation{
+		GroupID:     group.ID,
+		TaskID:      data.TaskID,
+		UserID:      data.UserID,
+		PresentedAt: data.PresentedAt,
+		Score:       data.Score,
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newPresentationResponse(presentation)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/presentations/{presentation_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: presentation_id,integer
+// METHOD: delete
+// TAG: presentations
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete a presentation
+func (rs *PresentationResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	presentation := r.Context().Value(symbol.CtxKeyPresentation).(*model.Presentation)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	if err := rs.Stores.Presentation.Delete(presentation.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// PickHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/presentations/pick
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: presentations
+// REQUEST: PresenterPickRequest
+// RESPONSE: 200,PresenterResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  pick a random presenter weighted by past presentations
+// DESCRIPTION:
+// A student who presented n times in the course is picked with a weight of 1/(n+1).
+// The candidates default to all members of the group, e.g. the students who are present
+// can be given instead. Students who already presented the given task in this group are
+// skipped unless nobody else is left. Nothing is recorded.
+func (rs *PresentationResource) PickHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	allowed, err := mayTutorGroup(rs.Stores, r, group)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if !allowed {
+		render.Render(w, r, ErrUnauthorizedWithDetails(errors.New("you are not a tutor of this group")))
+		return
+	}
+
+	data := &PresenterPickRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	members, err := rs.Stores.Group.GetMembers(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	id2member := make(map[int64]model.User)
+	for _, member := range members {
+		id2member[member.ID] = member
+	}
+
+	candidates := []int64{}
+	if len(data.CandidateIDs) == 0 {
+		for _, member := range members {
+			candidates = append(candidates, member.ID)
+		}
+	} else {
+		for _, userID := range data.CandidateIDs {
+			if _, exists := id2member[userID]; !exists {
+				render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("user %d is not a member of this group", userID)))
+				return
+			}
+			candidates = append(candidates, userID)
+		}
+	}
+
+	if data.TaskID != 0 {
+		presentations, err := rs.Stores.Presentation.PresentationsOfGroup(group.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		presented := make(map[int64]bool)
+		for _, presentation := range presentations {
+			if presentation.TaskID == data.TaskID {
+				presented[presentation.UserID] = true
+			}
+		}
+
+		remaining := []int64{}
+		for _, userID := range candidates {
+			if !presented[userID] {
+				remaining = append(remaining, userID)
+			}
+		}
+		if len(remaining) > 0 {
+			candidates = remaining
+		}
+	}
+
+	if len(candidates) == 0 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("there is nobody to pick")))
+		return
+	}
+
+	counts, err := getPresentationCounts(rs.Stores, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	userID := PickPresenter(candidates, counts, rand.New(rand.NewSource(time.Now().UnixNano())))
+	presenter := id2member[userID]
+
+	if err := render.Render(w, r, &PresenterResponse{
+		UserID:        presenter.ID,
+		FirstName:     presenter.FirstName,
+		LastName:      presenter.LastName,
+		Email:         presenter.Email,
+		Presentations: counts[presenter.ID],
+	}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetMineHandler is public endpoint for
+// URL: /courses/{course_id}/presentations/own
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: presentations
+// RESPONSE: 200,PresentationCountResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get the number of own presentations in the course
+func (rs *PresentationResource) GetMineHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	counts, err := getPresentationCounts(rs.Stores, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	count := counts[accessClaims.LoginID]
+	if err := render.Render(w, r, &PresentationCountResponse{
+		Presentations: count,
+		Required:      course.RequiredPresentations,
+		Met:           count >= course.RequiredPresentations,
+	}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a presentation from the URL parameter
+// `presentation_id` passed through as the request. In case the presentation
+// could not be found or belongs to another group, we stop here and return a 404.
+func (rs *PresentationResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+		var presentationID int64
+		var err error
+
+		// try to get id from URL
+		if presentationID, err = strconv.ParseInt(chi.URLParam(r, "presentation_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific presentation in database
+		presentation, err := rs.Stores.Presentation.Get(presentationID)
+		if err != nil || presentation.GroupID != group.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// serve next
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyPresentation, presentation)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,81 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// PresentationRequest records that a student presented the solution of a task.
+// A missing date means now.
+type PresentationRequest struct {
+	UserID      int64     `json:"user_id" example:"112"`
+	TaskID      int64     `json:"task_id" example:"4"`
+	PresentedAt time.Time `json:"presented_at" example:"auto" required:"false"`
+	Score       null.Int  `json:"score" example:"2" required:"false"`
+}
+
+// Bind preprocesses a PresentationRequest.
+func (body *PresentationRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"presentation\" data")
+	}
+	if body.PresentedAt.IsZero() {
+		body.PresentedAt = NowUTC()
+	}
+	return body.Validate()
+}
+
+func (body *PresentationRequest) Validate() error {
+	if body.Score.Valid && body.Score.Int64 < 0 {
+		return errors.New("score must not be negative")
+	}
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.UserID,
+			validation.Required,
+		),
+		validation.Field(
+			&body.TaskID,
+			validation.Required,
+		),
+	)
+}
+
+// PresenterPickRequest asks for a random presenter among the candidates (all
+// members of the group by default). Students who already presented the task in
+// this group are skipped unless nobody else is left.
+type PresenterPickRequest struct {
+	TaskID       int64   `json:"task_id" example:"4" required:"false"`
+	CandidateIDs []int64 `json:"candidate_ids" example:"112,113" required:"false"`
+}
+
+// Bind preprocesses a PresenterPickRequest.
+func (body *PresenterPickRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"pick\" data")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// PresentationResponse is the response payload for a presentation.
+type PresentationResponse struct {
+	ID          int64     `json:"id" example:"7"`
+	GroupID     int64     `json:"group_id" example:"1"`
+	TaskID      int64     `json:"task_id" example:"4"`
+	TaskName    string    `json:"task_name" example:"Task 1"`
+	UserID      int64     `json:"user_id" example:"112"`
+	FirstName   string    `json:"first_name" example:"Max"`
+	LastName    string    `json:"last_name" example:"Mustermensch"`
+	Email       string    `json:"email" example:"test@uni-tuebingen.de"`
+	PresentedAt time.Time `json:"presented_at" example:"auto"`
+	Score       null.Int  `json:"score" example:"2"`
+}
+
+// Render post-processes a PresentationResponse.
+func (body *PresentationResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newPresentationResponse creates a response from a Presentation model.
+func newPresentationResponse(p *model.Presentation) *PresentationResponse {
+	return &PresentationResponse{
+		ID:          p.ID,
+		GroupID:     p.GroupID,
+		TaskID:      p.TaskID,
+		TaskName:    p.TaskName,
+		UserID:      p.UserID,
+		FirstName:   p.FirstName,
+		LastName:    p.LastName,
+		Email:       p.Email,
+		PresentedAt: p.PresentedAt,
+		Score:       p.Score,
+	}
+}
+
+// newPresentationListResponse creates a response from a list of Presentation models.
+func newPresentationListResponse(presentations []model.Presentation) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range presentations {
+		list = append(list, newPresentationResponse(&presentations[k]))
+	}
+	return list
+}
+
+// PresenterResponse is the randomly picked presenter.
+type PresenterResponse struct {
+	UserID        int64  `json:"user_id" example:"112"`
+	FirstName     string `json:"first_name" example:"Max"`
+	LastName      string `json:"last_name" example:"Mustermensch"`
+	Email         string `json:"email" example:"test@uni-tuebingen.de"`
+	Presentations int    `json:"presentations" example:"1"`
+}
+
+// Render post-processes a PresenterResponse.
+func (body *PresenterResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// PresentationCountResponse tells how often the identity presented in the
+// course and how often this is required.
+type PresentationCountResponse struct {
+	Presentations int  `json:"presentations" example:"1"`
+	Required      int  `json:"required" example:"2"`
+	Met           bool `json:"met" example:"false"`
+}
+
+// Render post-processes a PresentationCountResponse.
+func (body *PresentationCountResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
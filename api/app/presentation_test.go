@@ -0,0 +1,158 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestPresentation(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	g.Describe("Presentation", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should prefer students with fewer presentations", func() {
+			rnd := rand.New(rand.NewSource(42))
+			counts := map[int64]int{1: 0, 2: 3}
+
+			picked := map[int64]int{}
+			for k := 0; k < 10000; k++ {
+				picked[PickPresenter([]int64{1, 2}, counts, rnd)]++
+			}
+
+			// weights are 1 and 1/4, hence 80% vs. 20%
+			g.Assert(picked[1] > 7500 && picked[1] < 8500).IsTrue()
+			g.Assert(picked[1] + picked[2]).Equal(10000)
+
+			g.Assert(PickPresenter([]int64{}, counts, rnd)).Equal(int64(0))
+		})
+
+		g.It("Should record presentations and pick presenters", func() {
+			members, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 1).IsTrue()
+
+			others, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/groups/1/presentations",
+				H{"user_id": others[0].ID, "task_id": 1}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/groups/2/presentations",
+				H{"user_id": others[0].ID, "task_id": 1}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/groups/2/presentations",
+				H{"user_id": members[0].ID, "task_id": 1, "score": 2}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			presentation := PresentationResponse{}
+			err = json.NewDecoder(w.Body).Decode(&presentation)
+			g.Assert(err).Equal(nil)
+			g.Assert(presentation.UserID).Equal(members[0].ID)
+			g.Assert(presentation.TaskID).Equal(int64(1))
+			g.Assert(presentation.Score.Int64).Equal(int64(2))
+
+			// who presented task 1 is skipped
+			w = tape.Post("/api/v1/courses/1/groups/2/presentations/pick",
+				H{"task_id": 1, "candidate_ids": []int64{members[0].ID, members[1].ID}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			presenter := PresenterResponse{}
+			err = json.NewDecoder(w.Body).Decode(&presenter)
+			g.Assert(err).Equal(nil)
+			g.Assert(presenter.UserID).Equal(members[1].ID)
+
+			// unless nobody else is left
+			w = tape.Post("/api/v1/courses/1/groups/2/presentations/pick",
+				H{"task_id": 1, "candidate_ids": []int64{members[0].ID}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&presenter)
+			g.Assert(err).Equal(nil)
+			g.Assert(presenter.UserID).Equal(members[0].ID)
+			g.Assert(presenter.Presentations).Equal(1)
+
+			w = tape.Post("/api/v1/courses/1/groups/2/presentations/pick",
+				H{"candidate_ids": []int64{others[0].ID}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			studentJWT := tape.NewJWTRequest(members[0].ID, false)
+			_, err = tape.DB.Exec("UPDATE courses SET required_presentations = 2 WHERE id = 1")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/courses/1/presentations/own", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			count := PresentationCountResponse{}
+			err = json.NewDecoder(w.Body).Decode(&count)
+			g.Assert(err).Equal(nil)
+			g.Assert(count.Presentations).Equal(1)
+			g.Assert(count.Required).Equal(2)
+			g.Assert(count.Met).IsFalse()
+
+			w = tape.Get("/api/v1/courses/1/grades/export?format=csv&columns=email,presentations", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			records, err := csv.NewReader(w.Body).ReadAll()
+			g.Assert(err).Equal(nil)
+			for _, record := range records[1:] {
+				if record[0] == members[0].Email {
+					g.Assert(record[1]).Equal("1")
+				}
+			}
+
+			url := fmt.Sprintf("/api/v1/courses/1/groups/2/presentations/%d", presentation.ID)
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/groups/1/presentations/%d", presentation.ID), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Delete(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			presentations, err := stores.Presentation.PresentationsOfGroup(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(presentations)).Equal(0)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -188,6 +188,15 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 		return nil, err
 	}
 
+	// check-in codes are short, hence guessing them is limited per account
+	checkInLimiter, err := authenticate.NewLoginLimiter("infomark-check-ins",
+		fmt.Sprintf("%d-H", config.Authentication.CheckInsPerHour),
+		config.RedisURL())
+	if err != nil {
+		logger.WithField("module", "app").Error(err)
+		return nil, err
+	}
+
 	r := chi.NewRouter()
 	r.Use(VersionMiddleware)
 	r.Use(SecureMiddleware)
@@ -223,6 +232,8 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 
 				r.Post("/auth/token", appAPI.Auth.RefreshAccessTokenHandler)
 				r.Post("/auth/sessions", appAPI.Auth.LoginHandler)
+				r.Get("/auth/oidc/login", appAPI.Auth.OIDCLoginHandler)
+				r.Get("/auth/oidc/callback", appAPI.Auth.OIDCCallbackHandler)
 				r.Post("/auth/request_password_reset", appAPI.Auth.RequestPasswordResetHandler)
 				r.Post("/auth/update_password", appAPI.Auth.UpdatePasswordHandler)
 				r.Post("/auth/confirm_email", appAPI.Auth.ConfirmEmailHandler)
@@ -230,17 +241,20 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 				r.Get("/ping", appAPI.Common.PingHandler)
 				r.Get("/version", appAPI.Common.VersionHandler)
 				r.Get("/privacy_statement", appAPI.Common.PrivacyStatementHandler)
+				r.Get("/calendar/{calendar_token}.ics", appAPI.User.GetCalendarFeedHandler)
 			})
 
 			// protected routes
 			r.Group(func(r chi.Router) {
-				r.Use(authenticate.RequiredValidAccessClaims(sessionAuth, config))
+				r.Use(authenticate.RequiredValidAccessClaims(sessionAuth, config, appAPI.PersonalToken, appAPI.LoginSession))
 
 				r.Get("/me", appAPI.User.GetMeHandler)
 				r.Put("/me", appAPI.User.EditMeHandler)
+				r.Get("/me/calendar.ics", appAPI.User.GetMeCalendarHandler)
 
 				r.Route("/users", func(r chi.Router) {
 					r.Get("/", appAPI.User.IndexHandler)
+					r.Get("/lockouts", appAPI.LoginLockout.IndexHandler)
 
 					r.Route("/{user_id}", func(r chi.Router) {
 						r.Use(appAPI.User.Context)
@@ -250,6 +264,9 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 						r.Put("/", appAPI.User.EditHandler)
 						r.Delete("/", appAPI.User.DeleteHandler)
 						r.Post("/emails", appAPI.User.SendEmailHandler)
+						r.Get("/lockout", appAPI.LoginLockout.GetHandler)
+						r.Delete("/lockout", appAPI.LoginLockout.DeleteHandler)
+						r.Post("/impersonate", appAPI.Auth.ImpersonateHandler)
 					})
 					r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/find", appAPI.User.Find)
 				})
@@ -275,12 +292,16 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 								r.Post("/emails", appAPI.Course.SendEmailHandler)
 								r.Put("/", appAPI.Course.EditHandler)
 								r.Delete("/", appAPI.Course.DeleteHandler)
+								r.Post("/clone", appAPI.Course.CloneHandler)
+								r.Post("/enrollments/import", appAPI.Course.ImportEnrollmentsHandler)
 							})
 
 							r.Get("/enrollments", appAPI.Course.IndexEnrollmentsHandler)
 							r.Delete("/enrollments", appAPI.Course.DisenrollHandler)
 							r.Get("/points", appAPI.Course.PointsHandler)
 							r.Get("/bids", appAPI.Course.BidsHandler)
+							r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/admissions", appAPI.Course.AdmissionsHandler)
+							r.Get("/admissions/own", appAPI.Course.GetMyAdmissionHandler)
 
 							r.Route("/enrollments/{user_id}", func(r chi.Router) {
 								r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
@@ -313,33 +334,78 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 										r.Put("/", appAPI.Sheet.EditHandler)
 										r.Delete("/", appAPI.Sheet.DeleteHandler)
 										r.Post("/file", appAPI.Sheet.ChangeFileHandler)
+										r.Get("/statistics", appAPI.Sheet.StatisticsHandler)
 									})
 								})
 							})
 
 							r.Route("/groups", func(r chi.Router) {
 								r.Get("/own", appAPI.Group.GetMineHandler)
+								r.Delete("/own", appAPI.Group.LeaveHandler)
+								r.Get("/waitlist", appAPI.Group.GetWaitlistHandler)
+								r.Get("/swaps", appAPI.Group.IndexSwapsHandler)
+								r.Post("/swaps", appAPI.Group.RequestSwapHandler)
+								r.Delete("/swaps", appAPI.Group.DeleteSwapHandler)
 								r.Get("/", appAPI.Group.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Group.CreateHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/assignments", appAPI.Group.AssignHandler)
 
 								r.Route("/{group_id}", func(r chi.Router) {
 									r.Use(appAPI.Group.Context)
 
 									r.Post("/bids", appAPI.Group.ChangeBidHandler)
+									r.Post("/join", appAPI.Group.JoinHandler)
 									r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/emails", appAPI.Group.SendEmailHandler)
 									r.Get("/enrollments", appAPI.Group.IndexEnrollmentsHandler)
 									r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/enrollments", appAPI.Group.EditGroupEnrollmentHandler)
 									r.Get("/", appAPI.Group.GetHandler)
+									r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/tutors", appAPI.Group.IndexTutorsHandler)
 
 									r.Route("/", func(r chi.Router) {
 										r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
 
 										r.Put("/", appAPI.Group.EditHandler)
 										r.Delete("/", appAPI.Group.DeleteHandler)
+										r.Post("/tutors", appAPI.Group.ChangeTutorHandler)
+										r.With(appAPI.User.Context).Delete("/tutors/{user_id}", appAPI.Group.DeleteTutorHandler)
+									})
+
+									r.Route("/sessions", func(r chi.Router) {
+										r.Use(authorize.RequiresAtLeastCourseRole(authorize.TUTOR))
+
+										r.Get("/", appAPI.Attendance.IndexSessionsHandler)
+										r.Post("/", appAPI.Attendance.CreateSessionHandler)
+
+										r.Route("/{session_id}", func(r chi.Router) {
+											r.Use(appAPI.Attendance.Context)
+
+											r.Get("/", appAPI.Attendance.GetSessionHandler)
+											r.Put("/", appAPI.Attendance.EditSessionHandler)
+											r.Delete("/", appAPI.Attendance.DeleteSessionHandler)
+											r.Put("/attendances", appAPI.Attendance.EditAttendancesHandler)
+											r.Post("/code", appAPI.Attendance.CreateCodeHandler)
+										})
+									})
+
+									r.Route("/presentations", func(r chi.Router) {
+										r.Use(authorize.RequiresAtLeastCourseRole(authorize.TUTOR))
+
+										r.Get("/", appAPI.Presentation.IndexHandler)
+										r.Post("/", appAPI.Presentation.CreateHandler)
+										r.Post("/pick", appAPI.Presentation.PickHandler)
+										r.With(appAPI.Presentation.Context).Delete("/{presentation_id}", appAPI.Presentation.DeleteHandler)
 									})
 								})
 							})
 
+							r.Route("/attendances", func(r chi.Router) {
+								r.With(authenticate.RateLimitPerAccountMiddleware(checkInLimiter)).Post("/", appAPI.Attendance.CheckInHandler)
+								r.Get("/own", appAPI.Attendance.GetMineHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/", appAPI.Attendance.IndexSummariesHandler)
+							})
+
+							r.Get("/presentations/own", appAPI.Presentation.GetMineHandler)
+
 							r.Route("/exams", func(r chi.Router) {
 								r.Get("/", appAPI.Exam.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Exam.CreateHandler)
@@ -368,7 +434,14 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 							r.Route("/grades", func(r chi.Router) {
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/", appAPI.Grade.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/summary", appAPI.Grade.IndexSummaryHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/export", appAPI.Grade.ExportHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/statistics", appAPI.Grade.StatisticsHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/import", appAPI.Grade.ImportHandler)
 								r.Get("/missing", appAPI.Grade.IndexMissingHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/pool", appAPI.Grade.IndexPoolHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/pool/claim", appAPI.Grade.ClaimHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/assign", appAPI.Grade.AssignHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/workload", appAPI.Grade.IndexWorkloadHandler)
 
 								r.Route("/{grade_id}", func(r chi.Router) {
 									r.Use(appAPI.Grade.Context)
@@ -455,6 +528,19 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 				r.Post("/account/avatar", appAPI.Account.ChangeAvatarHandler)
 				r.Delete("/account/avatar", appAPI.Account.DeleteAvatarHandler)
 				r.Patch("/account", appAPI.Account.EditHandler)
+				r.Get("/account/two_factor", appAPI.Auth.GetTwoFactorHandler)
+				r.Post("/account/two_factor", appAPI.Auth.EnrollTwoFactorHandler)
+				r.Post("/account/two_factor/confirm", appAPI.Auth.ConfirmTwoFactorHandler)
+				r.Post("/account/two_factor/disable", appAPI.Auth.DisableTwoFactorHandler)
+				r.Post("/account/two_factor/recovery_codes", appAPI.Auth.RenewRecoveryCodesHandler)
+				r.Post("/account/calendar_token", appAPI.User.CreateCalendarTokenHandler)
+				r.Delete("/account/calendar_token", appAPI.User.DeleteCalendarTokenHandler)
+				r.Get("/account/tokens", appAPI.PersonalToken.IndexHandler)
+				r.Post("/account/tokens", appAPI.PersonalToken.CreateHandler)
+				r.Delete("/account/tokens/{token_id}", appAPI.PersonalToken.DeleteHandler)
+				r.Get("/account/sessions", appAPI.LoginSession.IndexHandler)
+				r.Delete("/account/sessions", appAPI.LoginSession.DeleteOthersHandler)
+				r.Delete("/account/sessions/{session_id}", appAPI.LoginSession.DeleteHandler)
 				r.Delete("/auth/sessions", appAPI.Auth.LogoutHandler)
 
 			})
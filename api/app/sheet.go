@@ -105,9 +105,11 @@ func (rs *SheetResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sheet := &model.Sheet{
-		Name:      data.Name,
-		PublishAt: data.PublishAt,
-		DueAt:     data.DueAt,
+		Name:               data.Name,
+		PublishAt:          data.PublishAt,
+		DueAt:              data.DueAt,
+		Anonymous:          data.Anonymous,
+		RequiredPercentage: data.RequiredPercentage,
 	}
 
 	// create Sheet entry in database
@@ -178,6 +180,8 @@ func (rs *SheetResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	sheet.Name = data.Name
 	sheet.PublishAt = data.PublishAt
 	sheet.DueAt = data.DueAt
+	sheet.Anonymous = data.Anonymous
+	sheet.RequiredPercentage = data.RequiredPercentage
 
 	// update database entry
 	if err := rs.Stores.Sheet.Update(sheet); err != nil {
@@ -262,6 +266,36 @@ func (rs *SheetResource) ChangeFileHandler(w http.ResponseWriter, r *http.Reques
 	render.Status(r, http.StatusOK)
 }
 
+// StatisticsHandler is public endpoint for
+// URL: /courses/{course_id}/sheets/{sheet_id}/statistics
+// URLPARAM: course_id,integer
+// URLPARAM: sheet_id,integer
+// METHOD: get
+// TAG: sheets
+// RESPONSE: 200,GradeStatisticsResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  aggregated statistics of all grades of a sheet per task, tutor and group
+func (rs *SheetResource) StatisticsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
+
+	entries, err := rs.Stores.Grade.GetStatisticEntries(course.ID, sheet.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err := render.Render(w, r, newGradeStatisticsResponse(entries, course.ID, sheet.ID)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
 // PointsHandler is public endpoint for
 // URL: /courses/{course_id}/sheets/{sheet_id}/points
 // URLPARAM: course_id,integer
@@ -32,6 +32,9 @@ type SheetRequest struct {
 	Name      string    `json:"name" example:"Blatt 42"`
 	PublishAt time.Time `json:"publish_at" example:"auto"`
 	DueAt     time.Time `json:"due_at" example:"auto"`
+	Anonymous bool      `json:"anonymous" example:"false"`
+	// RequiredPercentage of the points of this sheet is required for admission.
+	RequiredPercentage int `json:"required_percentage" example:"30" minval:"0" maxval:"100" required:"false"`
 }
 
 // Bind preprocesses a SheetRequest.
@@ -60,6 +63,11 @@ func (body *SheetRequest) Validate() error {
 			&body.Name,
 			validation.Required,
 		),
+		validation.Field(
+			&body.RequiredPercentage,
+			validation.Min(0),
+			validation.Max(100),
+		),
 	)
 
 	if err == nil {
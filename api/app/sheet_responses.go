@@ -37,6 +37,9 @@ type SheetResponse struct {
 	FileURL   string    `json:"file_url" example:"/api/v1/sheets/13/file"`
 	PublishAt time.Time `json:"publish_at" example:"auto"`
 	DueAt     time.Time `json:"due_at" example:"auto"`
+	Anonymous bool      `json:"anonymous" example:"false"`
+	// RequiredPercentage of the points of this sheet is required for admission.
+	RequiredPercentage int `json:"required_percentage" example:"30"`
 }
 
 // Render post-processes a SheetResponse.
@@ -47,11 +50,13 @@ func (body *SheetResponse) Render(w http.ResponseWriter, r *http.Request) error
 // newSheetResponse creates a response from a Sheet model.
 func (rs *SheetResource) newSheetResponse(p *model.Sheet) *SheetResponse {
 	return &SheetResponse{
-		ID:        p.ID,
-		Name:      p.Name,
-		PublishAt: p.PublishAt,
-		DueAt:     p.DueAt,
-		FileURL:   fmt.Sprintf("/api/v1/sheets/%s/file", strconv.FormatInt(p.ID, 10)),
+		ID:                 p.ID,
+		Name:               p.Name,
+		PublishAt:          p.PublishAt,
+		DueAt:              p.DueAt,
+		Anonymous:          p.Anonymous,
+		RequiredPercentage: p.RequiredPercentage,
+		FileURL:            fmt.Sprintf("/api/v1/sheets/%s/file", strconv.FormatInt(p.ID, 10)),
 	}
 }
 
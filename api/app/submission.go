@@ -378,21 +378,17 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 	}
 
 	// enqueue file into testing queue
-	// By definition user with id 1 is the system itself with root access
+	// Each job gets its own token which only allows the requests of this job.
 	tokenManager := rs.TokenAuth
-	if err != nil {
-		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
-		return
-	}
-	accessToken, err := tokenManager.CreateAccessJWT(
-		authenticate.NewAccessClaims(1, true))
-	if err != nil {
-		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
-		return
-	}
 
 	if task.PublicDockerImage.Valid && helper.NewPublicTestFileHandle(task.ID).Exists() {
 		// enqueue public test
+		accessToken, err := tokenManager.CreateJobJWT(shared.NewSubmissionJobScope(
+			course.ID, task.ID, submission.ID, grade.ID, "public"))
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
 
 		request := shared.NewSubmissionAMQPWorkerRequest(
 			course.ID, task.ID, submission.ID, grade.ID,
@@ -420,6 +416,12 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 
 	if task.PrivateDockerImage.Valid && helper.NewPrivateTestFileHandle(task.ID).Exists() {
 		// enqueue private test
+		accessToken, err := tokenManager.CreateJobJWT(shared.NewSubmissionJobScope(
+			course.ID, task.ID, submission.ID, grade.ID, "private"))
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
 
 		request := shared.NewSubmissionAMQPWorkerRequest(
 			course.ID, task.ID, submission.ID, grade.ID,
@@ -466,6 +468,7 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 // SUMMARY:  Query submissions in a course
 func (rs *SubmissionResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
 	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
 	filterUserID := helper.Int64FromURL(r, "user_id", 0)
@@ -478,8 +481,20 @@ func (rs *SubmissionResource) IndexHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// filtering by a student would reveal who is behind a pseudonym
+	visibleSubmissions := []model.Submission{}
+	for k := range submissions {
+		if HidesIdentity(givenRole, submissions[k].SheetAnonymous) {
+			if filterUserID != 0 {
+				continue
+			}
+			submissions[k].UserID = 0
+		}
+		visibleSubmissions = append(visibleSubmissions, submissions[k])
+	}
+
 	// render JSON response
-	if err = render.RenderList(w, r, newSubmissionListResponse(submissions, course.ID)); err != nil {
+	if err = render.RenderList(w, r, newSubmissionListResponse(visibleSubmissions, course.ID)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
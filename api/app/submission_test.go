@@ -28,6 +28,8 @@ import (
 
 	"github.com/franela/goblin"
 	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/api/shared"
+	"github.com/infomark-org/infomark/auth/authenticate"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
 )
@@ -145,6 +147,44 @@ func TestSubmission(t *testing.T) {
 
 		})
 
+		g.It("Job tokens only allow the requests of their job", func() {
+			submission, err := stores.Submission.Get(1)
+			g.Assert(err).Equal(nil)
+			grade, err := stores.Grade.GetForSubmission(submission.ID)
+			g.Assert(err).Equal(nil)
+			course, err := stores.Task.IdentifyCourseOfTask(submission.TaskID)
+			g.Assert(err).Equal(nil)
+
+			jobJWT := JWTRequest{
+				Claims: authenticate.NewJobAccessClaims(shared.NewSubmissionJobScope(
+					course.ID, submission.TaskID, submission.ID, grade.ID, "public")),
+				TokenAuth: tape.TokenAuth,
+			}
+
+			// the result of the own grade
+			w := tape.Post(fmt.Sprintf("/api/v1/courses/%d/grades/%d/public_result", course.ID, grade.ID),
+				helper.H{"log": "some new logs", "status": 2}, jobJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// but not the private result, other grades or anything else
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/%d/grades/%d/private_result", course.ID, grade.ID),
+				helper.H{"log": "some new logs", "status": 2}, jobJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/%d/grades/%d/public_result", course.ID, grade.ID+1),
+				helper.H{"log": "some new logs", "status": 2}, jobJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/%d/tasks/%d/private_file", course.ID, submission.TaskID), jobJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/me", jobJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/users", jobJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
 		g.It("Students cannot upload solution (create) since too late", func() {
 
 			deadlineAt := NowUTC().Add(-2 * time.Hour)
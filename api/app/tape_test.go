@@ -20,6 +20,7 @@
 package app
 
 import (
+	"context"
 	"net/http"
 
 	txdb "github.com/DATA-DOG/go-txdb"
@@ -29,6 +30,7 @@ import (
 	otape "github.com/infomark-org/infomark/tape"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq" // need for Postgres
+	redis "github.com/redis/go-redis/v9"
 )
 
 type JWTRequest struct {
@@ -82,6 +84,20 @@ func NewTape() *Tape {
 
 func (t *Tape) AfterEach() {
 	t.DB.Close()
+	resetLoginLimiter()
+}
+
+// resetLoginLimiter forgets the requests to the rate limited endpoints as all
+// requests of the tape come from the same address.
+func resetLoginLimiter() {
+	option, err := redis.ParseURL(configuration.Configuration.Server.RedisURL())
+	if err != nil {
+		panic(err)
+	}
+	client := redis.NewClient(option)
+	defer client.Close()
+
+	client.Set(context.Background(), "infomark-logins:1.2.3.4-infomark-logins", "0", 0)
 }
 
 // TransactionDB creates a sql-driver which seemlessly supports transactions.
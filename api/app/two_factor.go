@@ -0,0 +1,155 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// RecoveryCodeCount is the number of recovery codes generated at once.
+const RecoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorCodeRequired is returned when the account uses two-factor
+	// authentication, but no code was given.
+	ErrTwoFactorCodeRequired = errors.New("two-factor code required")
+	// ErrTwoFactorCodeInvalid is returned for wrong or already used codes.
+	ErrTwoFactorCodeInvalid = errors.New("two-factor code is wrong")
+)
+
+// VerifyTwoFactor checks the second factor of a login. The code is either the
+// current code of the authenticator app or an unused recovery code, which is
+// consumed. Accounts without two-factor authentication pass.
+func VerifyTwoFactor(stores *Stores, user *model.User, code string) error {
+	if !user.TOTPEnabled {
+		return nil
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTwoFactorCodeRequired
+	}
+
+	// codes are consumed by conditional writes, such that concurrent logins
+	// cannot use the same code twice
+	if step, ok := auth.ValidateTOTP(user.TOTPSecret.String, code, time.Now(), user.TOTPLastStep); ok {
+		used, err := stores.User.UseTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrTwoFactorCodeInvalid
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	recoveryCodes, err := stores.User.GetRecoveryCodes(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, recoveryCode := range recoveryCodes {
+		if auth.CheckPasswordHash(strings.ToLower(code), recoveryCode.EncryptedCode) {
+			used, err := stores.User.UseRecoveryCode(recoveryCode.ID)
+			if err != nil {
+				return err
+			}
+			if !used {
+				return ErrTwoFactorCodeInvalid
+			}
+			return nil
+		}
+	}
+
+	return ErrTwoFactorCodeInvalid
+}
+
+// TwoFactorRequired reports whether the configuration forces the account to
+// use two-factor authentication, which applies to root users and admins of any
+// course.
+func TwoFactorRequired(stores *Stores, user *model.User) (bool, error) {
+	if !configuration.Configuration.Server.Authentication.TwoFactor.RequiredForStaff {
+		return false, nil
+	}
+	if user.Root {
+		return true, nil
+	}
+
+	enrollments, err := stores.User.GetEnrollments(user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, enrollment := range enrollments {
+		if authorize.CourseRole(enrollment.Role) == authorize.ADMIN {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TwoFactorSetupRequired reports whether an account has to set up two-factor
+// authentication before it can use the api.
+func TwoFactorSetupRequired(stores *Stores, user *model.User) (bool, error) {
+	if user.TOTPEnabled {
+		return false, nil
+	}
+	return TwoFactorRequired(stores, user)
+}
+
+// NewRecoveryCodes replaces the recovery codes of a user and returns the new
+// codes in plain text, which are not stored.
+func NewRecoveryCodes(stores *Stores, userID int64) ([]string, error) {
+	codes := []string{}
+	encryptedCodes := []string{}
+	for k := 0; k < RecoveryCodeCount; k++ {
+		code := auth.GenerateRecoveryCode()
+		encryptedCode, err := HashPassword(code)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		encryptedCodes = append(encryptedCodes, encryptedCode)
+	}
+
+	if err := stores.User.ReplaceRecoveryCodes(userID, encryptedCodes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTwoFactor disables two-factor authentication and removes all recovery
+// codes of a user.
+func ResetTwoFactor(stores *Stores, user *model.User) error {
+	user.TOTPSecret = null.String{}
+	user.TOTPEnabled = false
+	user.TOTPLastStep = 0
+	if err := stores.User.Update(user); err != nil {
+		return err
+	}
+	return stores.User.ReplaceRecoveryCodes(user.ID, []string{})
+}
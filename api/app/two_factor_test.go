@@ -0,0 +1,265 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestTwoFactor(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var w *httptest.ResponseRecorder
+	var stores *Stores
+
+	twoFactorConfig := &configuration.Configuration.Server.Authentication.TwoFactor
+
+	// enable sets up two-factor authentication for a user and returns the
+	// secret and the recovery codes.
+	enable := func(loginID int64) (string, []string) {
+		w := tape.Post("/api/v1/account/two_factor", H{}, tape.NewJWTRequest(loginID, false))
+		g.Assert(w.Code).Equal(http.StatusCreated)
+		enrollment := &TwoFactorEnrollmentResponse{}
+		g.Assert(json.NewDecoder(w.Body).Decode(enrollment)).Equal(nil)
+
+		code, _ := auth.TOTPCode(enrollment.Secret, auth.TOTPStep(time.Now()))
+		w = tape.Post("/api/v1/account/two_factor/confirm", H{"code": code}, tape.NewJWTRequest(loginID, false))
+		g.Assert(w.Code).Equal(http.StatusOK)
+		recovery := &RecoveryCodesResponse{}
+		g.Assert(json.NewDecoder(w.Body).Decode(recovery)).Equal(nil)
+
+		return enrollment.Secret, recovery.RecoveryCodes
+	}
+
+	// nextCode is a code which has not been used yet.
+	nextCode := func(secret string) string {
+		code, _ := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+1)
+		return code
+	}
+
+	login := func(password string, code string) *httptest.ResponseRecorder {
+		return tape.Post("/api/v1/auth/sessions", H{
+			"email":           "test@uni-tuebingen.de",
+			"plain_password":  password,
+			"two_factor_code": code,
+		})
+	}
+
+	g.Describe("TwoFactor", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			tape.Router, _ = New(tape.DB, EmptyHandler(), false)
+			stores = NewStores(tape.DB)
+		})
+
+		g.AfterEach(func() {
+			twoFactorConfig.RequiredForStaff = false
+		})
+
+		g.It("Should be set up with a code of the authenticator app", func() {
+			w = tape.Get("/api/v1/account/two_factor", tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Body.String()).Equal(`{"enabled":false,"required":false,"recovery_codes_left":0}` + "\n")
+
+			w = tape.Post("/api/v1/account/two_factor", H{}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			enrollment := &TwoFactorEnrollmentResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(enrollment)).Equal(nil)
+
+			w = tape.Post("/api/v1/account/two_factor/confirm", H{"code": "000000"}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			code, _ := auth.TOTPCode(enrollment.Secret, auth.TOTPStep(time.Now()))
+			w = tape.Post("/api/v1/account/two_factor/confirm", H{"code": code}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusOK)
+			recovery := &RecoveryCodesResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(recovery)).Equal(nil)
+			g.Assert(len(recovery.RecoveryCodes)).Equal(RecoveryCodeCount)
+
+			user, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(user.TOTPEnabled).Equal(true)
+
+			w = tape.Post("/api/v1/account/two_factor", H{}, tape.NewJWTRequest(1, true))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should require the code at login", func() {
+			secret, _ := enable(1)
+
+			w = login("test", "")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = login("test", "000000")
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = login("wrong", nextCode(secret))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			code := nextCode(secret)
+			w = login("test", code)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// codes cannot be replayed
+			w = login("test", code)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should require the code when requesting tokens", func() {
+			secret, _ := enable(1)
+
+			w = tape.Post("/api/v1/auth/token", H{
+				"email":          "test@uni-tuebingen.de",
+				"plain_password": "test",
+			})
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/auth/token", H{
+				"email":           "test@uni-tuebingen.de",
+				"plain_password":  "test",
+				"two_factor_code": nextCode(secret),
+			})
+			g.Assert(w.Code).Equal(http.StatusOK)
+		})
+
+		g.It("Should accept each recovery code once", func() {
+			_, codes := enable(1)
+
+			w = login("test", codes[3])
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = login("test", codes[3])
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			recoveryCodes, err := stores.User.GetRecoveryCodes(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(recoveryCodes)).Equal(RecoveryCodeCount - 1)
+		})
+
+		g.It("Should accept a code only once for concurrent logins", func() {
+			secret, codes := enable(1)
+
+			// both logins read the account before either consumes the code
+			first, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+			second, err := stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+
+			code := nextCode(secret)
+			g.Assert(VerifyTwoFactor(stores, first, code)).Equal(nil)
+			g.Assert(VerifyTwoFactor(stores, second, code)).Equal(ErrTwoFactorCodeInvalid)
+
+			g.Assert(VerifyTwoFactor(stores, first, codes[0])).Equal(nil)
+			g.Assert(VerifyTwoFactor(stores, second, codes[0])).Equal(ErrTwoFactorCodeInvalid)
+		})
+
+		g.It("Should renew recovery codes and disable", func() {
+			secret, codes := enable(112)
+
+			w = tape.Post("/api/v1/account/two_factor/recovery_codes", H{"code": nextCode(secret)}, tape.NewJWTRequest(112, false))
+			g.Assert(w.Code).Equal(http.StatusOK)
+			recovery := &RecoveryCodesResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(recovery)).Equal(nil)
+
+			// old recovery codes are invalid
+			w = tape.Post("/api/v1/account/two_factor/disable", H{"code": codes[0]}, tape.NewJWTRequest(112, false))
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/account/two_factor/disable", H{"code": recovery.RecoveryCodes[0]}, tape.NewJWTRequest(112, false))
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			user, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(user.TOTPEnabled).Equal(false)
+			g.Assert(user.TOTPSecret.Valid).Equal(false)
+
+			recoveryCodes, err := stores.User.GetRecoveryCodes(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(recoveryCodes)).Equal(0)
+		})
+
+		g.It("Should restrict staff without two-factor authentication to set it up", func() {
+			twoFactorConfig.RequiredForStaff = true
+
+			w = login("test", "")
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Body.String()).Equal(`{"root":true,"setup_two_factor":true}` + "\n")
+
+			cookies := CookieRequest{Cookies: map[string]*http.Cookie{}}
+			cookies.Store(w)
+
+			w = tape.Get("/api/v1/courses", cookies)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/account/two_factor", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Body.String()).Equal(`{"enabled":false,"required":true,"recovery_codes_left":0}` + "\n")
+
+			w = tape.Post("/api/v1/account/two_factor", H{}, cookies)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			enrollment := &TwoFactorEnrollmentResponse{}
+			g.Assert(json.NewDecoder(w.Body).Decode(enrollment)).Equal(nil)
+
+			code, _ := auth.TOTPCode(enrollment.Secret, auth.TOTPStep(time.Now()))
+			w = tape.Post("/api/v1/account/two_factor/confirm", H{"code": code}, cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			cookies.Store(w)
+
+			w = tape.Get("/api/v1/courses", cookies)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// required accounts cannot disable it
+			w = tape.Post("/api/v1/account/two_factor/disable", H{"code": nextCode(enrollment.Secret)}, cookies)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should not restrict students", func() {
+			twoFactorConfig.RequiredForStaff = true
+
+			user, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+
+			required, err := TwoFactorSetupRequired(stores, user)
+			g.Assert(err).Equal(nil)
+			g.Assert(required).Equal(false)
+
+			user, err = stores.User.Get(1)
+			g.Assert(err).Equal(nil)
+
+			required, err = TwoFactorSetupRequired(stores, user)
+			g.Assert(err).Equal(nil)
+			g.Assert(required).Equal(true)
+		})
+	})
+}
@@ -25,16 +25,19 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/auth"
 	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // UserResource specifies user management handler.
@@ -107,6 +110,156 @@ func (rs *UserResource) GetMeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetMeCalendarHandler is public endpoint for
+// URL: /me/calendar.ics
+// METHOD: get
+// TAG: users
+// TAG: groups
+// RESPONSE: 200,CalendarFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// SUMMARY:  Get the weekly meetings of all own groups as iCalendar feed
+// DESCRIPTION:
+// Contains the meetings of the groups the identity is enrolled in as a student
+// and of the groups the identity is tutoring.
+func (rs *UserResource) GetMeCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	rs.renderCalendar(w, r, accessClaims.LoginID)
+}
+
+// GetCalendarFeedHandler is public endpoint for
+// URL: /calendar/{calendar_token}.ics
+// URLPARAM: calendar_token,string
+// METHOD: get
+// TAG: users
+// TAG: groups
+// RESPONSE: 200,CalendarFile
+// RESPONSE: 404,NotFound
+// SUMMARY:  Get the iCalendar feed of a user by the secret feed token
+// DESCRIPTION:
+// Calendar clients cannot authenticate, so the feed is identified by a secret token
+// in the url instead. The content is the same as of /me/calendar.ics.
+func (rs *UserResource) GetCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := rs.Stores.User.FindByCalendarTokenHash(hashToken(chi.URLParam(r, "calendar_token")))
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+	rs.renderCalendar(w, r, user.ID)
+}
+
+// CreateCalendarTokenHandler is public endpoint for
+// URL: /account/calendar_token
+// METHOD: post
+// TAG: account
+// RESPONSE: 201,CalendarTokenResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create the secret url of the own calendar feed
+// DESCRIPTION:
+// The url is part of the response only once. Creating a new one revokes the previous url.
+func (rs *UserResource) CreateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	token := auth.GenerateToken(24)
+	user.CalendarTokenHash.SetValid(hashToken(token))
+	if err := rs.Stores.User.Update(user); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	resp := &CalendarTokenResponse{
+		URL: fmt.Sprintf("%s/api/v1/calendar/%s.ics", configuration.Configuration.Server.ExternalURL(), token),
+	}
+	if err := render.Render(w, r, resp); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// DeleteCalendarTokenHandler is public endpoint for
+// URL: /account/calendar_token
+// METHOD: delete
+// TAG: account
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  revoke the secret url of the own calendar feed
+func (rs *UserResource) DeleteCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	user.CalendarTokenHash = null.String{}
+	if err := rs.Stores.User.Update(user); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// renderCalendar writes the meetings of the groups a user is enrolled in as a
+// student and of the groups the user is tutoring.
+func (rs *UserResource) renderCalendar(w http.ResponseWriter, r *http.Request, userID int64) {
+	enrollments, err := rs.Stores.User.GetEnrollments(userID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	events := []helper.CalendarEvent{}
+	for _, enrollment := range enrollments {
+		course, err := rs.Stores.Course.Get(enrollment.CourseID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		var groups []model.GroupWithTutor
+		if enrollment.Role == int64(authorize.STUDENT) {
+			groups, err = rs.Stores.Group.GetInCourseWithUser(userID, course.ID)
+		} else {
+			groups, err = rs.Stores.Group.GetOfTutor(userID, course.ID)
+		}
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		for k := range groups {
+			slots, err := rs.Stores.Group.GetSlots(groups[k].ID)
+			if err != nil {
+				render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+				return
+			}
+			events = append(events, groupCalendarEvents(course, &groups[k], slots)...)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"calendar.ics\"")
+
+	if err := helper.WriteCalendar(w, "InfoMark", events, time.Now()); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
 // Find is public endpoint for
 // URL: /users/find
 // QUERYPARAM: query,string
@@ -272,8 +425,13 @@ func (rs *UserResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 
 	// all identities allowed to this endpoint are allowed to change the password
 	if data.PlainPassword != "" {
+		if err := ValidateNewPassword(data.PlainPassword, user.FirstName, user.LastName, user.Email); err != nil {
+			render.Render(w, r, ErrBadRequestWithDetails(err))
+			return
+		}
+
 		var err error
-		user.EncryptedPassword, err = auth.HashPassword(data.PlainPassword)
+		user.EncryptedPassword, err = HashPassword(data.PlainPassword)
 		if err != nil {
 			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 			return
@@ -286,6 +444,14 @@ func (rs *UserResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// whoever knew the old password is logged out, tokens are revoked
+	if data.PlainPassword != "" {
+		if err := RevokeCredentials(rs.Stores, user.ID, 0); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
 	render.Status(r, http.StatusNoContent)
 }
 
@@ -73,3 +73,14 @@ func (u *UserResponse) Render(w http.ResponseWriter, r *http.Request) error {
 	// nothing to hide
 	return nil
 }
+
+// CalendarTokenResponse contains the secret url of the calendar feed of a
+// user.
+type CalendarTokenResponse struct {
+	URL string `json:"url" example:"https://infomark.org/api/v1/calendar/0a1b...9f.ics"`
+}
+
+// Render post-processes a CalendarTokenResponse.
+func (body *CalendarTokenResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
@@ -0,0 +1,257 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package assignment distributes students to groups such that the sum of
+// their bids is maximal while every group respects a lower and an upper
+// bound of members.
+//
+// The problem is solved as a min-cost flow:
+//
+//	source -> student (capacity 1, cost 0)
+//	student -> group  (capacity 1, cost maxBid - bid)
+//	group -> sink     (capacity min, cost 0) and (capacity max - min, cost big)
+//
+// As each of the n students has to be assigned, every feasible flow has value
+// n. The large cost of the second group edge makes sure the first min members
+// of each group are preferred, which enforces the lower bound whenever the
+// problem is feasible at all. The constant offset does not change the optimal
+// assignment.
+package assignment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInfeasible is returned when no assignment satisfies the bounds.
+var ErrInfeasible = errors.New("assignment is infeasible")
+
+// Problem describes all students, groups and bids of a course.
+type Problem struct {
+	UserIDs  []int64
+	GroupIDs []int64
+	// Bids maps a user to the bids per group. Larger bids are better.
+	Bids map[int64]map[int64]int
+	// DefaultBid is used for groups a student did not bid for.
+	DefaultBid  int
+	MinPerGroup int
+	MaxPerGroup int
+	// Capacities optionally lowers MaxPerGroup for single groups.
+	Capacities map[int64]int
+}
+
+// Assignment places a single student into a group.
+type Assignment struct {
+	UserID  int64
+	GroupID int64
+	Bid     int
+}
+
+// Bid returns the bid of a user for a group.
+func (p *Problem) Bid(userID int64, groupID int64) int {
+	if bids, ok := p.Bids[userID]; ok {
+		if bid, ok := bids[groupID]; ok {
+			return bid
+		}
+	}
+	return p.DefaultBid
+}
+
+// Max returns the maximal number of students in a group.
+func (p *Problem) Max(groupID int64) int {
+	if capacity, ok := p.Capacities[groupID]; ok && capacity < p.MaxPerGroup {
+		return capacity
+	}
+	return p.MaxPerGroup
+}
+
+// Validate checks whether there is any assignment satisfying the bounds.
+func (p *Problem) Validate() error {
+	if len(p.GroupIDs) == 0 {
+		return fmt.Errorf("%w: there are no groups", ErrInfeasible)
+	}
+	if p.MinPerGroup < 0 {
+		return fmt.Errorf("%w: min_per_group must not be negative", ErrInfeasible)
+	}
+	if p.MinPerGroup > p.MaxPerGroup {
+		return fmt.Errorf("%w: min_per_group %d > max_per_group %d", ErrInfeasible, p.MinPerGroup, p.MaxPerGroup)
+	}
+
+	n := len(p.UserIDs)
+	if n < len(p.GroupIDs)*p.MinPerGroup {
+		return fmt.Errorf("%w: %d students cannot fill %d groups with at least %d members",
+			ErrInfeasible, n, len(p.GroupIDs), p.MinPerGroup)
+	}
+	seats := 0
+	for _, groupID := range p.GroupIDs {
+		if p.Max(groupID) < p.MinPerGroup {
+			return fmt.Errorf("%w: group %d has a capacity of %d < min_per_group %d",
+				ErrInfeasible, groupID, p.Max(groupID), p.MinPerGroup)
+		}
+		seats += p.Max(groupID)
+	}
+	if n > seats {
+		return fmt.Errorf("%w: %d students do not fit into %d groups with %d seats",
+			ErrInfeasible, n, len(p.GroupIDs), seats)
+	}
+	return nil
+}
+
+type edge struct {
+	to       int
+	capacity int
+	cost     int
+}
+
+type network struct {
+	edges []edge
+	// adjacency lists hold indices into edges, edge i^1 is the reverse of i
+	adjacency [][]int
+}
+
+func newNetwork(nodes int) *network {
+	return &network{adjacency: make([][]int, nodes)}
+}
+
+func (nw *network) addEdge(from int, to int, capacity int, cost int) int {
+	nw.adjacency[from] = append(nw.adjacency[from], len(nw.edges))
+	nw.edges = append(nw.edges, edge{to: to, capacity: capacity, cost: cost})
+	nw.adjacency[to] = append(nw.adjacency[to], len(nw.edges))
+	nw.edges = append(nw.edges, edge{to: from, capacity: 0, cost: -cost})
+	return len(nw.edges) - 2
+}
+
+// shortestPath runs Bellman-Ford with a queue (SPFA) on the residual network
+// and returns for each node the edge used to reach it.
+func (nw *network) shortestPath(source int, sink int) ([]int, bool) {
+	const infinity = int(^uint(0) >> 2)
+
+	nodes := len(nw.adjacency)
+	distance := make([]int, nodes)
+	via := make([]int, nodes)
+	queued := make([]bool, nodes)
+	for k := range distance {
+		distance[k] = infinity
+		via[k] = -1
+	}
+
+	distance[source] = 0
+	queue := []int{source}
+	queued[source] = true
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		queued[node] = false
+
+		for _, e := range nw.adjacency[node] {
+			current := nw.edges[e]
+			if current.capacity <= 0 {
+				continue
+			}
+			if d := distance[node] + current.cost; d < distance[current.to] {
+				distance[current.to] = d
+				via[current.to] = e
+				if !queued[current.to] {
+					queue = append(queue, current.to)
+					queued[current.to] = true
+				}
+			}
+		}
+	}
+
+	return via, distance[sink] != infinity
+}
+
+// Solve computes an assignment of all students maximizing the sum of bids.
+// The result is sorted by user id.
+func Solve(p *Problem) ([]Assignment, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	userIDs := append([]int64{}, p.UserIDs...)
+	groupIDs := append([]int64{}, p.GroupIDs...)
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	sort.Slice(groupIDs, func(i, j int) bool { return groupIDs[i] < groupIDs[j] })
+
+	maxBid := p.DefaultBid
+	for _, userID := range userIDs {
+		for _, groupID := range groupIDs {
+			if bid := p.Bid(userID, groupID); bid > maxBid {
+				maxBid = bid
+			}
+		}
+	}
+
+	n := len(userIDs)
+	m := len(groupIDs)
+	source := n + m
+	sink := source + 1
+	big := (n + 1) * (maxBid + 1)
+
+	nw := newNetwork(n + m + 2)
+
+	studentEdges := make([][]int, n)
+	for i, userID := range userIDs {
+		nw.addEdge(source, i, 1, 0)
+		studentEdges[i] = make([]int, m)
+		for j, groupID := range groupIDs {
+			studentEdges[i][j] = nw.addEdge(i, n+j, 1, maxBid-p.Bid(userID, groupID))
+		}
+	}
+	for j, groupID := range groupIDs {
+		if p.MinPerGroup > 0 {
+			nw.addEdge(n+j, sink, p.MinPerGroup, 0)
+		}
+		if p.Max(groupID) > p.MinPerGroup {
+			nw.addEdge(n+j, sink, p.Max(groupID)-p.MinPerGroup, big)
+		}
+	}
+
+	// every augmenting path carries exactly one student
+	for flow := 0; flow < n; flow++ {
+		via, found := nw.shortestPath(source, sink)
+		if !found {
+			return nil, ErrInfeasible
+		}
+		for node := sink; node != source; {
+			e := via[node]
+			nw.edges[e].capacity--
+			nw.edges[e^1].capacity++
+			node = nw.edges[e^1].to
+		}
+	}
+
+	result := []Assignment{}
+	for i, userID := range userIDs {
+		for j, groupID := range groupIDs {
+			if nw.edges[studentEdges[i][j]].capacity == 0 {
+				result = append(result, Assignment{
+					UserID:  userID,
+					GroupID: groupID,
+					Bid:     p.Bid(userID, groupID),
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,181 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package assignment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func groupSizes(result []Assignment) map[int64]int {
+	sizes := make(map[int64]int)
+	for _, item := range result {
+		sizes[item.GroupID]++
+	}
+	return sizes
+}
+
+func totalBid(result []Assignment) int {
+	sum := 0
+	for _, item := range result {
+		sum += item.Bid
+	}
+	return sum
+}
+
+func TestAssignment(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	g.Describe("Assignment", func() {
+		g.It("Should give everyone their favourite group when possible", func() {
+			p := &Problem{
+				UserIDs:  []int64{1, 2, 3, 4},
+				GroupIDs: []int64{10, 20},
+				Bids: map[int64]map[int64]int{
+					1: {10: 10, 20: 0},
+					2: {10: 0, 20: 10},
+					3: {10: 9, 20: 1},
+					4: {10: 2, 20: 8},
+				},
+				DefaultBid:  10,
+				MinPerGroup: 0,
+				MaxPerGroup: 4,
+			}
+
+			result, err := Solve(p)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(result)).Equal(4)
+			g.Assert(result[0]).Equal(Assignment{UserID: 1, GroupID: 10, Bid: 10})
+			g.Assert(result[1]).Equal(Assignment{UserID: 2, GroupID: 20, Bid: 10})
+			g.Assert(result[2]).Equal(Assignment{UserID: 3, GroupID: 10, Bid: 9})
+			g.Assert(result[3]).Equal(Assignment{UserID: 4, GroupID: 20, Bid: 8})
+		})
+
+		g.It("Should respect the upper bound", func() {
+			// everyone prefers group 10, but it only has room for two
+			p := &Problem{
+				UserIDs:  []int64{1, 2, 3, 4},
+				GroupIDs: []int64{10, 20},
+				Bids: map[int64]map[int64]int{
+					1: {10: 10, 20: 9},
+					2: {10: 10, 20: 2},
+					3: {10: 10, 20: 8},
+					4: {10: 10, 20: 1},
+				},
+				DefaultBid:  10,
+				MinPerGroup: 0,
+				MaxPerGroup: 2,
+			}
+
+			result, err := Solve(p)
+			g.Assert(err).Equal(nil)
+			g.Assert(groupSizes(result)).Equal(map[int64]int{10: 2, 20: 2})
+			g.Assert(totalBid(result)).Equal(10 + 10 + 9 + 8)
+			g.Assert(result[1].GroupID).Equal(int64(10))
+			g.Assert(result[3].GroupID).Equal(int64(10))
+		})
+
+		g.It("Should respect the lower bound", func() {
+			// nobody likes group 30, but it needs at least one member
+			p := &Problem{
+				UserIDs:  []int64{1, 2, 3},
+				GroupIDs: []int64{10, 20, 30},
+				Bids: map[int64]map[int64]int{
+					1: {10: 10, 20: 5, 30: 3},
+					2: {10: 10, 20: 5, 30: 0},
+					3: {10: 10, 20: 5, 30: 1},
+				},
+				DefaultBid:  10,
+				MinPerGroup: 1,
+				MaxPerGroup: 3,
+			}
+
+			result, err := Solve(p)
+			g.Assert(err).Equal(nil)
+			g.Assert(groupSizes(result)).Equal(map[int64]int{10: 1, 20: 1, 30: 1})
+			g.Assert(totalBid(result)).Equal(10 + 5 + 3)
+			g.Assert(result[0].GroupID).Equal(int64(30))
+		})
+
+		g.It("Should respect capacities of single groups", func() {
+			p := &Problem{
+				UserIDs:     []int64{1, 2, 3},
+				GroupIDs:    []int64{10, 20},
+				DefaultBid:  10,
+				MinPerGroup: 0,
+				MaxPerGroup: 3,
+				Capacities:  map[int64]int{10: 1, 20: 0},
+			}
+
+			// 0 is a real capacity here, unlimited groups are not part of the map
+			_, err := Solve(p)
+			g.Assert(errors.Is(err, ErrInfeasible)).IsTrue()
+
+			p.Capacities = map[int64]int{10: 1}
+			result, err := Solve(p)
+			g.Assert(err).Equal(nil)
+			g.Assert(groupSizes(result)).Equal(map[int64]int{10: 1, 20: 2})
+		})
+
+		g.It("Should use the default bid for missing bids", func() {
+			p := &Problem{
+				UserIDs:     []int64{1, 2},
+				GroupIDs:    []int64{10, 20},
+				Bids:        map[int64]map[int64]int{1: {10: 0}},
+				DefaultBid:  10,
+				MinPerGroup: 0,
+				MaxPerGroup: 2,
+			}
+
+			result, err := Solve(p)
+			g.Assert(err).Equal(nil)
+			g.Assert(result[0]).Equal(Assignment{UserID: 1, GroupID: 20, Bid: 10})
+			g.Assert(result[1].Bid).Equal(10)
+		})
+
+		g.It("Should reject infeasible bounds", func() {
+			p := &Problem{
+				UserIDs:     []int64{1, 2, 3},
+				GroupIDs:    []int64{10, 20},
+				DefaultBid:  10,
+				MinPerGroup: 2,
+				MaxPerGroup: 3,
+			}
+			_, err := Solve(p)
+			g.Assert(errors.Is(err, ErrInfeasible)).IsTrue()
+
+			p.MinPerGroup = 0
+			p.MaxPerGroup = 1
+			_, err = Solve(p)
+			g.Assert(errors.Is(err, ErrInfeasible)).IsTrue()
+
+			p.MinPerGroup = 2
+			_, err = Solve(p)
+			g.Assert(errors.Is(err, ErrInfeasible)).IsTrue()
+
+			p.GroupIDs = []int64{}
+			_, err = Solve(p)
+			g.Assert(errors.Is(err, ErrInfeasible)).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,85 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestArchive(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	g.Describe("Archive", func() {
+		g.It("Should zip and unzip a directory", func() {
+			src, err := ioutil.TempDir("", "infomark-archive-src")
+			g.Assert(err).Equal(nil)
+			defer os.RemoveAll(src)
+
+			err = os.MkdirAll(filepath.Join(src, "sheets", "01"), 0755)
+			g.Assert(err).Equal(nil)
+			err = ioutil.WriteFile(filepath.Join(src, "manifest.yml"), []byte("version: 1\n"), 0644)
+			g.Assert(err).Equal(nil)
+			err = ioutil.WriteFile(filepath.Join(src, "sheets", "01", "sheet.zip"), []byte("content"), 0644)
+			g.Assert(err).Equal(nil)
+
+			dst, err := ioutil.TempDir("", "infomark-archive-dst")
+			g.Assert(err).Equal(nil)
+			defer os.RemoveAll(dst)
+
+			zipPath := filepath.Join(dst, "course.zip")
+			err = ZipDirectory(src, zipPath)
+			g.Assert(err).Equal(nil)
+
+			err = UnzipToDirectory(zipPath, filepath.Join(dst, "extracted"))
+			g.Assert(err).Equal(nil)
+
+			content, err := ioutil.ReadFile(filepath.Join(dst, "extracted", "sheets", "01", "sheet.zip"))
+			g.Assert(err).Equal(nil)
+			g.Assert(string(content)).Equal("content")
+		})
+
+		g.It("Should reject entries outside of the directory", func() {
+			dst, err := ioutil.TempDir("", "infomark-archive-slip")
+			g.Assert(err).Equal(nil)
+			defer os.RemoveAll(dst)
+
+			zipPath := filepath.Join(dst, "evil.zip")
+			out, err := os.Create(zipPath)
+			g.Assert(err).Equal(nil)
+			zipWriter := zip.NewWriter(out)
+			w, err := zipWriter.Create("../evil.txt")
+			g.Assert(err).Equal(nil)
+			_, err = w.Write([]byte("evil"))
+			g.Assert(err).Equal(nil)
+			g.Assert(zipWriter.Close()).Equal(nil)
+			g.Assert(out.Close()).Equal(nil)
+
+			err = UnzipToDirectory(zipPath, filepath.Join(dst, "extracted"))
+			g.Assert(err != nil).IsTrue()
+		})
+	})
+}
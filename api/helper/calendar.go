@@ -0,0 +1,115 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is a single or weekly recurring event of an iCalendar feed.
+// Start and End are written as floating local times, i.e. calendar clients
+// show them in the time zone of the viewer. This matches how universities
+// announce the time of a meeting.
+type CalendarEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	WeeklyUntil time.Time
+	Summary     string
+	Location    string
+	URL         string
+	Description string
+}
+
+const (
+	calendarDateTime = "20060102T150405"
+	calendarLineSize = 75
+)
+
+// escapeCalendarText escapes a TEXT value (RFC 5545, section 3.3.11).
+func escapeCalendarText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// writeCalendarLine folds content lines longer than 75 octets without
+// splitting multi-byte characters (RFC 5545, section 3.1).
+func writeCalendarLine(w *bufio.Writer, line string) {
+	size := calendarLineSize
+	for len(line) > size {
+		cut := size
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space
+		size = calendarLineSize - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// WriteCalendar writes all events as an iCalendar (RFC 5545) feed.
+func WriteCalendar(w io.Writer, name string, events []CalendarEvent, stamp time.Time) error {
+	writer := bufio.NewWriter(w)
+
+	writeCalendarLine(writer, "BEGIN:VCALENDAR")
+	writeCalendarLine(writer, "VERSION:2.0")
+	writeCalendarLine(writer, "PRODID:-//InfoMark//InfoMark//EN")
+	writeCalendarLine(writer, "CALSCALE:GREGORIAN")
+	writeCalendarLine(writer, "METHOD:PUBLISH")
+	writeCalendarLine(writer, "X-WR-CALNAME:"+escapeCalendarText(name))
+
+	for _, event := range events {
+		writeCalendarLine(writer, "BEGIN:VEVENT")
+		writeCalendarLine(writer, "UID:"+event.UID)
+		writeCalendarLine(writer, "DTSTAMP:"+stamp.UTC().Format(calendarDateTime)+"Z")
+		writeCalendarLine(writer, "DTSTART:"+event.Start.Format(calendarDateTime))
+		writeCalendarLine(writer, "DTEND:"+event.End.Format(calendarDateTime))
+		if !event.WeeklyUntil.IsZero() {
+			writeCalendarLine(writer, "RRULE:FREQ=WEEKLY;UNTIL="+event.WeeklyUntil.Format(calendarDateTime))
+		}
+		writeCalendarLine(writer, "SUMMARY:"+escapeCalendarText(event.Summary))
+		if event.Location != "" {
+			writeCalendarLine(writer, "LOCATION:"+escapeCalendarText(event.Location))
+		}
+		if event.URL != "" {
+			writeCalendarLine(writer, "URL:"+event.URL)
+		}
+		if event.Description != "" {
+			writeCalendarLine(writer, "DESCRIPTION:"+escapeCalendarText(event.Description))
+		}
+		writeCalendarLine(writer, "END:VEVENT")
+	}
+
+	writeCalendarLine(writer, "END:VCALENDAR")
+	return writer.Flush()
+}
@@ -0,0 +1,88 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+)
+
+func TestCalendar(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	g.Describe("Calendar", func() {
+		g.It("Should write weekly events with floating times", func() {
+			start := time.Date(2026, 10, 19, 14, 15, 0, 0, time.UTC)
+			events := []CalendarEvent{
+				{
+					UID:         "group-slot-1@infomark",
+					Start:       start,
+					End:         start.Add(90 * time.Minute),
+					WeeklyUntil: time.Date(2027, 2, 14, 23, 59, 59, 0, time.UTC),
+					Summary:     "Info I; Group 1, Monday",
+					Location:    "Sand 1",
+				},
+			}
+
+			var buf bytes.Buffer
+			err := WriteCalendar(&buf, "InfoMark", events, start)
+			g.Assert(err).Equal(nil)
+
+			content := buf.String()
+			g.Assert(strings.HasPrefix(content, "BEGIN:VCALENDAR\r\n")).IsTrue()
+			g.Assert(strings.HasSuffix(content, "END:VCALENDAR\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "DTSTART:20261019T141500\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "DTEND:20261019T154500\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "RRULE:FREQ=WEEKLY;UNTIL=20270214T235959\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "SUMMARY:Info I\\; Group 1\\, Monday\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "LOCATION:Sand 1\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "URL:")).IsFalse()
+		})
+
+		g.It("Should fold long lines", func() {
+			events := []CalendarEvent{
+				{
+					UID:     "group-slot-2@infomark",
+					Summary: strings.Repeat("ä", 100),
+				},
+			}
+
+			var buf bytes.Buffer
+			err := WriteCalendar(&buf, "InfoMark", events, time.Now())
+			g.Assert(err).Equal(nil)
+
+			unfolded := ""
+			for _, line := range strings.Split(buf.String(), "\r\n") {
+				g.Assert(len(line) <= 75).IsTrue()
+				if strings.HasPrefix(line, " ") {
+					unfolded += line[1:]
+				} else {
+					unfolded += "\n" + line
+				}
+			}
+			g.Assert(strings.Contains(unfolded, "\nSUMMARY:"+strings.Repeat("ä", 100)+"\n")).IsTrue()
+		})
+	})
+}
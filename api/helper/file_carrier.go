@@ -241,6 +241,75 @@ func (f *FileHandle) Delete() error {
 	return os.Remove(f.Path())
 }
 
+// PathWithExtension returns the path of the file with the given extension,
+// even if it does not exist yet.
+func (f *FileHandle) PathWithExtension(ext string) string {
+	switch f.Category {
+	case AvatarCategory:
+		return fmt.Sprintf("%s/avatars/%d.%s", configuration.Configuration.Server.Paths.Uploads, f.ID, ext)
+	case MaterialCategory:
+		return fmt.Sprintf("%s/materials/%d.%s", configuration.Configuration.Server.Paths.Uploads, f.ID, ext)
+	}
+	return f.Path()
+}
+
+// CopyFrom replaces the file by a copy of a file on disk. The extension of the
+// source has to be one of the allowed extensions.
+func (f *FileHandle) CopyFrom(srcPath string) error {
+	ext := strings.ToLower(strings.TrimPrefix(pathpkg.Ext(srcPath), "."))
+
+	allowed := false
+	for _, candidate := range f.Extensions {
+		allowed = allowed || candidate == ext
+	}
+	if !allowed {
+		return fmt.Errorf("file %s should have one of the extensions %v", srcPath, f.Extensions)
+	}
+
+	// a previous file might have a different extension
+	if f.Exists() {
+		if err := f.Delete(); err != nil {
+			return err
+		}
+	}
+
+	return CopyFile(srcPath, f.PathWithExtension(ext))
+}
+
+// CopyTo copies the file on disk to the location of another handle of the
+// same category.
+func (f *FileHandle) CopyTo(dst *FileHandle) error {
+	srcPath := f.Path()
+	if srcPath == "" || !FileExists(srcPath) {
+		return fmt.Errorf("file of %d does not exist", f.ID)
+	}
+	return dst.CopyFrom(srcPath)
+}
+
+// CopyFile copies a file on disk.
+func CopyFile(srcPath string, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(pathpkg.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 // GetContentType tries to predict the content type without reading the entire
 // file. There are some issues with this function as it cannot distinguish
 // between zip and octstream.
@@ -86,6 +86,19 @@ func Int64FromURL(r *http.Request, name string, standard int64) int64 {
 	return int64(i)
 }
 
+// BoolFromURL will read an URL parameter like /api/?some_bool=true
+func BoolFromURL(r *http.Request, name string, standard bool) bool {
+	str := r.FormValue(name)
+	if str == "" {
+		return standard
+	}
+	b, err := strconv.ParseBool(str)
+	if err != nil {
+		return standard
+	}
+	return b
+}
+
 // H is a neat alias
 type H map[string]interface{}
 
@@ -0,0 +1,185 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Spreadsheets are represented as a list of rows. Each cell is either a
+// string, an int, a float64 or a bool. Strings are never re-interpreted as
+// numbers. Hence, student numbers with leading zeros survive the export.
+
+// WriteCSV writes all rows as a CSV file using the given delimiter.
+func WriteCSV(w io.Writer, rows [][]interface{}, delimiter rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for k, cell := range row {
+			record[k] = formatCell(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// SpreadsheetColumnName converts a zero-based column index into the
+// spreadsheet notation (0 -> A, 25 -> Z, 26 -> AA).
+func SpreadsheetColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const xlsxRootRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+// WriteXLSX writes all rows as an Office Open XML workbook with a single
+// worksheet. We only need plain values, so this writes the minimal set of
+// parts a spreadsheet application requires instead of pulling in a library.
+func WriteXLSX(w io.Writer, sheetName string, rows [][]interface{}) error {
+	archive := zip.NewWriter(w)
+
+	name, err := xmlEscape(sheetName)
+	if err != nil {
+		return err
+	}
+
+	parts := []struct {
+		Name    string
+		Content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRelationships},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelationships},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name)},
+	}
+
+	for _, part := range parts {
+		f, err := archive.Create(part.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.Content); err != nil {
+			return err
+		}
+	}
+
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeXLSXWorksheet(f, rows); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeXLSXWorksheet(w io.Writer, rows [][]interface{}) error {
+	io.WriteString(w, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	io.WriteString(w, `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for r, row := range rows {
+		fmt.Fprintf(w, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := fmt.Sprintf("%s%d", SpreadsheetColumnName(c), r+1)
+
+			switch v := cell.(type) {
+			case nil:
+				continue
+			case int, int64, float64:
+				fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, formatCell(v))
+			case bool:
+				value := 0
+				if v {
+					value = 1
+				}
+				fmt.Fprintf(w, `<c r="%s" t="b"><v>%d</v></c>`, ref, value)
+			default:
+				text, err := xmlEscape(formatCell(v))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, text)
+			}
+		}
+		io.WriteString(w, `</row>`)
+	}
+
+	_, err := io.WriteString(w, `</sheetData></worksheet>`)
+	return err
+}
+
+func xmlEscape(text string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(text)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
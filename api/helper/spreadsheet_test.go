@@ -0,0 +1,92 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestSpreadsheet(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	g.Describe("Spreadsheet", func() {
+		g.It("Should name columns like spreadsheet applications", func() {
+			g.Assert(SpreadsheetColumnName(0)).Equal("A")
+			g.Assert(SpreadsheetColumnName(25)).Equal("Z")
+			g.Assert(SpreadsheetColumnName(26)).Equal("AA")
+			g.Assert(SpreadsheetColumnName(27)).Equal("AB")
+			g.Assert(SpreadsheetColumnName(701)).Equal("ZZ")
+			g.Assert(SpreadsheetColumnName(702)).Equal("AAA")
+		})
+
+		g.It("Should write csv with custom delimiter", func() {
+			rows := [][]interface{}{
+				{"student_number", "points", "percentage", "passed"},
+				{"000123", 17, 56.666, true},
+			}
+
+			var buf bytes.Buffer
+			err := WriteCSV(&buf, rows, ';')
+			g.Assert(err).Equal(nil)
+			g.Assert(buf.String()).Equal("student_number;points;percentage;passed\n000123;17;56.67;true\n")
+		})
+
+		g.It("Should write xlsx keeping strings as strings", func() {
+			rows := [][]interface{}{
+				{"student_number", "points"},
+				{"000123", 17},
+				{"<b>&", nil},
+			}
+
+			var buf bytes.Buffer
+			err := WriteXLSX(&buf, "grades", rows)
+			g.Assert(err).Equal(nil)
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			g.Assert(err).Equal(nil)
+
+			var worksheet string
+			for _, f := range archive.File {
+				if f.Name == "xl/worksheets/sheet1.xml" {
+					rc, err := f.Open()
+					g.Assert(err).Equal(nil)
+					content, err := ioutil.ReadAll(rc)
+					g.Assert(err).Equal(nil)
+					rc.Close()
+					worksheet = string(content)
+				}
+			}
+
+			g.Assert(len(archive.File)).Equal(5)
+			g.Assert(strings.Contains(worksheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">000123</t></is></c>`)).IsTrue()
+			g.Assert(strings.Contains(worksheet, `<c r="B2"><v>17</v></c>`)).IsTrue()
+			g.Assert(strings.Contains(worksheet, `&lt;b&gt;&amp;`)).IsTrue()
+			g.Assert(strings.Contains(worksheet, `r="B3"`)).IsFalse()
+		})
+	})
+
+}
@@ -20,8 +20,9 @@
 package shared
 
 import (
-	"fmt"
 	"time"
+
+	"github.com/infomark-org/infomark/auth/authenticate"
 )
 
 // SubmissionAMQPWorkerRequest is the message which is handed over to the background workers
@@ -45,30 +46,35 @@ type SubmissionAMQPWorkerRequest struct {
 // 	FinishedAt time.Time `json:"finished_at"`
 // }
 
-// NewSubmissionAMQPWorkerRequest creates a new message for the workers
+// NewSubmissionAMQPWorkerRequest creates a new message for the workers. The
+// access token should be created for the job scope of the same submission,
+// grade and visibility (see authenticate.TokenAuth.CreateJobJWT).
 func NewSubmissionAMQPWorkerRequest(
 	courseID int64, taskID int64, submissionID int64, gradeID int64,
 	accessToken string, url string, dockerimage string, sha256 string, visibility string) *SubmissionAMQPWorkerRequest {
 
+	scope := NewSubmissionJobScope(courseID, taskID, submissionID, gradeID, visibility)
+
 	return &SubmissionAMQPWorkerRequest{
+		SubmissionID:      submissionID,
+		EnqueuedAt:        time.Now(),
+		AccessToken:       accessToken,
+		FrameworkFileURL:  url + scope.FrameworkFilePath(),
+		SubmissionFileURL: url + scope.SubmissionFilePath(),
+		ResultEndpointURL: url + scope.ResultPath(),
+		DockerImage:       dockerimage,
+		Sha256:            sha256,
+	}
+}
+
+// NewSubmissionJobScope describes the requests of a worker testing a submission.
+func NewSubmissionJobScope(courseID int64, taskID int64, submissionID int64, gradeID int64,
+	visibility string) authenticate.JobScope {
+	return authenticate.JobScope{
+		CourseID:     courseID,
+		TaskID:       taskID,
 		SubmissionID: submissionID,
-		EnqueuedAt:   time.Now(),
-		AccessToken:  accessToken,
-		FrameworkFileURL: fmt.Sprintf("%s/api/v1/courses/%d/tasks/%d/%s_file",
-			url,
-			courseID,
-			taskID,
-			visibility),
-		SubmissionFileURL: fmt.Sprintf("%s/api/v1/courses/%d/submissions/%d/file",
-			url,
-			courseID,
-			submissionID),
-		ResultEndpointURL: fmt.Sprintf("%s/api/v1/courses/%d/grades/%d/%s_result",
-			url,
-			courseID,
-			gradeID,
-			visibility),
-		DockerImage: dockerimage,
-		Sha256:      sha256,
+		GradeID:      gradeID,
+		Visibility:   visibility,
 	}
 }
@@ -22,7 +22,10 @@ package authenticate
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/alexedwards/scs"
 	jwt "github.com/golang-jwt/jwt/v4"
@@ -34,6 +37,151 @@ type AccessClaims struct {
 	AccessNotRefresh bool  `json:"anr"`      // to distinguish between access and refresh code
 	LoginID          int64 `json:"login_id"` // the id to get user information
 	Root             bool  `json:"root"`     // a global flag to bypass all permission checks
+	// Job restricts the token to the requests of a single testing job.
+	Job *JobScope `json:"job,omitempty"`
+	// SetupTwoFactor restricts the claims to set up two-factor authentication.
+	SetupTwoFactor bool `json:"setup_two_factor,omitempty"`
+	// Scope restricts the claims of a personal api token.
+	Scope *TokenScope `json:"scope,omitempty"`
+	// SessionID refers to the server-side login session, it is 0 for claims
+	// which are not bound to a session like those of testing jobs.
+	SessionID int64 `json:"sid,omitempty"`
+	// ImpersonatorID is the root user acting as the user of LoginID.
+	ImpersonatorID int64 `json:"impersonator_id,omitempty"`
+}
+
+// Scopes of personal api tokens.
+const (
+	ScopeRead        = "read"
+	ScopeGrading     = "grading"
+	ScopeCourseAdmin = "course_admin"
+)
+
+// PersonalTokenPrefix marks personal api tokens to distinguish them from JWTs.
+const PersonalTokenPrefix = "imp_"
+
+// TokenScope describes the requests a personal api token may issue on behalf
+// of its owner. Permissions of the owner are checked as usual on top.
+type TokenScope struct {
+	Kind     string `json:"kind"`
+	CourseID int64  `json:"course_id,omitempty"` // 0 for all courses
+}
+
+// Allows checks whether a request is within the scope.
+func (s *TokenScope) Allows(method string, path string) bool {
+	readOnly := method == http.MethodGet || method == http.MethodHead
+
+	// tokens can never manage the account, e.g. to create further tokens
+	if strings.HasPrefix(path, "/api/v1/account") && !readOnly {
+		return false
+	}
+
+	if s.CourseID != 0 {
+		prefix := fmt.Sprintf("/api/v1/courses/%d", s.CourseID)
+		inCourse := path == prefix || strings.HasPrefix(path, prefix+"/")
+		personal := path == "/api/v1/me" || strings.HasPrefix(path, "/api/v1/account")
+		if !inCourse && !(readOnly && personal) {
+			return false
+		}
+	}
+
+	switch s.Kind {
+	case ScopeRead:
+		return readOnly
+	case ScopeGrading:
+		return readOnly || (method == http.MethodPut && matchesRoute(path, "/api/v1/courses/{id}/grades/{id}"))
+	case ScopeCourseAdmin:
+		return s.CourseID != 0
+	}
+	return false
+}
+
+// matchesRoute checks whether a path consists of the segments of a pattern,
+// where "{id}" stands for any numeric id.
+func matchesRoute(path string, pattern string) bool {
+	segments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	patternSegments := strings.Split(pattern, "/")
+	if len(segments) != len(patternSegments) {
+		return false
+	}
+
+	for k, patternSegment := range patternSegments {
+		if patternSegment == "{id}" {
+			if _, err := strconv.ParseUint(segments[k], 10, 64); err != nil {
+				return false
+			}
+		} else if segments[k] != patternSegment {
+			return false
+		}
+	}
+	return true
+}
+
+// PersonalTokenResolver looks up the claims of a personal api token.
+type PersonalTokenResolver interface {
+	ResolvePersonalToken(token string) (*AccessClaims, error)
+}
+
+// SessionValidator checks whether a server-side login session is still valid,
+// i.e. it was not revoked and did not expire.
+type SessionValidator interface {
+	ValidateSession(sessionID int64, loginID int64) error
+}
+
+// TwoFactorSetupAllows checks whether a request is possible before the
+// required two-factor authentication has been set up.
+func TwoFactorSetupAllows(path string) bool {
+	return path == "/api/v1/me" ||
+		path == "/api/v1/account" ||
+		path == "/api/v1/auth/sessions" ||
+		strings.HasPrefix(path, "/api/v1/account/two_factor")
+}
+
+// ImpersonationAllows checks whether a root user acting as another user may
+// issue a request. Everything can be seen, but nothing can be changed.
+func ImpersonationAllows(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// JobScope describes the only requests a worker may issue for a testing job:
+// downloading the submission and the test file of a task and posting the
+// test result of the grade.
+type JobScope struct {
+	CourseID     int64  `json:"course_id"`
+	TaskID       int64  `json:"task_id"`
+	SubmissionID int64  `json:"submission_id"`
+	GradeID      int64  `json:"grade_id"`
+	Visibility   string `json:"visibility"` // either "public" or "private"
+}
+
+// SubmissionFilePath is the endpoint to download the submission.
+func (s *JobScope) SubmissionFilePath() string {
+	return fmt.Sprintf("/api/v1/courses/%d/submissions/%d/file", s.CourseID, s.SubmissionID)
+}
+
+// FrameworkFilePath is the endpoint to download the test file of the task.
+func (s *JobScope) FrameworkFilePath() string {
+	return fmt.Sprintf("/api/v1/courses/%d/tasks/%d/%s_file", s.CourseID, s.TaskID, s.Visibility)
+}
+
+// ResultPath is the endpoint to post the test result of the grade.
+func (s *JobScope) ResultPath() string {
+	return fmt.Sprintf("/api/v1/courses/%d/grades/%d/%s_result", s.CourseID, s.GradeID, s.Visibility)
+}
+
+// Allows checks whether a request belongs to the job.
+func (s *JobScope) Allows(method string, path string) bool {
+	if s.Visibility != "public" && s.Visibility != "private" {
+		return false
+	}
+
+	switch method {
+	case http.MethodGet:
+		return path == s.SubmissionFilePath() || path == s.FrameworkFilePath()
+	case http.MethodPost:
+		return path == s.ResultPath()
+	}
+	return false
 }
 
 func (a *AccessClaims) ToMap() map[string]interface{} {
@@ -51,11 +199,25 @@ func NewAccessClaims(loginId int64, root bool) AccessClaims {
 	}
 }
 
+// NewJobAccessClaims creates claims which are only valid for a single testing
+// job. By definition user with id 1 is the system itself.
+func NewJobAccessClaims(scope JobScope) AccessClaims {
+	return AccessClaims{
+		LoginID:          1,
+		AccessNotRefresh: true,
+		Root:             false,
+		Job:              &scope,
+	}
+}
+
 // RefreshClaims represent the claims parsed from JWT refresh token.
 type RefreshClaims struct {
 	jwt.StandardClaims
 	AccessNotRefresh bool  `json:"anr"`
 	LoginID          int64 `json:"login_id"`
+	// SessionID refers to the server-side login session. The id of the token
+	// (jti) has to match the one stored in the session.
+	SessionID int64 `json:"sid"`
 }
 
 func (a *RefreshClaims) ToMap() map[string]interface{} {
@@ -65,10 +227,12 @@ func (a *RefreshClaims) ToMap() map[string]interface{} {
 	return inInterface
 }
 
-func NewRefreshClaims(loginId int64) RefreshClaims {
+func NewRefreshClaims(loginId int64, sessionID int64, tokenID string) RefreshClaims {
 	return RefreshClaims{
+		StandardClaims:   jwt.StandardClaims{Id: tokenID},
 		LoginID:          loginId,
 		AccessNotRefresh: false,
+		SessionID:        sessionID,
 	}
 }
 
@@ -89,6 +253,8 @@ func (ret *RefreshClaims) ParseRefreshClaimsFromToken(secret string, tokenStr st
 		if !claims.AccessNotRefresh {
 			ret.LoginID = claims.LoginID
 			ret.AccessNotRefresh = claims.AccessNotRefresh
+			ret.SessionID = claims.SessionID
+			ret.Id = claims.Id
 			return nil
 		} else {
 			return errors.New("token is an access token, but refresh token was required")
@@ -118,6 +284,10 @@ func (ret *AccessClaims) ParseAccessClaimsFromToken(secret string, tokenStr stri
 			ret.LoginID = claims.LoginID
 			ret.AccessNotRefresh = claims.AccessNotRefresh
 			ret.Root = claims.Root
+			ret.Job = claims.Job
+			ret.SetupTwoFactor = claims.SetupTwoFactor
+			ret.SessionID = claims.SessionID
+			ret.ImpersonatorID = claims.ImpersonatorID
 			return nil
 		} else {
 			return errors.New("token is an refresh token, but access token was required")
@@ -142,10 +312,22 @@ func (ret *AccessClaims) ParseRefreshClaimsFromSession(manager *scs.Manager, r *
 		return err
 	}
 
+	setupTwoFactor, err := session.GetBool("setup_two_factor")
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := session.GetInt64("session_id")
+	if err != nil {
+		return err
+	}
+
 	ret.LoginID = loginId
 	// cookie based authentification is access-token only
 	ret.AccessNotRefresh = true
 	ret.Root = root
+	ret.SetupTwoFactor = setupTwoFactor
+	ret.SessionID = sessionID
 	return nil
 }
 
@@ -161,6 +343,14 @@ func (ret *AccessClaims) WriteToSession(manager *scs.Manager, w http.ResponseWri
 	if err != nil {
 		panic("hh")
 	}
+	err = session.PutBool(w, "setup_two_factor", ret.SetupTwoFactor)
+	if err != nil {
+		panic("hh")
+	}
+	err = session.PutInt64(w, "session_id", ret.SessionID)
+	if err != nil {
+		panic("hh")
+	}
 	// fmt.Println("Wrote ret.Root", ret.Root)
 
 	return w
@@ -0,0 +1,64 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package authenticate
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestTokenScope(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	g.Describe("TokenScope", func() {
+		g.It("Should let grading tokens only edit single grades", func() {
+			scope := &TokenScope{Kind: ScopeGrading}
+
+			g.Assert(scope.Allows(http.MethodGet, "/api/v1/courses/1/grades")).IsTrue()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/grades/42")).IsTrue()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/grades/42/")).IsTrue()
+
+			g.Assert(scope.Allows(http.MethodPost, "/api/v1/courses/1/grades/42")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPost, "/api/v1/courses/1/grades/import")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPost, "/api/v1/courses/1/grades/assign")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPost, "/api/v1/courses/1/grades/pool/claim")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPost, "/api/v1/courses/1/grades/42/public_result")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/grades/summary")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/sheets/1/grades/42")).IsFalse()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/tasks/1/grades")).IsFalse()
+		})
+
+		g.It("Should keep grading tokens of a course within the course", func() {
+			scope := &TokenScope{Kind: ScopeGrading, CourseID: 1}
+
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/grades/42")).IsTrue()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/2/grades/42")).IsFalse()
+		})
+
+		g.It("Should let read tokens only read", func() {
+			scope := &TokenScope{Kind: ScopeRead}
+
+			g.Assert(scope.Allows(http.MethodGet, "/api/v1/courses/1/grades")).IsTrue()
+			g.Assert(scope.Allows(http.MethodPut, "/api/v1/courses/1/grades/42")).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,211 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package authenticate
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/infomark-org/infomark/configuration"
+)
+
+// ErrLDAPInvalidCredentials is returned when the directory does not know the
+// user or rejects the password.
+var ErrLDAPInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// LDAPDirectory checks passwords by binding against an LDAP directory.
+type LDAPDirectory struct {
+	Config  *configuration.LDAPConfiguration
+	Timeout time.Duration
+}
+
+// LDAPIdentity is the directory entry of an authenticated user.
+type LDAPIdentity struct {
+	DN            string
+	Email         string
+	FirstName     string
+	LastName      string
+	StudentNumber string
+	Root          bool
+}
+
+// NewLDAPDirectory creates a directory. Connections are opened per login.
+func NewLDAPDirectory(config *configuration.LDAPConfiguration) *LDAPDirectory {
+	return &LDAPDirectory{
+		Config:  config,
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Authenticate binds as the user of the email address and reads the mapped
+// attributes of the entry.
+func (d *LDAPDirectory) Authenticate(email string, password string) (*LDAPIdentity, error) {
+	// an empty password would be an unauthenticated bind, which succeeds
+	if password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return nil, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := d.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	attributes := []string{}
+	for _, name := range []string{d.Config.Attributes.FirstName, d.Config.Attributes.LastName,
+		d.Config.Attributes.StudentNumber, d.Config.Attributes.Root} {
+		if name != "" {
+			attributes = append(attributes, name)
+		}
+	}
+
+	var entry *ldap.Entry
+	if d.Config.BindDN != "" {
+		if err := ldapBind(conn, d.Config.BindDN, d.Config.BindPassword); err != nil {
+			return nil, fmt.Errorf("ldap: service account: %w", err)
+		}
+
+		filter := strings.ReplaceAll(d.Config.UserFilter, "%s", ldap.EscapeFilter(email))
+		entries, err := d.search(conn, d.Config.BaseDN, ldap.ScopeWholeSubtree, filter, attributes)
+		if err != nil {
+			return nil, err
+		}
+		// unknown or ambiguous users are both rejected
+		if len(entries) != 1 {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		entry = entries[0]
+
+		if err := ldapBind(conn, entry.DN, password); err != nil {
+			return nil, err
+		}
+	} else {
+		dn := strings.ReplaceAll(d.Config.UserDN, "%s", ldap.EscapeDN(email[:at]))
+		if err := ldapBind(conn, dn, password); err != nil {
+			return nil, err
+		}
+
+		entries, err := d.search(conn, dn, ldap.ScopeBaseObject, "(objectClass=*)", attributes)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) != 1 {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		entry = entries[0]
+	}
+
+	identity := &LDAPIdentity{
+		DN:            entry.DN,
+		Email:         email,
+		FirstName:     ldapFirstValue(entry, d.Config.Attributes.FirstName),
+		LastName:      ldapFirstValue(entry, d.Config.Attributes.LastName),
+		StudentNumber: ldapFirstValue(entry, d.Config.Attributes.StudentNumber),
+	}
+	if d.Config.Attributes.Root != "" {
+		for _, value := range entry.GetEqualFoldAttributeValues(d.Config.Attributes.Root) {
+			for _, rootValue := range d.Config.RootValues {
+				if strings.EqualFold(value, rootValue) {
+					identity.Root = true
+				}
+			}
+		}
+	}
+	return identity, nil
+}
+
+func (d *LDAPDirectory) dial() (*ldap.Conn, error) {
+	u, err := url.Parse(d.Config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return nil, fmt.Errorf("ldap: unsupported scheme %q", u.Scheme)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: d.Config.InsecureSkipVerify,
+	}
+
+	conn, err := ldap.DialURL(d.Config.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: d.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(d.Timeout)
+
+	if u.Scheme == "ldap" && d.Config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// ldapBind reports rejected credentials as ErrLDAPInvalidCredentials.
+func ldapBind(conn *ldap.Conn, dn string, password string) error {
+	err := conn.Bind(dn, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return ErrLDAPInvalidCredentials
+	}
+	return err
+}
+
+// search returns at most two entries, which is enough to detect ambiguous
+// users. Referrals to other servers are not followed.
+func (d *LDAPDirectory) search(conn *ldap.Conn, base string, scope int, filter string, attributes []string) ([]*ldap.Entry, error) {
+	request := ldap.NewSearchRequest(base, scope, ldap.NeverDerefAliases,
+		2, int(d.Timeout/time.Second), false, filter, attributes, nil)
+
+	result, err := conn.Search(request)
+	switch {
+	case err == nil:
+		return result.Entries, nil
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) && result != nil:
+		// the ambiguous entries are still reported
+		return result.Entries, nil
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// ldapFirstValue returns the first value of an attribute, if configured.
+func ldapFirstValue(entry *ldap.Entry, name string) string {
+	if name == "" {
+		return ""
+	}
+	return entry.GetEqualFoldAttributeValue(name)
+}
@@ -0,0 +1,251 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package authenticate
+
+import (
+	"net"
+	"testing"
+
+	"github.com/franela/goblin"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/infomark-org/infomark/configuration"
+)
+
+type fakeEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// fakeDirectory is a tiny LDAP server which knows some entries and their
+// passwords. Search filters are matched by their equality assertions.
+type fakeDirectory struct {
+	listener  net.Listener
+	passwords map[string]string
+	entries   []fakeEntry
+}
+
+func newFakeDirectory() *fakeDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	d := &fakeDirectory{
+		listener:  listener,
+		passwords: map[string]string{},
+	}
+	go d.serve()
+	return d
+}
+
+func (d *fakeDirectory) URL() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *fakeDirectory) Close() {
+	d.listener.Close()
+}
+
+func (d *fakeDirectory) serve() {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func equalityValues(filter *ber.Packet) []string {
+	if filter.ClassType == ber.ClassContext && filter.Tag == ldap.FilterEqualityMatch {
+		return []string{filter.Children[1].Data.String()}
+	}
+	values := []string{}
+	for _, child := range filter.Children {
+		values = append(values, equalityValues(child)...)
+	}
+	return values
+}
+
+func (d *fakeDirectory) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reply := func(id int64, op *ber.Packet) {
+		msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+		msg.AppendChild(op)
+		conn.Write(msg.Bytes())
+	}
+	result := func(tag ber.Tag, code int64) *ber.Packet {
+		op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+		op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+		op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+		return op
+	}
+
+	for {
+		msg, err := ber.ReadPacket(conn)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+		id, _ := msg.Children[0].Value.(int64)
+		op := msg.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			code := int64(ldap.LDAPResultInvalidCredentials)
+			if expected, ok := d.passwords[dn]; ok && expected == password {
+				code = ldap.LDAPResultSuccess
+			}
+			reply(id, result(ldap.ApplicationBindResponse, code))
+
+		case ldap.ApplicationSearchRequest:
+			base := op.Children[0].Data.String()
+			scope, _ := op.Children[1].Value.(int64)
+			values := equalityValues(op.Children[6])
+			for _, entry := range d.entries {
+				match := false
+				if scope == ldap.ScopeBaseObject {
+					match = entry.DN == base
+				} else {
+					for _, value := range values {
+						match = match || entry.Attributes["mail"][0] == value
+					}
+				}
+				if !match {
+					continue
+				}
+
+				found := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+				found.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, ""))
+				attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				for name, vals := range entry.Attributes {
+					attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+					set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					for _, v := range vals {
+						set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+					}
+					attribute.AppendChild(set)
+					attributes.AppendChild(attribute)
+				}
+				found.AppendChild(attributes)
+				reply(id, found)
+			}
+			reply(id, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+
+		default:
+			return
+		}
+	}
+}
+func TestLDAP(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	var server *fakeDirectory
+	var config *configuration.LDAPConfiguration
+
+	g.Describe("LDAP", func() {
+
+		g.BeforeEach(func() {
+			server = newFakeDirectory()
+			server.passwords["cn=service,dc=uni"] = "service-secret"
+			server.passwords["uid=jdoe,ou=people,dc=uni"] = "directory-password"
+			server.entries = []fakeEntry{{
+				DN: "uid=jdoe,ou=people,dc=uni",
+				Attributes: map[string][]string{
+					"mail":      {"jane.doe@informatik.uni"},
+					"givenname": {"Jane"},
+					"sn":        {"Doe"},
+					"matrikel":  {"4012345"},
+					"memberof":  {"cn=staff,dc=uni", "CN=InfoMark-Admins,dc=uni"},
+				},
+			}}
+
+			config = &configuration.LDAPConfiguration{
+				URL:          server.URL(),
+				BindDN:       "cn=service,dc=uni",
+				BindPassword: "service-secret",
+				BaseDN:       "dc=uni",
+				UserFilter:   "(&(objectClass=person)(mail=%s))",
+				RootValues:   []string{"cn=infomark-admins,dc=uni"},
+			}
+			config.Attributes.FirstName = "givenName"
+			config.Attributes.LastName = "sn"
+			config.Attributes.StudentNumber = "matrikel"
+			config.Attributes.Root = "memberOf"
+		})
+
+		g.AfterEach(func() {
+			server.Close()
+		})
+
+		g.It("Should authenticate with a service account", func() {
+			identity, err := NewLDAPDirectory(config).Authenticate("jane.doe@informatik.uni", "directory-password")
+			g.Assert(err).Equal(nil)
+			g.Assert(identity.DN).Equal("uid=jdoe,ou=people,dc=uni")
+			g.Assert(identity.FirstName).Equal("Jane")
+			g.Assert(identity.LastName).Equal("Doe")
+			g.Assert(identity.StudentNumber).Equal("4012345")
+			g.Assert(identity.Root).Equal(true)
+		})
+
+		g.It("Should authenticate by binding directly", func() {
+			config.BindDN = ""
+			config.UserDN = "uid=%s,ou=people,dc=uni"
+			server.entries[0].DN = "uid=jane.doe,ou=people,dc=uni"
+			server.passwords["uid=jane.doe,ou=people,dc=uni"] = "directory-password"
+
+			identity, err := NewLDAPDirectory(config).Authenticate("jane.doe@informatik.uni", "directory-password")
+			g.Assert(err).Equal(nil)
+			g.Assert(identity.DN).Equal("uid=jane.doe,ou=people,dc=uni")
+			g.Assert(identity.LastName).Equal("Doe")
+		})
+
+		g.It("Should reject wrong, empty passwords and unknown users", func() {
+			directory := NewLDAPDirectory(config)
+
+			_, err := directory.Authenticate("jane.doe@informatik.uni", "wrong")
+			g.Assert(err).Equal(ErrLDAPInvalidCredentials)
+
+			_, err = directory.Authenticate("jane.doe@informatik.uni", "")
+			g.Assert(err).Equal(ErrLDAPInvalidCredentials)
+
+			_, err = directory.Authenticate("john.doe@informatik.uni", "directory-password")
+			g.Assert(err).Equal(ErrLDAPInvalidCredentials)
+		})
+
+		g.It("Should report a broken service account", func() {
+			config.BindPassword = "wrong"
+
+			_, err := NewLDAPDirectory(config).Authenticate("jane.doe@informatik.uni", "directory-password")
+			g.Assert(err == nil).IsFalse()
+			g.Assert(err == ErrLDAPInvalidCredentials).IsFalse()
+		})
+
+		g.It("Should escape the email address in the search filter", func() {
+			_, err := NewLDAPDirectory(config).Authenticate("*)(mail=jane.doe@informatik.uni", "directory-password")
+			g.Assert(err).Equal(ErrLDAPInvalidCredentials)
+		})
+	})
+}
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/alexedwards/scs"
 	"github.com/go-chi/jwtauth/v5"
@@ -32,6 +33,7 @@ import (
 	"github.com/infomark-org/infomark/auth"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/symbol"
+	"github.com/sirupsen/logrus"
 
 	libredis "github.com/redis/go-redis/v9"
 
@@ -42,7 +44,7 @@ import (
 // RequiredValidAccessClaimsMiddleware tries to get information about the identity which
 // issues a request by looking into the authorization header and then into
 // the cookie.
-func RequiredValidAccessClaims(manager *scs.Manager, config *configuration.ServerConfigurationSchema) func(http.Handler) http.Handler {
+func RequiredValidAccessClaims(manager *scs.Manager, config *configuration.ServerConfigurationSchema, tokens PersonalTokenResolver, sessions SessionValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			accessClaims := &AccessClaims{}
@@ -56,7 +58,21 @@ func RequiredValidAccessClaims(manager *scs.Manager, config *configuration.Serve
 				}
 			} else {
 				// first we test the JWT autorization
-				if HasHeaderToken(r) {
+				if HasHeaderToken(r) && strings.HasPrefix(jwtauth.TokenFromHeader(r), PersonalTokenPrefix) {
+					// personal api tokens are looked up in the database
+					claims, err := tokens.ResolvePersonalToken(jwtauth.TokenFromHeader(r))
+					if err != nil {
+						render.Render(w, r, auth.ErrUnauthenticated)
+						return
+					}
+					accessClaims = claims
+
+					if !accessClaims.Scope.Allows(r.Method, r.URL.Path) {
+						render.Render(w, r, auth.ErrUnauthorized)
+						return
+					}
+
+				} else if HasHeaderToken(r) {
 					// parse token from from header
 					tokenStr := jwtauth.TokenFromHeader(r)
 
@@ -68,6 +84,23 @@ func RequiredValidAccessClaims(manager *scs.Manager, config *configuration.Serve
 						return
 					}
 
+					// tokens of a login are revoked together with its session
+					if accessClaims.SessionID != 0 {
+						if err := sessions.ValidateSession(accessClaims.SessionID, accessClaims.LoginID); err != nil {
+							render.Render(w, r, auth.ErrUnauthenticated)
+							return
+						}
+					}
+
+					// tokens of testing jobs are restricted to the requests of the job
+					if accessClaims.Job != nil {
+						if !accessClaims.Job.Allows(r.Method, r.URL.Path) {
+							render.Render(w, r, auth.ErrUnauthorized)
+							return
+						}
+						accessClaims.Root = true
+					}
+
 				} else {
 					// fmt.Println("no token, try session")
 					if HasSessionToken(manager, r) {
@@ -79,6 +112,13 @@ func RequiredValidAccessClaims(manager *scs.Manager, config *configuration.Serve
 							return
 						}
 
+						// every cookie belongs to a server-side session which might be revoked
+						if err := sessions.ValidateSession(accessClaims.SessionID, accessClaims.LoginID); err != nil {
+							accessClaims.DestroyInSession(manager, w, r)
+							render.Render(w, r, auth.ErrUnauthenticated)
+							return
+						}
+
 						// session is valid --> we will extend the session
 						w = accessClaims.UpdateSession(manager, w, r)
 					} else {
@@ -90,6 +130,29 @@ func RequiredValidAccessClaims(manager *scs.Manager, config *configuration.Serve
 				}
 			}
 
+			// accounts which have to set up two-factor authentication can do only this
+			if accessClaims.SetupTwoFactor && !TwoFactorSetupAllows(r.URL.Path) {
+				render.Render(w, r, auth.ErrTwoFactorSetupRequired)
+				return
+			}
+
+			// root users acting as another user are restricted and audited
+			if accessClaims.ImpersonatorID != 0 {
+				allowed := ImpersonationAllows(r.Method)
+				logrus.StandardLogger().WithFields(logrus.Fields{
+					"module":          "impersonation",
+					"impersonator_id": accessClaims.ImpersonatorID,
+					"login_id":        accessClaims.LoginID,
+					"method":          r.Method,
+					"allowed":         allowed,
+				}).Info(r.URL.RequestURI())
+
+				if !allowed {
+					render.Render(w, r, auth.ErrUnauthorized)
+					return
+				}
+			}
+
 			// nothing given
 			// serve next
 			ctx := context.WithValue(r.Context(), symbol.CtxKeyAccessClaims, accessClaims)
@@ -128,6 +191,20 @@ func (obj *LoginLimiterKeyFromIP) Key() string {
 	return limiter.GetIP(obj.R, options).String()
 }
 
+// LoginLimiterKeyFromClaims counts the requests of an account regardless of
+// the addresses and login sessions they come from.
+type LoginLimiterKeyFromClaims struct {
+	Claims *AccessClaims
+}
+
+func NewLoginLimiterKeyFromClaims(claims *AccessClaims) *LoginLimiterKeyFromClaims {
+	return &LoginLimiterKeyFromClaims{Claims: claims}
+}
+
+func (obj *LoginLimiterKeyFromClaims) Key() string {
+	return fmt.Sprintf("user-%d", obj.Claims.LoginID)
+}
+
 func NewLoginLimiter(prefix string, limit string, redisURL string) (*LoginLimiter, error) {
 	// Define a limit rate to 4 requests per hour.
 	rate, err := limiter.NewRateFromFormatted(limit)
@@ -196,3 +273,28 @@ func RateLimitMiddleware(ll *LoginLimiter) func(h http.Handler) http.Handler {
 		})
 	}
 }
+
+// RateLimitPerAccountMiddleware limits the requests of each account, it
+// requires valid access claims.
+func RateLimitPerAccountMiddleware(ll *LoginLimiter) func(h http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*AccessClaims)
+
+			context, err := ll.Get(r, NewLoginLimiterKeyFromClaims(accessClaims))
+			if err != nil {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+
+			ll.WriteHeaders(w, context)
+			if context.Reached {
+				http.Error(w, "Limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,447 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package authenticate
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexedwards/scs"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/configuration"
+)
+
+// OIDCProvider implements the authorization code flow with PKCE against an
+// OpenID Connect identity provider.
+type OIDCProvider struct {
+	Config      *configuration.OIDCConfiguration
+	RedirectURL string
+	Client      *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+}
+
+// OIDCFlow holds the secrets of a single login attempt. They are kept in the
+// session of the browser between the redirect to the identity provider and the
+// callback.
+type OIDCFlow struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// OIDCIdentity is the identity asserted by a verified ID token.
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	StudentNumber string
+	Root          bool
+	// MultiFactor is true when the identity provider checked a second factor.
+	MultiFactor bool
+}
+
+// NewOIDCProvider creates a provider. The discovery document is fetched
+// lazily on first use.
+func NewOIDCProvider(config *configuration.OIDCConfiguration, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{
+		Config:      config,
+		RedirectURL: redirectURL,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewOIDCFlow generates fresh random state, nonce and PKCE code verifier.
+func NewOIDCFlow() OIDCFlow {
+	return OIDCFlow{
+		State:        auth.GenerateToken(16),
+		Nonce:        auth.GenerateToken(16),
+		CodeVerifier: auth.GenerateToken(32),
+	}
+}
+
+// CodeChallenge is the S256 PKCE challenge of the code verifier.
+func (f OIDCFlow) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(f.CodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// WriteToSession stores the flow in the session cookie.
+func (f OIDCFlow) WriteToSession(manager *scs.Manager, w http.ResponseWriter, r *http.Request) error {
+	session := manager.Load(r)
+	if err := session.PutString(w, "oidc_state", f.State); err != nil {
+		return err
+	}
+	if err := session.PutString(w, "oidc_nonce", f.Nonce); err != nil {
+		return err
+	}
+	return session.PutString(w, "oidc_code_verifier", f.CodeVerifier)
+}
+
+// PopOIDCFlowFromSession reads and removes the flow from the session cookie,
+// such that each flow can be completed only once.
+func PopOIDCFlowFromSession(manager *scs.Manager, w http.ResponseWriter, r *http.Request) (*OIDCFlow, error) {
+	session := manager.Load(r)
+
+	flow := &OIDCFlow{}
+	var err error
+	if flow.State, err = session.PopString(w, "oidc_state"); err != nil {
+		return nil, err
+	}
+	if flow.Nonce, err = session.PopString(w, "oidc_nonce"); err != nil {
+		return nil, err
+	}
+	if flow.CodeVerifier, err = session.PopString(w, "oidc_code_verifier"); err != nil {
+		return nil, err
+	}
+	if flow.State == "" || flow.Nonce == "" || flow.CodeVerifier == "" {
+		return nil, errors.New("no single sign-on login in progress")
+	}
+	return flow, nil
+}
+
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.Config.Issuer, "/") + "/.well-known/openid-configuration"
+	doc := &oidcDiscovery{}
+	if err := p.getJSON(ctx, wellKnown, doc); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(p.Config.Issuer, "/") {
+		return nil, fmt.Errorf("issuer %q in discovery document does not match %q", doc.Issuer, p.Config.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, errors.New("discovery document is incomplete")
+	}
+
+	p.discovery = doc
+	return doc, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+// AuthCodeURL returns the url of the identity provider the browser should be
+// redirected to.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, flow OIDCFlow) (string, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.Config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.Config.ClientID)
+	params.Set("redirect_uri", p.RedirectURL)
+	params.Set("scope", strings.Join(scopes, " "))
+	params.Set("state", flow.State)
+	params.Set("nonce", flow.Nonce)
+	params.Set("code_challenge", flow.CodeChallenge())
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange trades the authorization code for tokens and returns the identity
+// from the verified ID token.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, flow OIDCFlow) (*OIDCIdentity, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.Config.ClientID)
+	form.Set("code_verifier", flow.CodeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.Config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	tokens := &oidcTokenResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(tokens); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %s: %s", resp.Status, tokens.Error)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response contains no id_token")
+	}
+
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, flow.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return p.IdentityFromClaims(claims)
+}
+
+// VerifyIDToken checks signature, issuer, audience, expiry and nonce of an ID
+// token.
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, idToken string, nonce string) (jwt.MapClaims, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}))
+	_, err = parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, doc, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyIssuer(doc.Issuer, true) {
+		return nil, errors.New("id token has wrong issuer")
+	}
+	if !claims.VerifyAudience(p.Config.ClientID, true) {
+		return nil, errors.New("id token has wrong audience")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return nil, errors.New("id token is expired")
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("id token has wrong nonce")
+	}
+	return claims, nil
+}
+
+// publicKey returns the signing key with the given id. Unknown keys trigger a
+// single refetch of the key set to follow key rotations.
+func (p *OIDCProvider) publicKey(ctx context.Context, doc *oidcDiscovery, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+
+	set := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	if err := p.getJSON(ctx, doc.JwksURI, &set); err != nil {
+		return nil, err
+	}
+
+	p.keys = make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		p.keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *OIDCProvider) lookupKey(kid string) *rsa.PublicKey {
+	if key, ok := p.keys[kid]; ok {
+		return key
+	}
+	// providers with a single key often omit the key id
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// IdentityFromClaims maps the configured claims onto an identity.
+func (p *OIDCProvider) IdentityFromClaims(claims jwt.MapClaims) (*OIDCIdentity, error) {
+	identity := &OIDCIdentity{
+		Subject:       claimString(claims, "sub"),
+		Email:         strings.ToLower(claimString(claims, p.Config.Claims.Email)),
+		EmailVerified: claimBool(claims, "email_verified"),
+		FirstName:     claimString(claims, p.Config.Claims.FirstName),
+		LastName:      claimString(claims, p.Config.Claims.LastName),
+		StudentNumber: claimString(claims, p.Config.Claims.StudentNumber),
+	}
+
+	if identity.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+	if identity.Email == "" {
+		return nil, errors.New("id token has no email address")
+	}
+
+	for _, value := range claimStrings(claims, p.Config.Claims.Root) {
+		for _, rootValue := range p.Config.RootValues {
+			if value == rootValue {
+				identity.Root = true
+			}
+		}
+	}
+
+	for _, value := range claimStrings(claims, "amr") {
+		for _, multiFactorValue := range p.Config.MultiFactorValues {
+			if value == multiFactorValue {
+				identity.MultiFactor = true
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+// claimBool returns a claim as bool. Some providers send "true" as string.
+func claimBool(claims jwt.MapClaims, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+// claimString returns a claim as string. Numbers are common for student
+// numbers and are formatted without exponent.
+func claimString(claims jwt.MapClaims, name string) string {
+	if name == "" {
+		return ""
+	}
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case bool, json.Number:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// claimStrings returns a claim which is either a single value or a list of
+// values (like groups or roles) as list of strings.
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	if name == "" {
+		return nil
+	}
+	if list, ok := claims[name].([]interface{}); ok {
+		values := []string{}
+		for _, item := range list {
+			values = append(values, claimString(jwt.MapClaims{name: item}, name))
+		}
+		return values
+	}
+	if value := claimString(claims, name); value != "" {
+		return []string{value}
+	}
+	return nil
+}
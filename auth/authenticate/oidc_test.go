@@ -0,0 +1,215 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package authenticate
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/infomark-org/infomark/auth/authenticate/oidctest"
+	"github.com/infomark-org/infomark/configuration"
+)
+
+// authorize follows the redirect to the mock issuer and returns the code and
+// state of the callback.
+func authorize(p *OIDCProvider, flow OIDCFlow) (string, string, error) {
+	authURL, err := p.AuthCodeURL(context.Background(), flow)
+	if err != nil {
+		return "", "", err
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func TestOIDC(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	var issuer *oidctest.Issuer
+	var provider *OIDCProvider
+
+	g.Describe("OIDC", func() {
+
+		g.BeforeEach(func() {
+			issuer = oidctest.NewIssuer("infomark", "secret")
+			issuer.Claims = map[string]interface{}{
+				"sub":            "user-1",
+				"email":          "Jane.Doe@uni-tuebingen.de",
+				"email_verified": true,
+				"given_name":     "Jane",
+				"family_name":    "Doe",
+				"matrikel":       float64(4012345),
+				"groups":         []interface{}{"students", "infomark-admins"},
+			}
+
+			config := &configuration.OIDCConfiguration{
+				Enabled:      true,
+				Issuer:       issuer.URL(),
+				ClientID:     "infomark",
+				ClientSecret: "secret",
+				RootValues:   []string{"infomark-admins"},
+
+				MultiFactorValues: []string{"mfa"},
+			}
+			config.Claims.FirstName = "given_name"
+			config.Claims.LastName = "family_name"
+			config.Claims.Email = "email"
+			config.Claims.StudentNumber = "matrikel"
+			config.Claims.Root = "groups"
+
+			provider = NewOIDCProvider(config, "http://infomark.local/api/v1/auth/oidc/callback")
+		})
+
+		g.AfterEach(func() {
+			issuer.Close()
+		})
+
+		g.It("Should complete the authorization code flow with PKCE", func() {
+			flow := NewOIDCFlow()
+			code, state, err := authorize(provider, flow)
+			g.Assert(err).Equal(nil)
+			g.Assert(state).Equal(flow.State)
+
+			identity, err := provider.Exchange(context.Background(), code, flow)
+			g.Assert(err).Equal(nil)
+			g.Assert(identity.Subject).Equal("user-1")
+			g.Assert(identity.Email).Equal("jane.doe@uni-tuebingen.de")
+			g.Assert(identity.EmailVerified).Equal(true)
+			g.Assert(identity.FirstName).Equal("Jane")
+			g.Assert(identity.LastName).Equal("Doe")
+			g.Assert(identity.StudentNumber).Equal("4012345")
+			g.Assert(identity.Root).Equal(true)
+		})
+
+		g.It("Should not grant root without matching group", func() {
+			issuer.Claims["groups"] = []interface{}{"students"}
+
+			flow := NewOIDCFlow()
+			code, _, err := authorize(provider, flow)
+			g.Assert(err).Equal(nil)
+
+			identity, err := provider.Exchange(context.Background(), code, flow)
+			g.Assert(err).Equal(nil)
+			g.Assert(identity.Root).Equal(false)
+		})
+
+		g.It("Should read whether the email address is verified", func() {
+			for value, expected := range map[interface{}]bool{
+				true: true, "true": true, false: false, "false": false, nil: false,
+			} {
+				identity, err := provider.IdentityFromClaims(jwt.MapClaims{
+					"sub": "user-1", "email": "jane.doe@uni-tuebingen.de", "email_verified": value,
+				})
+				g.Assert(err).Equal(nil)
+				g.Assert(identity.EmailVerified).Equal(expected)
+			}
+		})
+
+		g.It("Should read whether a second factor was checked", func() {
+			identity, err := provider.IdentityFromClaims(jwt.MapClaims{
+				"sub": "user-1", "email": "jane.doe@uni-tuebingen.de", "amr": []interface{}{"pwd"},
+			})
+			g.Assert(err).Equal(nil)
+			g.Assert(identity.MultiFactor).Equal(false)
+
+			identity, err = provider.IdentityFromClaims(jwt.MapClaims{
+				"sub": "user-1", "email": "jane.doe@uni-tuebingen.de", "amr": []interface{}{"pwd", "mfa"},
+			})
+			g.Assert(err).Equal(nil)
+			g.Assert(identity.MultiFactor).Equal(true)
+		})
+
+		g.It("Should reject a wrong code verifier", func() {
+			flow := NewOIDCFlow()
+			code, _, err := authorize(provider, flow)
+			g.Assert(err).Equal(nil)
+
+			flow.CodeVerifier = "something-else"
+			_, err = provider.Exchange(context.Background(), code, flow)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should reject a wrong nonce", func() {
+			flow := NewOIDCFlow()
+			code, _, err := authorize(provider, flow)
+			g.Assert(err).Equal(nil)
+
+			flow.Nonce = "replayed"
+			_, err = provider.Exchange(context.Background(), code, flow)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should reject tokens for other clients or expired tokens", func() {
+			now := time.Now().Unix()
+			token := issuer.SignIDToken(jwt.MapClaims{
+				"iss": issuer.URL(), "aud": "other", "sub": "user-1",
+				"exp": now + 60, "nonce": "n",
+			})
+			_, err := provider.VerifyIDToken(context.Background(), token, "n")
+			g.Assert(err == nil).IsFalse()
+
+			token = issuer.SignIDToken(jwt.MapClaims{
+				"iss": issuer.URL(), "aud": "infomark", "sub": "user-1",
+				"exp": now - 60, "nonce": "n",
+			})
+			_, err = provider.VerifyIDToken(context.Background(), token, "n")
+			g.Assert(err == nil).IsFalse()
+
+			token = issuer.SignIDToken(jwt.MapClaims{
+				"iss": issuer.URL(), "aud": []interface{}{"infomark"}, "sub": "user-1",
+				"exp": now + 60, "nonce": "n",
+			})
+			_, err = provider.VerifyIDToken(context.Background(), token, "n")
+			g.Assert(err).Equal(nil)
+		})
+
+		g.It("Should reject tokens signed by an unknown key", func() {
+			other := oidctest.NewIssuer("infomark", "secret")
+			defer other.Close()
+
+			token := other.SignIDToken(jwt.MapClaims{
+				"iss": issuer.URL(), "aud": "infomark", "sub": "user-1",
+				"exp": time.Now().Unix() + 60, "nonce": "n",
+			})
+			_, err := provider.VerifyIDToken(context.Background(), token, "n")
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+}
@@ -0,0 +1,226 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package oidctest provides a local OpenID Connect identity provider to test
+// the single sign-on without an external service.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// Issuer is a minimal identity provider. Its authorization endpoint
+// immediately redirects back with a code, as if the user had logged in.
+type Issuer struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+	// Claims are added to every issued ID token.
+	Claims map[string]interface{}
+
+	key  *rsa.PrivateKey
+	kid  string
+	mu   sync.Mutex
+	auth map[string]authorization
+}
+
+type authorization struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// NewIssuer starts a new identity provider. Call Close when done.
+func NewIssuer(clientID string, clientSecret string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	iss := &Issuer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Claims:       map[string]interface{}{},
+		key:          key,
+		kid:          "test-key",
+		auth:         map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", iss.discoveryHandler)
+	mux.HandleFunc("/jwks", iss.jwksHandler)
+	mux.HandleFunc("/authorize", iss.authorizeHandler)
+	mux.HandleFunc("/token", iss.tokenHandler)
+	iss.Server = httptest.NewServer(mux)
+	return iss
+}
+
+// URL is the issuer identifier.
+func (iss *Issuer) URL() string {
+	return iss.Server.URL
+}
+
+// Close shuts down the server.
+func (iss *Issuer) Close() {
+	iss.Server.Close()
+}
+
+// SignIDToken signs arbitrary claims with the key of the issuer.
+func (iss *Issuer) SignIDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = iss.kid
+	signed, err := token.SignedString(iss.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (iss *Issuer) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (iss *Issuer) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	iss.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                iss.URL(),
+		"authorization_endpoint":                iss.URL() + "/authorize",
+		"token_endpoint":                        iss.URL() + "/token",
+		"jwks_uri":                              iss.URL() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (iss *Issuer) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	pub := iss.key.PublicKey
+	iss.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": iss.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (iss *Issuer) authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != iss.ClientID || q.Get("response_type") != "code" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "pkce required", http.StatusBadRequest)
+		return
+	}
+
+	code := fmt.Sprintf("code-%d", time.Now().UnixNano())
+	iss.mu.Lock()
+	iss.auth[code] = authorization{
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+	}
+	iss.mu.Unlock()
+
+	target, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := target.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (iss *Issuer) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		iss.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != iss.ClientID || clientSecret != iss.ClientSecret {
+		iss.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	iss.mu.Lock()
+	authz, ok := iss.auth[code]
+	delete(iss.auth, code)
+	iss.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != authz.redirectURI {
+		iss.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != authz.codeChallenge {
+		iss.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now().Unix()
+	claims := jwt.MapClaims{
+		"iss":   iss.URL(),
+		"aud":   iss.ClientID,
+		"iat":   now,
+		"exp":   now + 300,
+		"nonce": authz.nonce,
+	}
+	for k, v := range iss.Claims {
+		claims[k] = v
+	}
+
+	iss.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     iss.SignIDToken(claims),
+	})
+}
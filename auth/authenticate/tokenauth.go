@@ -32,14 +32,19 @@ type TokenAuth struct {
 	JwtAuth          *jwtauth.JWTAuth
 	JwtAccessExpiry  time.Duration
 	JwtRefreshExpiry time.Duration
+	JwtJobExpiry     time.Duration
+	// JwtImpersonationExpiry limits tokens of root users acting as another user.
+	JwtImpersonationExpiry time.Duration
 }
 
 // NewTokenAuth configures and returns a JWT authentication instance.
 func NewTokenAuth(config *configuration.AuthenticationConfiguration) *TokenAuth {
 	return &TokenAuth{
-		JwtAuth:          jwtauth.New("HS256", []byte(config.JWT.Secret), nil),
-		JwtAccessExpiry:  config.JWT.AccessExpiry,
-		JwtRefreshExpiry: config.JWT.RefreshExpiry,
+		JwtAuth:                jwtauth.New("HS256", []byte(config.JWT.Secret), nil),
+		JwtAccessExpiry:        config.JWT.AccessExpiry,
+		JwtRefreshExpiry:       config.JWT.RefreshExpiry,
+		JwtJobExpiry:           config.JWT.JobExpiry,
+		JwtImpersonationExpiry: config.JWT.ImpersonationExpiry,
 	}
 
 }
@@ -67,3 +72,29 @@ func (a *TokenAuth) CreateRefreshJWT(claims RefreshClaims) (string, error) {
 	_, tokenString, err := a.JwtAuth.Encode(claims.ToMap())
 	return tokenString, err
 }
+
+// CreateJobJWT returns a short-living access token which is only valid for a
+// single testing job.
+func (a *TokenAuth) CreateJobJWT(scope JobScope) (string, error) {
+	now := time.Now().UTC()
+
+	claims := NewJobAccessClaims(scope)
+	claims.StandardClaims.IssuedAt = now.Unix()
+	claims.StandardClaims.ExpiresAt = now.Add(a.JwtJobExpiry).Unix()
+
+	_, tokenString, err := a.JwtAuth.Encode(claims.ToMap())
+	return tokenString, err
+}
+
+// CreateImpersonationJWT returns a short-living access token for a root user
+// to act as another user. It cannot be refreshed.
+func (a *TokenAuth) CreateImpersonationJWT(claims AccessClaims) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(a.JwtImpersonationExpiry)
+
+	claims.StandardClaims.IssuedAt = now.Unix()
+	claims.StandardClaims.ExpiresAt = expiresAt.Unix()
+
+	_, tokenString, err := a.JwtAuth.Encode(claims.ToMap())
+	return tokenString, expiresAt, err
+}
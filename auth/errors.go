@@ -82,4 +82,12 @@ var (
 	// ErrUnauthorized returns status 403 Forbidden for unauthorized request.
 	// e.g. "User doesn't have enough privilege"
 	ErrUnauthorized = &ErrResponse{HTTPStatusCode: http.StatusForbidden, StatusText: http.StatusText(http.StatusForbidden)}
+
+	// ErrTwoFactorSetupRequired returns status 403 Forbidden for requests of
+	// accounts which have to set up two-factor authentication first.
+	ErrTwoFactorSetupRequired = &ErrResponse{
+		HTTPStatusCode: http.StatusForbidden,
+		StatusText:     http.StatusText(http.StatusForbidden),
+		ErrorText:      "two-factor authentication has to be set up",
+	}
 )
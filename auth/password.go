@@ -21,22 +21,153 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword uses bcrypt to securely hash a plain password
-func HashPassword(plainPassword string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
-	return string(bytes), err
+// Algorithms for password hashes. Each hash starts with an identifier of its
+// algorithm and includes its parameters, so hashes created with different
+// settings can be checked side by side.
+const (
+	HashAlgorithmBcrypt   = "bcrypt"
+	HashAlgorithmArgon2id = "argon2id"
+)
+
+// Argon2idParameters are the costs of argon2id. Memory is given in KiB.
+type Argon2idParameters struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// PasswordHasher creates password hashes with one algorithm and fixed
+// parameters.
+type PasswordHasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParameters
+}
+
+// HashAlgorithm returns the algorithm of a stored hash or an empty string if
+// the format is unknown.
+func HashAlgorithm(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return HashAlgorithmBcrypt
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return HashAlgorithmArgon2id
+	default:
+		return ""
+	}
+}
+
+// Hash securely hashes a plain password. Argon2id hashes use the format
+// "$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>".
+func (h *PasswordHasher) Hash(plainPassword string) (string, error) {
+	switch h.Algorithm {
+	case HashAlgorithmBcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(plainPassword), h.BcryptCost)
+		return string(bytes), err
+
+	case HashAlgorithmArgon2id:
+		params := h.Argon2id
+		if params.Iterations == 0 || params.Parallelism == 0 || params.KeyLength == 0 {
+			return "", fmt.Errorf("invalid argon2id parameters")
+		}
+		salt := make([]byte, params.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(plainPassword), salt,
+			params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key)), nil
+
+	default:
+		return "", fmt.Errorf("unknown password hashing algorithm %q", h.Algorithm)
+	}
+}
+
+// NeedsRehash tells whether a stored hash was created with another algorithm
+// or other parameters than the ones of the hasher.
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	algorithm := HashAlgorithm(hash)
+	if algorithm != h.Algorithm {
+		return true
+	}
+
+	switch algorithm {
+	case HashAlgorithmBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.BcryptCost
+
+	case HashAlgorithmArgon2id:
+		params, _, _, err := parseArgon2idHash(hash)
+		return err != nil || params != h.Argon2id
+	}
+	return true
+}
+
+// parseArgon2idHash splits an argon2id hash into its parameters, the salt and
+// the derived key.
+func parseArgon2idHash(hash string) (params Argon2idParameters, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d",
+		&params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, err
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
 }
 
 // CheckPasswordHash tests whether a given plainPassword matches the securely
-// hashed one.
+// hashed one. The algorithm is taken from the hash.
 func CheckPasswordHash(plainPassword, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plainPassword))
-	return err == nil
+	switch HashAlgorithm(hash) {
+	case HashAlgorithmBcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plainPassword))
+		return err == nil
+
+	case HashAlgorithmArgon2id:
+		params, salt, key, err := parseArgon2idHash(hash)
+		if err != nil || params.Iterations == 0 || params.Parallelism == 0 || params.KeyLength == 0 {
+			return false
+		}
+		other := argon2.IDKey([]byte(plainPassword), salt,
+			params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+	return false
 }
 
 // GenerateToken generates a random string with a specific length
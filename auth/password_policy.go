@@ -0,0 +1,153 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed" // bundled list of common passwords
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+//go:embed common_passwords.txt
+var bundledCommonPasswords []byte
+
+// PasswordPolicy describes which passwords users are allowed to choose.
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	CharacterClasses int
+	BanPersonalData  bool
+	// Common contains lowercased passwords which are rejected.
+	Common map[string]bool
+}
+
+// ReadCommonPasswords parses a list with one password per line. Empty lines
+// and lines starting with "#" are ignored.
+func ReadCommonPasswords(r io.Reader) (map[string]bool, error) {
+	passwords := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = true
+	}
+	return passwords, scanner.Err()
+}
+
+// BundledCommonPasswords returns the list of common passwords shipped with
+// InfoMark.
+func BundledCommonPasswords() map[string]bool {
+	// the bundled file is known to be readable
+	passwords, _ := ReadCommonPasswords(bytes.NewReader(bundledCommonPasswords))
+	return passwords
+}
+
+// characterClasses counts lowercase letters, uppercase letters, digits and
+// all other characters as different classes.
+func characterClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = 1
+		case unicode.IsUpper(c):
+			upper = 1
+		case unicode.IsDigit(c):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+// personalFragments splits names and email addresses into the parts a
+// password must not contain. Only the local part of an email address is used
+// and parts shorter than three characters are too unspecific to be banned.
+func personalFragments(personal []string) []string {
+	fragments := []string{}
+	for _, text := range personal {
+		text = strings.ToLower(strings.TrimSpace(text))
+		if at := strings.LastIndex(text, "@"); at >= 0 {
+			text = text[:at]
+			if len(text) >= 3 {
+				fragments = append(fragments, text)
+			}
+		}
+		for _, part := range strings.FieldsFunc(text, func(c rune) bool {
+			return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+		}) {
+			if len(part) >= 3 {
+				fragments = append(fragments, part)
+			}
+		}
+	}
+	return fragments
+}
+
+// Check tests a password against the policy. Personal data are the names and
+// the email address of the user. All violated rules are reported as
+// validation.Errors keyed by a short code, or nil if the password is fine.
+func (p *PasswordPolicy) Check(password string, personal ...string) error {
+	errs := validation.Errors{}
+
+	if len(password) < p.MinLength {
+		errs["too_short"] = fmt.Errorf("must be at least %d characters long", p.MinLength)
+	}
+
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		errs["too_long"] = fmt.Errorf("must be at most %d characters long", p.MaxLength)
+	}
+
+	if characterClasses(password) < p.CharacterClasses {
+		errs["character_classes"] = fmt.Errorf(
+			"must contain characters of at least %d classes (lowercase, uppercase, digits, symbols)",
+			p.CharacterClasses)
+	}
+
+	lower := strings.ToLower(password)
+
+	if p.BanPersonalData {
+		for _, fragment := range personalFragments(personal) {
+			if strings.Contains(lower, fragment) {
+				errs["personal_data"] = errors.New("must not contain your name or email address")
+				break
+			}
+		}
+	}
+
+	if p.Common[lower] {
+		errs["common"] = errors.New("is too common")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
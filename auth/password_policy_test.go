@@ -0,0 +1,88 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+func violations(err error) []string {
+	codes := []string{}
+	if errs, ok := err.(validation.Errors); ok {
+		for code := range errs {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+func TestPasswordPolicy(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	g.Describe("PasswordPolicy", func() {
+
+		policy := &PasswordPolicy{
+			MinLength:        8,
+			MaxLength:        72,
+			CharacterClasses: 3,
+			BanPersonalData:  true,
+			Common:           BundledCommonPasswords(),
+		}
+
+		g.It("Should accept a good password", func() {
+			g.Assert(policy.Check("Tr0mbone-Harbor", "Max", "Mustermann", "max.mustermann@uni-tuebingen.de")).Equal(nil)
+		})
+
+		g.It("Should report length violations", func() {
+			g.Assert(violations(policy.Check("Ab1-"))).Equal([]string{"too_short"})
+			g.Assert(violations(policy.Check("Ab1-" + strings.Repeat("x", 72)))).Equal([]string{"too_long"})
+		})
+
+		g.It("Should count character classes", func() {
+			g.Assert(characterClasses("abc")).Equal(1)
+			g.Assert(characterClasses("aBc")).Equal(2)
+			g.Assert(characterClasses("aB1")).Equal(3)
+			g.Assert(characterClasses("aB1-")).Equal(4)
+			g.Assert(violations(policy.Check("tromboneharbor"))).Equal([]string{"character_classes"})
+		})
+
+		g.It("Should reject names and the email address", func() {
+			g.Assert(violations(policy.Check("Mustermann-2019", "Max", "Mustermann", "mm@uni-tuebingen.de"))).Equal([]string{"personal_data"})
+			g.Assert(violations(policy.Check("Kleinmax.Mustermann1", "", "", "max.mustermann@uni-tuebingen.de"))).Equal([]string{"personal_data"})
+			// too short to be banned and the domain is not personal
+			g.Assert(policy.Check("Tuebingen-Ox-1", "Ox", "", "ox@uni-tuebingen.de")).Equal(nil)
+		})
+
+		g.It("Should reject common passwords case-insensitively", func() {
+			g.Assert(violations(policy.Check("Password123"))).Equal([]string{"common"})
+		})
+
+		g.It("Should read lists of common passwords", func() {
+			list, err := ReadCommonPasswords(strings.NewReader("# comment\n\nHunter2\n  letmein  \n"))
+			g.Assert(err).Equal(nil)
+			g.Assert(list).Equal(map[string]bool{"hunter2": true, "letmein": true})
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/franela/goblin"
+)
+
+func TestPasswordHasher(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	g.Describe("PasswordHasher", func() {
+
+		argon2id := &PasswordHasher{
+			Algorithm: HashAlgorithmArgon2id,
+			Argon2id: Argon2idParameters{
+				Memory:      1024,
+				Iterations:  2,
+				Parallelism: 1,
+				SaltLength:  16,
+				KeyLength:   32,
+			},
+		}
+		bcrypt := &PasswordHasher{Algorithm: HashAlgorithmBcrypt, BcryptCost: 4}
+
+		g.It("Should store the algorithm with the hash", func() {
+			hash, err := argon2id.Hash("secret")
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=2,p=1$")).IsTrue()
+			g.Assert(HashAlgorithm(hash)).Equal(HashAlgorithmArgon2id)
+
+			hash, err = bcrypt.Hash("secret")
+			g.Assert(err).Equal(nil)
+			g.Assert(HashAlgorithm(hash)).Equal(HashAlgorithmBcrypt)
+
+			g.Assert(HashAlgorithm("secret")).Equal("")
+		})
+
+		g.It("Should check hashes of both algorithms", func() {
+			for _, hasher := range []*PasswordHasher{argon2id, bcrypt} {
+				hash, err := hasher.Hash("secret")
+				g.Assert(err).Equal(nil)
+				g.Assert(CheckPasswordHash("secret", hash)).IsTrue()
+				g.Assert(CheckPasswordHash("Secret", hash)).IsFalse()
+			}
+			g.Assert(CheckPasswordHash("secret", "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5")).IsFalse()
+			g.Assert(CheckPasswordHash("secret", "secret")).IsFalse()
+		})
+
+		g.It("Should detect outdated hashes", func() {
+			hash, _ := argon2id.Hash("secret")
+			g.Assert(argon2id.NeedsRehash(hash)).IsFalse()
+			g.Assert(bcrypt.NeedsRehash(hash)).IsTrue()
+
+			stronger := *argon2id
+			stronger.Argon2id.Iterations = 3
+			g.Assert(stronger.NeedsRehash(hash)).IsTrue()
+
+			hash, _ = bcrypt.Hash("secret")
+			g.Assert(bcrypt.NeedsRehash(hash)).IsFalse()
+			g.Assert(argon2id.NeedsRehash(hash)).IsTrue()
+
+			costlier := *bcrypt
+			costlier.BcryptCost = 5
+			g.Assert(costlier.NeedsRehash(hash)).IsTrue()
+		})
+
+		g.It("Should reject unknown algorithms", func() {
+			_, err := (&PasswordHasher{Algorithm: "md5"}).Hash("secret")
+			g.Assert(err != nil).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,85 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+)
+
+func TestTOTP(t *testing.T) {
+
+	g := goblin.Goblin(t)
+
+	// the secret "12345678901234567890" of the test vectors in RFC 6238
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	g.Describe("TOTP", func() {
+
+		g.It("Should match the test vectors", func() {
+			for unix, expected := range map[int64]string{
+				59:         "287082",
+				1111111109: "081804",
+				1234567890: "005924",
+				2000000000: "279037",
+			} {
+				code, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+				g.Assert(err).Equal(nil)
+				g.Assert(code).Equal(expected)
+			}
+		})
+
+		g.It("Should accept codes within the allowed skew only once", func() {
+			now := time.Unix(1234567890, 0)
+			code, _ := TOTPCode(secret, TOTPStep(now)-1)
+
+			step, ok := ValidateTOTP(secret, code, now, 0)
+			g.Assert(ok).Equal(true)
+			g.Assert(step).Equal(TOTPStep(now) - 1)
+
+			_, ok = ValidateTOTP(secret, code, now, step)
+			g.Assert(ok).Equal(false)
+
+			_, ok = ValidateTOTP(secret, code, now.Add(3*TOTPPeriod*time.Second), 0)
+			g.Assert(ok).Equal(false)
+
+			_, ok = ValidateTOTP(secret, "12345", now, 0)
+			g.Assert(ok).Equal(false)
+		})
+
+		g.It("Should generate secrets, uris and recovery codes", func() {
+			generated := GenerateTOTPSecret()
+			g.Assert(len(generated)).Equal(32)
+			_, err := TOTPCode(generated, 1)
+			g.Assert(err).Equal(nil)
+
+			uri := TOTPURI("InfoMark", "jane doe@uni.de", generated)
+			g.Assert(strings.HasPrefix(uri, "otpauth://totp/InfoMark:jane%20doe@uni.de?")).Equal(true)
+			g.Assert(strings.Contains(uri, "secret="+generated)).Equal(true)
+
+			code := GenerateRecoveryCode()
+			g.Assert(len(code)).Equal(14)
+			g.Assert(code == GenerateRecoveryCode()).Equal(false)
+		})
+	})
+}
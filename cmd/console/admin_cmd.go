@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/infomark-org/infomark/api/app"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/spf13/cobra"
 )
@@ -68,8 +69,9 @@ var AdminAddCmd = &cobra.Command{
 var AdminRemoveCmd = &cobra.Command{
 	Use:   "remove [userID]",
 	Short: "removes global admin permission from a user",
-	Long:  `Will set the gobal root flag to false for a user `,
-	Args:  cobra.ExactArgs(1),
+	Long: `Will set the gobal root flag to false for a user
+and end all of their logins`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		userID := MustInt64Parameter(args[0], "userID")
 
@@ -86,6 +88,11 @@ var AdminRemoveCmd = &cobra.Command{
 			panic(err)
 		}
 
+		// logins still carry the root flag until they end
+		if err := app.RevokeLoginSessions(stores, user.ID, 0); err != nil {
+			panic(err)
+		}
+
 		fmt.Printf("user %s %s (%v) is not an admin anymore\n", user.FirstName, user.LastName, user.ID)
 
 	},
@@ -105,13 +105,42 @@ func GenerateExampleConfiguration(domain string, root_path string) *configuratio
 	config.Server.Authentication.JWT.Secret = auth.GenerateToken(32)
 	config.Server.Authentication.JWT.AccessExpiry = 15 * time.Minute
 	config.Server.Authentication.JWT.RefreshExpiry = DurationFromString("10h")
+	config.Server.Authentication.JWT.JobExpiry = DurationFromString("30m")
+	config.Server.Authentication.JWT.ImpersonationExpiry = DurationFromString("30m")
 	config.Server.Authentication.Session.Secret = auth.GenerateToken(32)
 	config.Server.Authentication.Session.Cookies.Secure = config.Server.HTTP.UseHTTPS
 	config.Server.Authentication.Session.Cookies.Lifetime = DurationFromString("24h")
 	config.Server.Authentication.Session.Cookies.IdleTimeout = DurationFromString("60m")
 	config.Server.Authentication.Password.MinLength = 7
+	config.Server.Authentication.Password.MaxLength = 72
+	config.Server.Authentication.Password.CharacterClasses = 1
+	config.Server.Authentication.Password.BanPersonalData = true
+	config.Server.Authentication.Password.CommonPasswordsFile = ""
+	config.Server.Authentication.Password.Hashing.Algorithm = "argon2id"
+	config.Server.Authentication.Password.Hashing.BcryptCost = 10
+	config.Server.Authentication.Password.Hashing.Argon2id.Memory = 19456
+	config.Server.Authentication.Password.Hashing.Argon2id.Iterations = 2
+	config.Server.Authentication.Password.Hashing.Argon2id.Parallelism = 1
+	config.Server.Authentication.Password.Hashing.Argon2id.SaltLength = 16
+	config.Server.Authentication.Password.Hashing.Argon2id.KeyLength = 32
+	config.Server.Authentication.TwoFactor.RequiredForStaff = false
+	config.Server.Authentication.TwoFactor.Issuer = "InfoMark"
+	config.Server.Authentication.Lockout.Enabled = true
+	config.Server.Authentication.Lockout.FreeAttempts = 3
+	config.Server.Authentication.Lockout.Delay = DurationFromString("1s")
+	config.Server.Authentication.Lockout.Threshold = 10
+	config.Server.Authentication.Lockout.Duration = DurationFromString("15m")
+	config.Server.Authentication.Lockout.ResetAfter = DurationFromString("24h")
+	config.Server.Authentication.LDAP = []configuration.LDAPConfiguration{}
+	config.Server.Authentication.OIDC.Enabled = false
+	config.Server.Authentication.OIDC.Scopes = []string{"openid", "profile", "email"}
+	config.Server.Authentication.OIDC.Claims.FirstName = "given_name"
+	config.Server.Authentication.OIDC.Claims.LastName = "family_name"
+	config.Server.Authentication.OIDC.Claims.Email = "email"
+	config.Server.Authentication.OIDC.Claims.Root = "groups"
 
 	config.Server.Authentication.TotalRequestsPerMinute = 100
+	config.Server.Authentication.CheckInsPerHour = 20
 	config.Server.Cronjobs.ZipSubmissionsIntervall = DurationFromString("5m")
 
 	config.Server.Email.Send = false
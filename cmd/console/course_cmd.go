@@ -21,14 +21,61 @@ package console
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/infomark-org/infomark/api/app"
+	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
 	"github.com/spf13/cobra"
 )
 
+var (
+	exportGroupID             int64
+	exportColumns             string
+	exportDelimiter           string
+	exportStudentNumberWidth  int
+	exportStudentNumberPrefix string
+	cloneName                 string
+	importCourseID            int64
+	enrollmentsDelimiter      string
+	enrollmentsCreateMissing  bool
+	enrollmentsAllowDowngrade bool
+	enrollmentsDryRun         bool
+)
+
 func init() {
+	CourseExportGrades.Flags().Int64VarP(&exportGroupID, "group", "g", 0, "restrict export to a single group")
+	CourseExportGrades.Flags().StringVarP(&exportColumns, "columns", "c",
+		strings.Join(app.DefaultGradeExportColumns, ","), "comma separated list of columns")
+	CourseExportGrades.Flags().StringVarP(&exportDelimiter, "delimiter", "d", ",", "delimiter for csv files")
+	CourseExportGrades.Flags().IntVarP(&exportStudentNumberWidth, "student-number-width", "w", 0,
+		"pad student numbers with leading zeros to this width")
+	CourseExportGrades.Flags().StringVarP(&exportStudentNumberPrefix, "student-number-prefix", "p", "",
+		"prefix for all student numbers")
+
+	CourseClone.Flags().StringVarP(&cloneName, "name", "n", "", "name of the clone (default: name of the course)")
+
 	CourseCmd.AddCommand(UserEnrollInCourse)
+	CourseCmd.AddCommand(CourseExportGrades)
+	CourseImport.Flags().Int64VarP(&importCourseID, "course", "c", 0,
+		"import into this course instead of the course named in the manifest")
+
+	CourseImportEnrollments.Flags().StringVarP(&enrollmentsDelimiter, "delimiter", "d", ",", "delimiter for csv files")
+	CourseImportEnrollments.Flags().BoolVarP(&enrollmentsCreateMissing, "create-missing", "m", false,
+		"create and invite accounts for unknown emails")
+	CourseImportEnrollments.Flags().BoolVarP(&enrollmentsAllowDowngrade, "allow-downgrade", "a", false,
+		"allow to lower the role of members")
+	CourseImportEnrollments.Flags().BoolVarP(&enrollmentsDryRun, "dry-run", "n", false, "only report what would change")
+
+	CourseCmd.AddCommand(CourseImportEnrollments)
+	CourseCmd.AddCommand(CourseClone)
+	CourseCmd.AddCommand(CourseExport)
+	CourseCmd.AddCommand(CourseImport)
 }
 
 var CourseCmd = &cobra.Command{
@@ -80,3 +127,235 @@ var UserEnrollInCourse = &cobra.Command{
 			user.FirstName, user.LastName, course.ID, role)
 	},
 }
+
+var CourseImportEnrollments = &cobra.Command{
+	Use:   "import-enrollments [courseID] [file]",
+	Short: "enroll many users from a csv file",
+	Long: `reads a csv file with the column "user" (email or student number) and the
+optional columns "role" (student, tutor, admin), "group" (id or description),
+"first_name" and "last_name". Lines which do not match a user are reported.
+Members keep their role if a line states none and are only downgraded with
+--allow-downgrade.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		delimiter := []rune(enrollmentsDelimiter)
+		if len(delimiter) != 1 {
+			log.Fatalf("delimiter '%s' must be a single character\n", enrollmentsDelimiter)
+		}
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		f, err := os.Open(args[1])
+		failWhenSmallestWhiff(err)
+		defer f.Close()
+
+		rows, err := app.ParseEnrollmentImportCSV(f, delimiter[0])
+		failWhenSmallestWhiff(err)
+
+		opts := app.EnrollmentImportOptions{
+			CreateMissing:  enrollmentsCreateMissing,
+			AllowDowngrade: enrollmentsAllowDowngrade,
+		}
+		enrollmentImport, err := app.BuildEnrollmentImport(stores, course, rows, opts)
+		failWhenSmallestWhiff(err)
+
+		if !enrollmentsDryRun {
+			invitations, err := enrollmentImport.Apply(stores, course)
+			failWhenSmallestWhiff(err)
+
+			// the enrollments are written, so failed invitations are only reported
+			for _, msg := range invitations {
+				if err := email.DefaultMail.Send(msg); err != nil {
+					fmt.Printf("cannot invite %s: %v\n", msg.To, err)
+				}
+			}
+		}
+
+		for _, change := range enrollmentImport.Changes {
+			fmt.Printf("line %d: %s (%d) role %d -> %d", change.Line, change.UserEmail,
+				change.UserID, change.OldRole, change.NewRole)
+			if change.Created {
+				fmt.Printf(", created")
+			}
+			if change.GroupID != 0 {
+				fmt.Printf(", group %d", change.GroupID)
+			}
+			fmt.Println()
+		}
+		for _, rowError := range enrollmentImport.Errors {
+			fmt.Printf("line %d: %s\n", rowError.Line, rowError.Message)
+		}
+
+		fmt.Printf("%d enrollments, %d rejected lines in course %s (%d)\n",
+			len(enrollmentImport.Changes), len(enrollmentImport.Errors), course.Name, course.ID)
+	},
+}
+
+var CourseExportGrades = &cobra.Command{
+	Use:   "export-grades [courseID] [file]",
+	Short: "export all grades of a course as csv or xlsx",
+	Long: `writes points per sheet, totals, percentage, pass/fail against the
+required percentage of the course and all exam results of each student.
+The format is derived from the file extension (.csv or .xlsx)`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		format := strings.TrimPrefix(strings.ToLower(filepath.Ext(args[1])), ".")
+		if format != app.GradeExportFormatCSV && format != app.GradeExportFormatXLSX {
+			log.Fatalf("file '%s' must end with .csv or .xlsx\n", args[1])
+		}
+
+		delimiter := []rune(exportDelimiter)
+		if len(delimiter) != 1 {
+			log.Fatalf("delimiter '%s' must be a single character\n", exportDelimiter)
+		}
+
+		opts := app.NewGradeExportOptions()
+		opts.GroupID = exportGroupID
+		opts.Columns = strings.Split(exportColumns, ",")
+		opts.StudentNumberWidth = exportStudentNumberWidth
+		opts.StudentNumberPrefix = exportStudentNumberPrefix
+		if err := opts.Validate(); err != nil {
+			log.Fatalln(err)
+		}
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		export, err := app.BuildGradeExport(stores, course, opts)
+		failWhenSmallestWhiff(err)
+
+		f, err := os.Create(args[1])
+		failWhenSmallestWhiff(err)
+		defer f.Close()
+
+		err = export.Write(f, format, delimiter[0])
+		failWhenSmallestWhiff(err)
+
+		fmt.Printf("exported grades of %d students in course %s (%d) to %s\n",
+			len(export.Rows)-1, course.Name, course.ID, args[1])
+	},
+}
+
+var CourseClone = &cobra.Command{
+	Use:   "clone [courseID] [offsetDays]",
+	Short: "clone a course with all sheets, tasks, materials and exams",
+	Long: `copies the structure and all files of a course into a new course and
+shifts all dates by the given number of days. Enrollments, groups, submissions
+and grades are not copied.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+		offsetDays := MustIntParameter(args[1], "offsetDays")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		name := cloneName
+		if name == "" {
+			name = course.Name
+		}
+
+		clone, err := app.CloneCourse(stores, course, name, offsetDays)
+		failWhenSmallestWhiff(err)
+
+		fmt.Printf("cloned course %s (%d) into course %s (%d)\n",
+			course.Name, course.ID, clone.Name, clone.ID)
+	},
+}
+
+var CourseExport = &cobra.Command{
+	Use:   "export [courseID] [archive]",
+	Short: "export the content of a course into a directory or zip file",
+	Long: `writes a manifest (manifest.yml) with the settings, sheets, tasks, materials
+and exams of a course together with all sheet files, test files and materials.
+If the archive ends with .zip a zip file is written, otherwise a directory.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		dir := args[1]
+		if isZipArchive(args[1]) {
+			dir, err = ioutil.TempDir("", "infomark-course-export")
+			failWhenSmallestWhiff(err)
+			defer os.RemoveAll(dir)
+		}
+
+		err = app.ExportCourseArchive(stores, course, dir)
+		failWhenSmallestWhiff(err)
+
+		if isZipArchive(args[1]) {
+			err = helper.ZipDirectory(dir, args[1])
+			failWhenSmallestWhiff(err)
+		}
+
+		fmt.Printf("exported course %s (%d) to %s\n", course.Name, course.ID, args[1])
+	},
+}
+
+var CourseImport = &cobra.Command{
+	Use:   "import [archive]",
+	Short: "import the content of a course from a directory or zip file",
+	Long: `reads an archive written by "course export". Sheets, tasks, materials and
+exams are matched by name and updated, missing ones are created. Importing the
+same archive again does not change anything. Without --course the course with
+the name from the manifest is used and created if it does not exist.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		dir := args[0]
+		if isZipArchive(args[0]) {
+			dir, err = ioutil.TempDir("", "infomark-course-import")
+			failWhenSmallestWhiff(err)
+			defer os.RemoveAll(dir)
+
+			err = helper.UnzipToDirectory(args[0], dir)
+			failWhenSmallestWhiff(err)
+		}
+
+		course, err := app.ImportCourseArchive(stores, dir, importCourseID)
+		failWhenSmallestWhiff(err)
+
+		fmt.Printf("imported %s into course %s (%d)\n", args[0], course.Name, course.ID)
+	},
+}
+
+func isZipArchive(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".zip"
+}
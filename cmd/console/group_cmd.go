@@ -28,13 +28,19 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/infomark-org/infomark/api/app"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/model"
 	"github.com/lib/pq"
 	"github.com/spf13/cobra"
 )
 
+var assignDryRun bool
+
 func init() {
+	GroupAssign.Flags().BoolVarP(&assignDryRun, "dry-run", "n", false, "only print the assignment")
+
+	GroupCmd.AddCommand(GroupAssign)
 	GroupCmd.AddCommand(GroupReadBids)
 	GroupCmd.AddCommand(GroupParseBidsSolution)
 	GroupCmd.AddCommand(GroupEnroll)
@@ -158,18 +164,7 @@ already enrolled in another group`,
 		failWhenSmallestWhiff(err)
 
 		enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(userID, course.ID)
-
-		if err != nil {
-			// does not exists yet
-			enrollment := &model.GroupEnrollment{
-				UserID:  userID,
-				GroupID: groupID,
-			}
-
-			_, err := stores.Group.CreateGroupEnrollmentOfUserInCourse(enrollment)
-			failWhenSmallestWhiff(err)
-
-		} else {
+		if err == nil {
 			group, err := stores.Group.Get(enrollment.GroupID)
 			failWhenSmallestWhiff(err)
 
@@ -178,17 +173,18 @@ already enrolled in another group`,
 				user.LastName,
 				user.ID,
 				group.ID, group.Description)
-
-			// does exists --> simply change it
-			enrollment.GroupID = groupID
-			err = stores.Group.ChangeGroupEnrollmentOfUserInCourse(enrollment)
-			failWhenSmallestWhiff(err)
-
 		}
 
 		group, err := stores.Group.Get(groupID)
 		failWhenSmallestWhiff(err)
 
+		// creates the enrollment or changes an existing one
+		join, err := stores.Group.JoinGroup(course.ID, groupID, userID, false)
+		failWhenSmallestWhiff(err)
+		if !join.Enrolled {
+			log.Fatalf("group (%v) %s is full (capacity %d)\n", group.ID, group.Description, group.Capacity)
+		}
+
 		fmt.Printf("user %s %s (id: %v) is now enrolled in group (%v) %s\n",
 			user.FirstName,
 			user.LastName,
@@ -513,3 +509,52 @@ AND
 
 	},
 }
+
+var GroupAssign = &cobra.Command{
+	Use:   "assign [courseID] [min_per_group] [max_per_group]",
+	Short: "assign all students of a course to groups according to their bids",
+	Long: `maximizes the sum of all bids such that each group has between
+min_per_group and max_per_group students and replaces all group enrollments
+of the course in a single transaction`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+		minPerGroup := MustIntParameter(args[1], "minPerGroup")
+		maxPerGroup := MustIntParameter(args[2], "maxPerGroup")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		solution, err := app.SolveGroupAssignment(stores, course.ID, minPerGroup, maxPerGroup)
+		failWhenSmallestWhiff(err)
+
+		fmt.Printf(" userID  groupID  bid  previous\n")
+		for _, entry := range solution.Entries {
+			fmt.Printf("%7d  %7d  %3d  %8d\n", entry.UserID, entry.GroupID, entry.Bid, entry.PreviousGroupID)
+		}
+		fmt.Println("")
+
+		fmt.Printf("count   groupID    description\n")
+		for _, group := range solution.Groups {
+			fmt.Printf("%5d  %7d   %s\n", group.Students, group.GroupID, group.Description)
+		}
+		fmt.Println("")
+
+		fmt.Printf("total bid %d, %d of %d students change their group\n",
+			solution.TotalBid, solution.Changed, len(solution.Entries))
+
+		if assignDryRun {
+			fmt.Println("dry run, nothing has been changed")
+			return
+		}
+
+		failWhenSmallestWhiff(solution.Apply(stores, course.ID))
+		fmt.Println("Done")
+	},
+}
@@ -86,20 +86,24 @@ var SubmissionTriggerCmd = &cobra.Command{
 
 		tokenManager := authenticate.NewTokenAuth(&configuration.Configuration.Server.Authentication)
 
-		accessToken, err := tokenManager.CreateAccessJWT(
-			authenticate.NewAccessClaims(1, true))
+		publicToken, err := tokenManager.CreateJobJWT(shared.NewSubmissionJobScope(
+			course.ID, task.ID, submission.ID, grade.ID, "public"))
+		failWhenSmallestWhiff(err)
+
+		privateToken, err := tokenManager.CreateJobJWT(shared.NewSubmissionJobScope(
+			course.ID, task.ID, submission.ID, grade.ID, "private"))
 		failWhenSmallestWhiff(err)
 
 		bodyPublic, err := json.Marshal(shared.NewSubmissionAMQPWorkerRequest(
 			course.ID, task.ID, submission.ID, grade.ID,
-			accessToken, configuration.Configuration.Server.ExternalURL(), task.PublicDockerImage.String, sha256, "public"))
+			publicToken, configuration.Configuration.Server.ExternalURL(), task.PublicDockerImage.String, sha256, "public"))
 		if err != nil {
 			log.Fatalf("json.Marshal: %s", err)
 		}
 
 		bodyPrivate, err := json.Marshal(shared.NewSubmissionAMQPWorkerRequest(
 			course.ID, task.ID, submission.ID, grade.ID,
-			accessToken, configuration.Configuration.Server.ExternalURL(), task.PrivateDockerImage.String, sha256, "private"))
+			privateToken, configuration.Configuration.Server.ExternalURL(), task.PrivateDockerImage.String, sha256, "private"))
 		if err != nil {
 			log.Fatalf("json.Marshal: %s", err)
 		}
@@ -288,8 +292,13 @@ WHERE task_id = $1
 
 			tokenManager := authenticate.NewTokenAuth(&configuration.Configuration.Server.Authentication)
 
-			accessToken, err := tokenManager.CreateAccessJWT(
-				authenticate.NewAccessClaims(1, true))
+			visibility := "private"
+			if args[1] == "public" {
+				visibility = "public"
+			}
+
+			accessToken, err := tokenManager.CreateJobJWT(shared.NewSubmissionJobScope(
+				course.ID, taskID, submissionWithGrade.ID, submissionWithGrade.GradeID, visibility))
 			failWhenSmallestWhiff(err)
 
 			var (
@@ -297,7 +306,7 @@ WHERE task_id = $1
 				merr error
 			)
 
-			if args[1] == "public" {
+			if visibility == "public" {
 				body, merr = json.Marshal(shared.NewSubmissionAMQPWorkerRequest(
 					course.ID, taskID, submissionWithGrade.ID, submissionWithGrade.GradeID,
 					accessToken, configuration.Configuration.Server.ExternalURL(), task.PublicDockerImage.String, sha256, "public"))
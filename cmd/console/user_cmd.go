@@ -22,8 +22,12 @@ package console
 import (
 	"fmt"
 	"log"
+	"sort"
+	"time"
 
 	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/infomark-org/infomark/api/app"
+	"github.com/infomark-org/infomark/auth"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/model"
 	"github.com/spf13/cobra"
@@ -34,6 +38,10 @@ func init() {
 	UserCmd.AddCommand(UserFindCmd)
 	UserCmd.AddCommand(UserConfirmCmd)
 	UserCmd.AddCommand(UserSetEmailCmd)
+	UserCmd.AddCommand(UserResetTwoFactorCmd)
+	UserCmd.AddCommand(UserLockoutsCmd)
+	UserCmd.AddCommand(UserUnlockCmd)
+	UserCmd.AddCommand(UserPasswordHashesCmd)
 }
 
 var UserCmd = &cobra.Command{
@@ -141,3 +149,130 @@ var UserSetEmailCmd = &cobra.Command{
 			user.FirstName, user.LastName, user.Email)
 	},
 }
+
+var UserResetTwoFactorCmd = &cobra.Command{
+	Use:   "reset-2fa [userID]",
+	Short: "disables two-factor authentication of a user",
+	Long: `Will remove the authenticator secret and all recovery codes of a user
+who lost access to them. If two-factor authentication is required, the user has
+to set it up again after the next login.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userID := MustInt64Parameter(args[0], "userID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		user, err := stores.User.Get(userID)
+		if err != nil {
+			log.Fatalf("user with id %v not found\n", userID)
+		}
+
+		if err := app.ResetTwoFactor(stores, user); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("two-factor authentication of user %s %s (id:%v) has been reset\n",
+			user.FirstName, user.LastName, user.ID)
+	},
+}
+
+var UserLockoutsCmd = &cobra.Command{
+	Use:   "lockouts",
+	Short: "list users with recent failed logins",
+	Long: `List all users with failed logins which are not forgotten yet
+and whether they are blocked from logging in right now`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		lockouts, err := stores.LoginLockout.GetAll()
+		failWhenSmallestWhiff(err)
+
+		now := time.Now()
+		for _, lockout := range lockouts {
+			blocked := "-"
+			if until := app.LoginBlockedUntil(&lockout, now); until != nil {
+				blocked = until.Until.Local().Format("2006-01-02 15:04:05")
+				if until.Locked {
+					blocked += " (locked)"
+				}
+			}
+			fmt.Printf("%4d %40s %4d failed, last from %20s, blocked until %s\n",
+				lockout.UserID, lockout.Email, lockout.FailedLogins, lockout.LastFailedAddress, blocked)
+		}
+
+		fmt.Printf("found %v users with failed logins\n", len(lockouts))
+	},
+}
+
+var UserUnlockCmd = &cobra.Command{
+	Use:   "unlock [userID]",
+	Short: "unlocks a user after failed logins",
+	Long:  `Will forget all failed logins of a user to lift a lockout or delay`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		userID := MustInt64Parameter(args[0], "userID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		user, err := stores.User.Get(userID)
+		if err != nil {
+			log.Fatalf("user with id %v not found\n", userID)
+		}
+
+		if err := app.ClearFailedLogins(stores, user.ID); err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("user %s %s (id:%v) can log in again\n",
+			user.FirstName, user.LastName, user.ID)
+	},
+}
+
+var UserPasswordHashesCmd = &cobra.Command{
+	Use:   "password-hashes",
+	Short: "counts accounts with outdated password hashes",
+	Long: `Report how many password hashes use another algorithm or other
+parameters than configured. They are replaced on the next login of the user`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		users, err := stores.User.GetAll()
+		failWhenSmallestWhiff(err)
+
+		hasher := app.CurrentPasswordHasher()
+		algorithms := map[string]int{}
+		outdated := 0
+		for _, user := range users {
+			algorithm := auth.HashAlgorithm(user.EncryptedPassword)
+			if algorithm == "" {
+				algorithm = "unknown"
+			}
+			algorithms[algorithm]++
+
+			if hasher.NeedsRehash(user.EncryptedPassword) {
+				outdated++
+			}
+		}
+
+		names := []string{}
+		for algorithm := range algorithms {
+			names = append(names, algorithm)
+		}
+		sort.Strings(names)
+		for _, algorithm := range names {
+			fmt.Printf("%10s %6d accounts\n", algorithm, algorithms[algorithm])
+		}
+		fmt.Printf("found %v of %v accounts with outdated password hashes (current: %s)\n",
+			outdated, len(users), hasher.Algorithm)
+	},
+}
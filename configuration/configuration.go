@@ -51,6 +51,10 @@ type AuthenticationConfiguration struct {
 		Secret        string        `yaml:"secret"`
 		AccessExpiry  time.Duration `yaml:"access_expiry"`
 		RefreshExpiry time.Duration `yaml:"refresh_expiry"`
+		JobExpiry     time.Duration `yaml:"job_expiry" default:"30m"`
+		// ImpersonationExpiry limits the tokens of root users acting as
+		// another user.
+		ImpersonationExpiry time.Duration `yaml:"impersonation_expiry" default:"30m"`
 	} `yaml:"jwt"`
 	Session struct {
 		Secret  string `yaml:"secret"`
@@ -62,8 +66,121 @@ type AuthenticationConfiguration struct {
 	} `yaml:"session"`
 	Password struct {
 		MinLength int `yaml:"min_length"`
+		// MaxLength defaults to the number of bytes bcrypt takes into account.
+		MaxLength int `yaml:"max_length" default:"72"`
+		// CharacterClasses is the number of different classes (lowercase,
+		// uppercase, digits, symbols) a password must contain.
+		CharacterClasses int `yaml:"character_classes" default:"1"`
+		// BanPersonalData rejects passwords containing the name or email of
+		// the user.
+		BanPersonalData bool `yaml:"ban_personal_data" default:"true"`
+		// CommonPasswordsFile replaces the bundled list of common passwords,
+		// one password per line.
+		CommonPasswordsFile string `yaml:"common_passwords_file"`
+		// Hashing describes how new password hashes are created. Existing
+		// hashes of other algorithms or parameters are replaced on login.
+		Hashing struct {
+			// Algorithm is either "argon2id" or "bcrypt".
+			Algorithm  string `yaml:"algorithm" default:"argon2id"`
+			BcryptCost int    `yaml:"bcrypt_cost" default:"10"`
+			Argon2id   struct {
+				// Memory is given in KiB.
+				Memory      uint32 `yaml:"memory" default:"19456"`
+				Iterations  uint32 `yaml:"iterations" default:"2"`
+				Parallelism uint8  `yaml:"parallelism" default:"1"`
+				SaltLength  uint32 `yaml:"salt_length" default:"16"`
+				KeyLength   uint32 `yaml:"key_length" default:"32"`
+			} `yaml:"argon2id"`
+		} `yaml:"hashing"`
 	} `yaml:"password"`
-	TotalRequestsPerMinute int64 `yaml:"total_requests_per_minute"`
+	TwoFactor struct {
+		// RequiredForStaff forces root users and course admins to set up
+		// two-factor authentication before they can use the api.
+		RequiredForStaff bool   `yaml:"required_for_staff" default:"false"`
+		Issuer           string `yaml:"issuer" default:"InfoMark"`
+	} `yaml:"two_factor"`
+	// Lockout slows down guessing the password of a single account,
+	// independent of the addresses the requests come from.
+	Lockout struct {
+		Enabled bool `yaml:"enabled" default:"true"`
+		// FreeAttempts failed logins are possible without any delay, each
+		// further one doubles the delay before the next attempt.
+		FreeAttempts int           `yaml:"free_attempts" default:"3"`
+		Delay        time.Duration `yaml:"delay" default:"1s"`
+		// Threshold failed logins lock the account for the duration.
+		Threshold int           `yaml:"threshold" default:"10"`
+		Duration  time.Duration `yaml:"duration" default:"15m"`
+		// ResetAfter forgets failed logins if there was none for this long.
+		ResetAfter time.Duration `yaml:"reset_after" default:"24h"`
+	} `yaml:"lockout"`
+	OIDC OIDCConfiguration `yaml:"oidc"`
+	// LDAP directories used instead of the stored password for the email
+	// domains they list.
+	LDAP                   []LDAPConfiguration `yaml:"ldap"`
+	TotalRequestsPerMinute int64               `yaml:"total_requests_per_minute"`
+	// CheckInsPerHour limits the attempts of a single account to check in to
+	// a group session by its code.
+	CheckInsPerHour int64 `yaml:"check_ins_per_hour" default:"20"`
+}
+
+// OIDCConfiguration describes the single sign-on via an external OpenID
+// Connect identity provider.
+type OIDCConfiguration struct {
+	Enabled      bool     `yaml:"enabled" default:"false"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+	Claims       struct {
+		FirstName     string `yaml:"first_name" default:"given_name"`
+		LastName      string `yaml:"last_name" default:"family_name"`
+		Email         string `yaml:"email" default:"email"`
+		StudentNumber string `yaml:"student_number"`
+		Root          string `yaml:"root" default:"groups"`
+	} `yaml:"claims"`
+	// RootValues lists values of the root claim which grant root privileges.
+	RootValues []string `yaml:"root_values"`
+	// MultiFactorValues lists values of the "amr" claim which show that the
+	// identity provider checked a second factor. Accounts with two-factor
+	// authentication can only use single sign-on with such a login.
+	MultiFactorValues []string `yaml:"multi_factor_values" default:"[\"mfa\"]"`
+}
+
+// LDAPConfiguration describes a directory (like Active Directory) which checks
+// the passwords of all accounts within the listed email domains.
+type LDAPConfiguration struct {
+	// Domains are email suffixes like "informatik.uni-tuebingen.de".
+	Domains            []string `yaml:"domains"`
+	URL                string   `yaml:"url"`
+	StartTLS           bool     `yaml:"start_tls" default:"false"`
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify" default:"false"`
+	// BindDN and BindPassword are the service account to search the user
+	// entry. Without them, UserDN is used to bind directly.
+	BindDN       string `yaml:"bind_dn"`
+	BindPassword string `yaml:"bind_password"`
+	BaseDN       string `yaml:"base_dn"`
+	// UserFilter finds the user entry, %s is replaced by the email address.
+	UserFilter string `yaml:"user_filter" default:"(mail=%s)"`
+	// UserDN is the entry of the user, %s is replaced by the local part of the
+	// email address, e.g. "uid=%s,ou=people,dc=uni-tuebingen,dc=de".
+	UserDN     string `yaml:"user_dn"`
+	Attributes struct {
+		FirstName     string `yaml:"first_name" default:"givenName"`
+		LastName      string `yaml:"last_name" default:"sn"`
+		StudentNumber string `yaml:"student_number"`
+		Root          string `yaml:"root" default:"memberOf"`
+	} `yaml:"attributes"`
+	// RootValues lists values of the root attribute which grant root privileges.
+	RootValues []string `yaml:"root_values"`
+}
+
+// CallbackURL returns the redirect url registered at the identity provider.
+func (config *OIDCConfiguration) CallbackURL(externalURL string) string {
+	if config.RedirectURL != "" {
+		return config.RedirectURL
+	}
+	return fmt.Sprintf("%s/api/v1/auth/oidc/callback", externalURL)
 }
 
 func (config *ServerConfigurationSchema) URL() string {
@@ -243,3 +360,18 @@ func (s *ConfigurationSchema) UnmarshalYAML(unmarshal func(interface{}) error) e
 
 	return nil
 }
+
+// UnmarshalYAML applies the defaults to each directory, as they are list items
+// which do not exist when the defaults of the schema are set.
+func (s *LDAPConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := defaults.Set(s); err != nil {
+		return err
+	}
+
+	type plain LDAPConfiguration
+	if err := unmarshal((*plain)(s)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/franela/goblin"
+	"gopkg.in/yaml.v2"
 )
 
 func TestConfiguration(t *testing.T) {
@@ -77,6 +78,24 @@ func TestConfiguration(t *testing.T) {
 
 		})
 
+		g.It("Should set defaults of ldap directories", func() {
+
+			config := &AuthenticationConfiguration{}
+			err := yaml.Unmarshal([]byte(`
+ldap:
+- domains: [informatik.uni-tuebingen.de]
+  url: ldaps://ldap.uni-tuebingen.de
+  attributes:
+    student_number: matrikel
+`), config)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(config.LDAP)).Equal(1)
+			g.Assert(config.LDAP[0].UserFilter).Equal("(mail=%s)")
+			g.Assert(config.LDAP[0].Attributes.FirstName).Equal("givenName")
+			g.Assert(config.LDAP[0].Attributes.StudentNumber).Equal("matrikel")
+
+		})
+
 	})
 
 }
@@ -0,0 +1,177 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type AttendanceStore struct {
+	db *sqlx.DB
+}
+
+func NewAttendanceStore(db *sqlx.DB) *AttendanceStore {
+	return &AttendanceStore{
+		db: db,
+	}
+}
+
+func (s *AttendanceStore) GetSession(sessionID int64) (*model.GroupSession, error) {
+	p := model.GroupSession{ID: sessionID}
+	err := s.db.Get(&p, "SELECT * FROM group_sessions WHERE id = $1 LIMIT 1;", p.ID)
+	return &p, err
+}
+
+func (s *AttendanceStore) SessionsOfGroup(groupID int64) ([]model.GroupSession, error) {
+	p := []model.GroupSession{}
+	err := s.db.Select(&p, "SELECT * FROM group_sessions WHERE group_id = $1 ORDER BY held_at ASC;", groupID)
+	return p, err
+}
+
+func (s *AttendanceStore) CreateSession(p *model.GroupSession) (*model.GroupSession, error) {
+	newID, err := Insert(s.db, "group_sessions", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSession(newID)
+}
+
+func (s *AttendanceStore) UpdateSession(p *model.GroupSession) error {
+	return Update(s.db, "group_sessions", p.ID, p)
+}
+
+func (s *AttendanceStore) DeleteSession(sessionID int64) error {
+	return Delete(s.db, "group_sessions", sessionID)
+}
+
+// FindSessionByCode returns the session of a course whose code is given and
+// has not expired yet.
+func (s *AttendanceStore) FindSessionByCode(courseID int64, code string, now time.Time) (*model.GroupSession, error) {
+	p := model.GroupSession{}
+	err := s.db.Get(&p, `
+SELECT
+  s.*
+FROM
+  group_sessions s
+INNER JOIN groups g ON g.id = s.group_id
+WHERE
+  g.course_id = $1
+AND
+  s.code = $2
+AND
+  s.code <> ''
+AND
+  s.code_expires_at >= $3
+LIMIT 1`, courseID, code, now)
+	return &p, err
+}
+
+func (s *AttendanceStore) GetAttendances(sessionID int64) ([]model.Attendance, error) {
+	p := []model.Attendance{}
+	err := s.db.Select(&p, `
+SELECT
+  a.id,
+  a.created_at,
+  a.session_id,
+  a.user_id,
+  u.first_name,
+  u.last_name,
+  u.email,
+  u.student_number
+FROM
+  attendances a
+INNER JOIN users u ON u.id = a.user_id
+WHERE
+  a.session_id = $1
+ORDER BY
+  u.last_name, u.first_name`, sessionID)
+	return p, err
+}
+
+// Attend records the attendance of a single student. Recording it twice has
+// no effect.
+func (s *AttendanceStore) Attend(sessionID int64, userID int64) error {
+	_, err := s.db.Exec(`
+INSERT INTO attendances
+  (session_id, user_id)
+VALUES
+  ($1, $2)
+ON CONFLICT (session_id, user_id) DO NOTHING`, sessionID, userID)
+	return err
+}
+
+// ReplaceAttendances sets the students who attended a session, e.g. from a
+// checklist of the tutor.
+func (s *AttendanceStore) ReplaceAttendances(sessionID int64, userIDs []int64) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM attendances WHERE session_id = $1`, sessionID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if _, err := tx.Exec(`
+INSERT INTO attendances
+  (session_id, user_id)
+VALUES
+  ($1, $2)
+ON CONFLICT (session_id, user_id) DO NOTHING`, sessionID, userID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSummaries counts for each student in a group of the course the sessions
+// of this group held until the given time and how many of them the student
+// attended.
+func (s *AttendanceStore) GetSummaries(courseID int64, until time.Time) ([]model.AttendanceSummary, error) {
+	p := []model.AttendanceSummary{}
+	err := s.db.Select(&p, `
+SELECT
+  ug.user_id,
+  ug.group_id,
+  (
+    SELECT count(*) FROM group_sessions s
+    WHERE s.group_id = ug.group_id AND s.held_at <= $2
+  ) sessions,
+  (
+    SELECT count(*) FROM attendances a
+    INNER JOIN group_sessions s ON s.id = a.session_id
+    WHERE s.group_id = ug.group_id AND s.held_at <= $2 AND a.user_id = ug.user_id
+  ) attended
+FROM
+  user_group ug
+INNER JOIN groups g ON g.id = ug.group_id
+WHERE
+  g.course_id = $1
+ORDER BY
+  ug.user_id`, courseID, until)
+	return p, err
+}
@@ -116,6 +116,66 @@ VALUES (DEFAULT, $1, $2, $3);
 	return err
 }
 
+// ImportEnrollments creates the missing users of an enrollment import, enrolls
+// all users and assigns them to their groups in a single transaction.
+func (s *CourseStore) ImportEnrollments(courseID int64, entries []model.EnrollmentImportEntry) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for k := range entries {
+		if err := importEnrollment(tx, courseID, &entries[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func importEnrollment(tx *sqlx.Tx, courseID int64, entry *model.EnrollmentImportEntry) error {
+	if entry.User.ID == 0 {
+		newID, err := Insert(tx, "users", entry.User)
+		if err != nil {
+			return err
+		}
+		entry.User.ID = newID
+	}
+
+	switch {
+	case entry.OldRole == -1:
+		if _, err := tx.Exec(`
+INSERT INTO
+  user_course (id, user_id, course_id, role)
+VALUES (DEFAULT, $1, $2, $3);
+`, entry.User.ID, courseID, entry.NewRole); err != nil {
+			return err
+		}
+	case entry.OldRole != entry.NewRole:
+		if _, err := tx.Exec(`
+UPDATE
+  user_course
+SET
+  role = $3
+WHERE
+  user_id = $1
+AND
+  course_id = $2`, entry.User.ID, courseID, entry.NewRole); err != nil {
+			return err
+		}
+	}
+
+	if entry.GroupID != 0 {
+		join, err := joinGroup(tx, courseID, entry.GroupID, entry.User.ID, false)
+		if err != nil {
+			return err
+		}
+		entry.Joined = join.Enrolled
+	}
+	return nil
+}
+
 func (s *CourseStore) Disenroll(courseID int64, userID int64) error {
 	_, err := s.db.Exec(`
 DELETE FROM
@@ -269,6 +329,30 @@ ORDER BY
 
 }
 
+// PointsOfCourse returns the points of each student in each sheet of a course.
+func (s *CourseStore) PointsOfCourse(courseID int64) ([]model.UserSheetPoints, error) {
+	p := []model.UserSheetPoints{}
+
+	err := s.db.Select(&p, `
+SELECT
+  sub.user_id,
+  ts.sheet_id,
+  SUM(g.acquired_points) acquired_points
+FROM
+  grades g
+INNER JOIN submissions sub ON g.submission_id = sub.id
+INNER JOIN task_sheet ts ON ts.task_id = sub.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE
+  sc.course_id = $1
+GROUP BY
+  sub.user_id, ts.sheet_id
+ORDER BY
+  sub.user_id, ts.sheet_id`, courseID,
+	)
+	return p, err
+}
+
 func (s *CourseStore) RoleInCourse(userID int64, courseID int64) (authorize.CourseRole, error) {
 	var role_int int
 
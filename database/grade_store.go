@@ -23,6 +23,7 @@ import (
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type GradeStore struct {
@@ -49,11 +50,14 @@ SELECT
   s.user_id,
   u.last_name user_last_name,
   u.first_name user_first_name,
-  u.email user_email
+  u.email user_email,
+  sh.anonymous sheet_anonymous
 FROM
   grades g
 INNER JOIN submissions s ON g.submission_id = s.id
 INNER JOIN users u ON s.user_id = u.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheets sh ON sh.id = ts.sheet_id
 WHERE
   g.id = $1 LIMIT 1
 `, p.ID)
@@ -142,9 +146,68 @@ ORDER BY
 	return p, err
 }
 
+// GetStatisticEntries returns all grades of a course (or sheet) for the
+// statistics. Graded grades belong to the tutor who graded them. Ungraded
+// grades carry only a placeholder tutor and belong to the assigned tutor or the
+// tutor of the group instead, pooled ones to nobody (tutor id 0).
+func (s *GradeStore) GetStatisticEntries(courseID int64, sheetID int64) ([]model.GradeStatisticEntry, error) {
+	p := []model.GradeStatisticEntry{}
+	err := s.db.Select(&p, `
+SELECT
+  g.id grade_id,
+  t.id task_id,
+  t.name task_name,
+  t.max_points,
+  sh.id sheet_id,
+  sh.name sheet_name,
+  sh.due_at sheet_due_at,
+  COALESCE(gs.id, 0) group_id,
+  resp.tutor_id,
+  COALESCE(tu.first_name, '') tutor_first_name,
+  COALESCE(tu.last_name, '') tutor_last_name,
+  g.acquired_points,
+  COALESCE(g.feedback, '') feedback,
+  g.public_execution_state,
+  g.private_execution_state,
+  g.public_test_status,
+  g.private_test_status,
+  g.updated_at
+FROM
+  grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN tasks t ON t.id = s.task_id
+INNER JOIN task_sheet ts ON ts.task_id = t.id
+INNER JOIN sheets sh ON sh.id = ts.sheet_id
+INNER JOIN sheet_course sc ON sc.sheet_id = sh.id
+LEFT JOIN (
+  user_group ug INNER JOIN groups gs ON gs.id = ug.group_id AND gs.course_id = $1
+) ON ug.user_id = s.user_id
+CROSS JOIN LATERAL (
+  SELECT
+    CASE
+      WHEN g.feedback not like '' THEN g.tutor_id
+      WHEN g.assigned_tutor_id IS NOT NULL THEN g.assigned_tutor_id
+      WHEN NOT g.pooled THEN COALESCE(gs.tutor_id, 0)
+      ELSE 0
+    END tutor_id
+) resp
+LEFT JOIN users tu ON tu.id = resp.tutor_id
+WHERE
+  sc.course_id = $1
+AND
+  ($2 = 0 OR sh.id = $2)
+ORDER BY
+  sh.id, t.id, g.id
+`, courseID, sheetID)
+	return p, err
+}
+
 func (s *GradeStore) GetAllMissingGrades(courseID int64, tutorID int64, groupID int64) ([]model.MissingGrade, error) {
 	p := []model.MissingGrade{}
 
+	// a tutor is responsible for ungraded work which has been explicitly
+	// assigned or which belongs to a student of a group the tutor leads or
+	// supports (unless pooled)
 	err := s.db.Select(&p,
 		`
 SELECT
@@ -155,28 +218,213 @@ SELECT
   s.user_id,
   u.last_name user_last_name,
   u.first_name user_first_name,
-  u.email user_email
+  u.email user_email,
+  sh.anonymous sheet_anonymous
 FROM
   grades g
 INNER JOIN submissions s ON s.id = g.submission_id
 INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheets sh ON sh.id = ts.sheet_id
 INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
 INNER JOIN users u ON s.user_id = u.id
-INNER JOIN user_group ug ON ug.user_id = u.id
+LEFT JOIN (
+  user_group ug INNER JOIN groups gr ON gr.id = ug.group_id AND gr.course_id = $2
+) ON ug.user_id = u.id
 WHERE
-  g.feedback like '' and g.tutor_id = $1
+  g.feedback like ''
+AND
+  (g.assigned_tutor_id = $1 OR (g.assigned_tutor_id IS NULL AND NOT g.pooled AND `+tutorsGroup("gr", "$1")+`))
 AND
   sg.course_id = $2
 AND
   ($3 = 0 OR ug.group_id = $3)
+ORDER BY
+  g.id
   `, tutorID, courseID, groupID)
 	return p, err
 }
 
+func (s *GradeStore) GetPooledGrades(courseID int64) ([]model.MissingGrade, error) {
+	p := []model.MissingGrade{}
+
+	err := s.db.Select(&p,
+		`
+SELECT
+  g.*,
+  ts.task_id,
+  ts.sheet_id,
+  sg.course_id,
+  s.user_id,
+  u.last_name user_last_name,
+  u.first_name user_first_name,
+  u.email user_email,
+  sh.anonymous sheet_anonymous
+FROM
+  grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheets sh ON sh.id = ts.sheet_id
+INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
+INNER JOIN users u ON s.user_id = u.id
+WHERE
+  g.feedback like ''
+AND
+  g.pooled
+AND
+  g.assigned_tutor_id IS NULL
+AND
+  sg.course_id = $1
+ORDER BY
+  g.id
+  `, courseID)
+	return p, err
+}
+
+// ClaimPooledGrades assigns pooled grades to a tutor. If no grade ids are given,
+// the oldest "count" grades in the pool are taken. Grades claimed concurrently
+// by another tutor are skipped.
+func (s *GradeStore) ClaimPooledGrades(courseID int64, tutorID int64, gradeIDs []int64, count int) ([]int64, error) {
+	p := []int64{}
+	if gradeIDs == nil {
+		gradeIDs = []int64{}
+	}
+	err := s.db.Select(&p, `
+UPDATE grades
+SET
+  assigned_tutor_id = $2,
+  pooled = false
+WHERE id IN (
+  SELECT
+    g.id
+  FROM
+    grades g
+  INNER JOIN submissions s ON s.id = g.submission_id
+  INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+  INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
+  WHERE
+    sg.course_id = $1
+  AND
+    g.feedback like ''
+  AND
+    g.pooled
+  AND
+    g.assigned_tutor_id IS NULL
+  AND
+    (cardinality($3::int[]) = 0 OR g.id = ANY($3::int[]))
+  ORDER BY
+    g.id
+  LIMIT $4
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id
+`, courseID, tutorID, pq.Array(gradeIDs), count)
+	return p, err
+}
+
+// AssignGrades moves ungraded grades of a course either to a tutor or
+// (tutorID = 0) into the pool.
+func (s *GradeStore) AssignGrades(courseID int64, gradeIDs []int64, tutorID int64) ([]int64, error) {
+	p := []int64{}
+	err := s.db.Select(&p, `
+UPDATE grades
+SET
+  assigned_tutor_id = NULLIF($3, 0),
+  pooled = ($3 = 0)
+WHERE id IN (
+  SELECT
+    g.id
+  FROM
+    grades g
+  INNER JOIN submissions s ON s.id = g.submission_id
+  INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+  INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
+  WHERE
+    sg.course_id = $1
+  AND
+    g.feedback like ''
+  AND
+    g.id = ANY($2::int[])
+)
+RETURNING id
+`, courseID, pq.Array(gradeIDs), tutorID)
+	return p, err
+}
+
+func (s *GradeStore) GetTutorWorkloads(courseID int64) ([]model.TutorWorkload, error) {
+	p := []model.TutorWorkload{}
+	err := s.db.Select(&p, `
+SELECT
+  u.id tutor_id,
+  u.first_name,
+  u.last_name,
+  u.email,
+  (
+    SELECT
+      count(*)
+    FROM
+      grades g
+    INNER JOIN submissions s ON s.id = g.submission_id
+    INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+    INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
+    LEFT JOIN (
+      user_group ug INNER JOIN groups gr ON gr.id = ug.group_id AND gr.course_id = $1
+    ) ON ug.user_id = s.user_id
+    WHERE
+      sg.course_id = $1
+    AND
+      g.feedback like ''
+    AND
+      (g.assigned_tutor_id = u.id OR (g.assigned_tutor_id IS NULL AND NOT g.pooled AND `+tutorsGroup("gr", "u.id")+`))
+  ) open,
+  (
+    SELECT
+      count(*)
+    FROM
+      grades g
+    INNER JOIN submissions s ON s.id = g.submission_id
+    INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+    INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
+    WHERE
+      sg.course_id = $1
+    AND
+      g.feedback not like ''
+    AND
+      g.tutor_id = u.id
+  ) finished
+FROM
+  users u
+INNER JOIN user_course uc ON uc.user_id = u.id
+WHERE
+  uc.course_id = $1
+AND
+  uc.role > 0
+ORDER BY
+  u.id
+`, courseID)
+	return p, err
+}
+
 func (s *GradeStore) Update(p *model.Grade) error {
 	return Update(s.db, "grades", p.ID, p)
 }
 
+// UpdateMany updates all given grades in a single transaction.
+func (s *GradeStore) UpdateMany(p []model.Grade) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for k := range p {
+		if err := Update(tx, "grades", p[k].ID, &p[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (s *GradeStore) GetFiltered(
 	courseID int64,
 	sheetID int64,
@@ -199,11 +447,13 @@ SELECT
   g.*, s.user_id,
   u.last_name user_last_name,
   u.first_name user_first_name,
-  u.email user_email
+  u.email user_email,
+  sh.anonymous sheet_anonymous
 FROM
   grades g
 INNER JOIN submissions s ON s.id = g.submission_id
 INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheets sh ON sh.id = ts.sheet_id
 INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
 INNER JOIN user_group ug ON ug.user_id = s.user_id
 INNER JOIN users u ON s.user_id = u.id
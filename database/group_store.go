@@ -20,6 +20,9 @@
 package database
 
 import (
+	"database/sql"
+	"fmt"
+
 	"github.com/infomark-org/infomark/model"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -57,6 +60,32 @@ func (s *GroupStore) Create(p *model.Group) (*model.Group, error) {
 	return s.Get(newID)
 }
 
+// CreateWithSlots creates a group together with its weekly meetings in a
+// single transaction.
+func (s *GroupStore) CreateWithSlots(p *model.Group, slots []model.GroupSlot) (*model.Group, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	newID, err := Insert(tx, "groups", p)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := replaceSlots(tx, newID, slots); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(newID)
+}
+
 func (s *GroupStore) Update(p *model.Group) error {
 	return Update(s.db, "groups", p.ID, p)
 }
@@ -75,7 +104,8 @@ SELECT
   u.last_name as tutor_last_name,
   u.avatar_url as tutor_avatar_url,
   u.email as tutor_email,
-  u.language as tutor_language
+  u.language as tutor_language,
+  (SELECT count(*) FROM user_group m WHERE m.group_id = g.id) members
 FROM
   groups g
 INNER JOIN users u ON g.tutor_id = u.id
@@ -114,7 +144,8 @@ SELECT
   u.last_name as tutor_last_name,
   u.avatar_url as tutor_avatar_url,
   u.email as tutor_email,
-  u.language as tutor_language
+  u.language as tutor_language,
+  (SELECT count(*) FROM user_group m WHERE m.group_id = g.id) members
 FROM
   groups g
 INNER JOIN users u ON g.tutor_id = u.id
@@ -214,6 +245,18 @@ func (s *GroupStore) ChangeGroupEnrollmentOfUserInCourse(p *model.GroupEnrollmen
 	return Update(s.db, "user_group", p.ID, p)
 }
 
+// tutorsGroup is an SQL condition matching all groups (given by their alias)
+// which the tutor leads or currently supports as co-tutor or substitute.
+func tutorsGroup(groupAlias string, tutorID string) string {
+	return fmt.Sprintf(`(%[1]s.tutor_id = %[2]s OR EXISTS (
+  SELECT 1 FROM group_tutors gt
+  WHERE gt.group_id = %[1]s.id
+  AND gt.user_id = %[2]s
+  AND (gt.valid_from IS NULL OR gt.valid_from <= now())
+  AND (gt.valid_until IS NULL OR gt.valid_until >= now())
+))`, groupAlias, tutorID)
+}
+
 func (s *GroupStore) GetOfTutor(tutorID int64, courseID int64) ([]model.GroupWithTutor, error) {
 	p := []model.GroupWithTutor{}
 
@@ -224,14 +267,15 @@ SELECT
   u.last_name as tutor_last_name,
   u.avatar_url as tutor_avatar_url,
   u.email as tutor_email,
-  u.language as tutor_language
+  u.language as tutor_language,
+  (SELECT count(*) FROM user_group m WHERE m.group_id = g.id) members
 FROM
   groups g
 INNER JOIN users u ON g.tutor_id = u.id
 WHERE
   course_id = $2
 AND
-  g.tutor_id = $1
+  `+tutorsGroup("g", "$1")+`
 ORDER BY
   g.id ASC`, tutorID, courseID)
 	return p, err
@@ -330,3 +374,635 @@ AND
 	return p, err
 
 }
+
+// ReplaceGroupEnrollmentsOfCourse removes all current group enrollments of the
+// given users in the course and creates the given ones in a single transaction.
+func (s *GroupStore) ReplaceGroupEnrollmentsOfCourse(courseID int64, p []model.GroupEnrollment) error {
+	userIDs := []int64{}
+	for _, enrollment := range p {
+		userIDs = append(userIDs, enrollment.UserID)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM
+  user_group ug
+USING
+  groups g
+WHERE
+  ug.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  ug.user_id = ANY($2)`, courseID, pq.Array(userIDs))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for k := range p {
+		if _, err := Insert(tx, "user_group", &p[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSlots returns all weekly meetings of a group.
+func (s *GroupStore) GetSlots(groupID int64) ([]model.GroupSlot, error) {
+	p := []model.GroupSlot{}
+	err := s.db.Select(&p, `
+SELECT
+  id,
+  group_id,
+  weekday,
+  to_char(start_time, 'HH24:MI') start_time,
+  to_char(end_time, 'HH24:MI') end_time,
+  location,
+  online_url
+FROM
+  group_slots
+WHERE
+  group_id = $1
+ORDER BY
+  weekday, start_time, id`, groupID)
+	return p, err
+}
+
+// GetSlotsOfCourse returns the weekly meetings of all groups in a course.
+func (s *GroupStore) GetSlotsOfCourse(courseID int64) ([]model.GroupSlot, error) {
+	p := []model.GroupSlot{}
+	err := s.db.Select(&p, `
+SELECT
+  gs.id,
+  gs.group_id,
+  gs.weekday,
+  to_char(gs.start_time, 'HH24:MI') start_time,
+  to_char(gs.end_time, 'HH24:MI') end_time,
+  gs.location,
+  gs.online_url
+FROM
+  group_slots gs
+INNER JOIN groups g ON g.id = gs.group_id
+WHERE
+  g.course_id = $1
+ORDER BY
+  gs.group_id, gs.weekday, gs.start_time, gs.id`, courseID)
+	return p, err
+}
+
+// ReplaceSlots replaces all weekly meetings of a group in a single transaction.
+func (s *GroupStore) ReplaceSlots(groupID int64, p []model.GroupSlot) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := replaceSlots(tx, groupID, p); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceSlots(tx *sqlx.Tx, groupID int64, p []model.GroupSlot) error {
+	if _, err := tx.Exec(`DELETE FROM group_slots WHERE group_id = $1`, groupID); err != nil {
+		return err
+	}
+
+	for k := range p {
+		p[k].GroupID = groupID
+		if _, err := Insert(tx, "group_slots", &p[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockCourseGroups serializes all changes of group enrollments within a course
+// such that capacities cannot be exceeded by concurrent requests.
+func lockCourseGroups(tx *sqlx.Tx, courseID int64) error {
+	_, err := tx.Exec(`SELECT id FROM courses WHERE id = $1 FOR UPDATE`, courseID)
+	return err
+}
+
+func groupHasSeat(tx *sqlx.Tx, groupID int64) (bool, error) {
+	var capacity, members int
+	err := tx.QueryRow(`
+SELECT
+  g.capacity,
+  (SELECT count(*) FROM user_group m WHERE m.group_id = g.id)
+FROM
+  groups g
+WHERE
+  g.id = $1`, groupID).Scan(&capacity, &members)
+	return capacity == 0 || members < capacity, err
+}
+
+// moveToGroup removes a student from the current group and all waitlists of the
+// course and enrolls the student into the given group unless it is 0. It
+// returns the group the student has left or 0.
+func moveToGroup(tx *sqlx.Tx, courseID int64, userID int64, groupID int64) (int64, error) {
+	var previousGroupID int64
+	err := tx.QueryRow(`
+DELETE FROM
+  user_group ug
+USING
+  groups g
+WHERE
+  ug.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  ug.user_id = $2
+RETURNING ug.group_id`, courseID, userID).Scan(&previousGroupID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM
+  group_waitlist gw
+USING
+  groups g
+WHERE
+  gw.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  gw.user_id = $2`, courseID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if groupID != 0 {
+		_, err = tx.Exec(`INSERT INTO user_group (id, user_id, group_id) VALUES (DEFAULT, $1, $2)`, userID, groupID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return previousGroupID, nil
+}
+
+// promoteWaitlist fills free seats of a group with students from its waitlist.
+// As those students leave their previous groups, this continues with the
+// waitlists of those groups.
+func promoteWaitlist(tx *sqlx.Tx, courseID int64, groupID int64) error {
+	freed := []int64{groupID}
+
+	for len(freed) > 0 {
+		current := freed[0]
+		freed = freed[1:]
+		if current == 0 {
+			continue
+		}
+
+		hasSeat, err := groupHasSeat(tx, current)
+		if err != nil {
+			return err
+		}
+		if !hasSeat {
+			continue
+		}
+
+		var userID int64
+		err = tx.QueryRow(`
+SELECT
+  user_id
+FROM
+  group_waitlist
+WHERE
+  group_id = $1
+ORDER BY
+  created_at ASC, id ASC
+LIMIT 1`, current).Scan(&userID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		previousGroupID, err := moveToGroup(tx, courseID, userID, current)
+		if err != nil {
+			return err
+		}
+
+		// there might be even more free seats
+		freed = append(freed, current, previousGroupID)
+	}
+
+	return nil
+}
+
+func waitlistPosition(tx *sqlx.Tx, groupID int64, userID int64) (int, error) {
+	var position int
+	err := tx.QueryRow(`
+SELECT
+  count(*)
+FROM
+  group_waitlist w
+INNER JOIN group_waitlist own ON own.group_id = w.group_id
+WHERE
+  own.group_id = $1
+AND
+  own.user_id = $2
+AND
+  (w.created_at, w.id) <= (own.created_at, own.id)`, groupID, userID).Scan(&position)
+	return position, err
+}
+
+// JoinGroup enrolls a student into a group of a course if the group has a free
+// seat. Otherwise the student is put on the waitlist of the group if requested.
+// A student waits for at most one group per course. Seats freed by the student
+// are given to the waitlist of the previous group.
+func (s *GroupStore) JoinGroup(courseID int64, groupID int64, userID int64, waitlist bool) (*model.GroupJoin, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := joinGroup(tx, courseID, groupID, userID, waitlist)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return result, tx.Commit()
+}
+
+func joinGroup(tx *sqlx.Tx, courseID int64, groupID int64, userID int64, waitlist bool) (*model.GroupJoin, error) {
+	if err := lockCourseGroups(tx, courseID); err != nil {
+		return nil, err
+	}
+
+	var currentGroupID int64
+	err := tx.QueryRow(`
+SELECT
+  ug.group_id
+FROM
+  user_group ug
+INNER JOIN groups g ON g.id = ug.group_id
+WHERE
+  g.course_id = $1
+AND
+  ug.user_id = $2
+LIMIT 1`, courseID, userID).Scan(&currentGroupID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if currentGroupID == groupID {
+		return &model.GroupJoin{Enrolled: true}, nil
+	}
+
+	hasSeat, err := groupHasSeat(tx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasSeat {
+		previousGroupID, err := moveToGroup(tx, courseID, userID, groupID)
+		if err != nil {
+			return nil, err
+		}
+		if err := promoteWaitlist(tx, courseID, previousGroupID); err != nil {
+			return nil, err
+		}
+		return &model.GroupJoin{Enrolled: true}, nil
+	}
+
+	if !waitlist {
+		return &model.GroupJoin{}, nil
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM
+  group_waitlist gw
+USING
+  groups g
+WHERE
+  gw.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  gw.user_id = $2
+AND
+  gw.group_id <> $3`, courseID, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO group_waitlist
+  (user_id, group_id)
+VALUES
+  ($1, $2)
+ON CONFLICT (user_id, group_id) DO NOTHING`, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := waitlistPosition(tx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GroupJoin{Waitlisted: true, Position: position}, nil
+}
+
+// LeaveGroup removes a student from the group and all waitlists of a course.
+// The freed seat is given to the waitlist of the group.
+func (s *GroupStore) LeaveGroup(courseID int64, userID int64) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := lockCourseGroups(tx, courseID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	previousGroupID, err := moveToGroup(tx, courseID, userID, 0)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := promoteWaitlist(tx, courseID, previousGroupID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetWaitlistOfUserInCourse returns the group a student is waiting for and the
+// position on its waitlist.
+func (s *GroupStore) GetWaitlistOfUserInCourse(userID int64, courseID int64) (int64, int, error) {
+	var groupID int64
+	var position int
+	err := s.db.QueryRow(`
+SELECT
+  own.group_id,
+  (
+    SELECT count(*) FROM group_waitlist w
+    WHERE w.group_id = own.group_id
+    AND (w.created_at, w.id) <= (own.created_at, own.id)
+  )
+FROM
+  group_waitlist own
+INNER JOIN groups g ON g.id = own.group_id
+WHERE
+  own.user_id = $1
+AND
+  g.course_id = $2
+LIMIT 1`, userID, courseID).Scan(&groupID, &position)
+	return groupID, position, err
+}
+
+// RequestGroupSwap records that a student wants to swap the group with a
+// partner. A previous request of the student in this course is replaced. If the
+// partner has already asked for the same swap, both students change their
+// groups and both requests are removed.
+func (s *GroupStore) RequestGroupSwap(courseID int64, userID int64, partnerID int64) (bool, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	swapped, err := requestGroupSwap(tx, courseID, userID, partnerID)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return swapped, tx.Commit()
+}
+
+func requestGroupSwap(tx *sqlx.Tx, courseID int64, userID int64, partnerID int64) (bool, error) {
+	if err := lockCourseGroups(tx, courseID); err != nil {
+		return false, err
+	}
+
+	_, err := tx.Exec(`
+INSERT INTO group_swaps
+  (course_id, user_id, partner_id)
+VALUES
+  ($1, $2, $3)
+ON CONFLICT (course_id, user_id) DO UPDATE SET
+  partner_id = EXCLUDED.partner_id,
+  created_at = current_timestamp`, courseID, userID, partnerID)
+	if err != nil {
+		return false, err
+	}
+
+	var agreed int
+	err = tx.QueryRow(`
+SELECT
+  count(*)
+FROM
+  group_swaps
+WHERE
+  course_id = $1
+AND
+  user_id = $2
+AND
+  partner_id = $3`, courseID, partnerID, userID).Scan(&agreed)
+	if err != nil || agreed == 0 {
+		return false, err
+	}
+
+	enrollments := []model.GroupEnrollment{}
+	err = tx.Select(&enrollments, `
+SELECT
+  ug.*
+FROM
+  user_group ug
+INNER JOIN groups g ON g.id = ug.group_id
+WHERE
+  g.course_id = $1
+AND
+  ug.user_id = ANY($2)`, courseID, pq.Array([]int64{userID, partnerID}))
+	if err != nil {
+		return false, err
+	}
+	// both students must be in a group to swap
+	if len(enrollments) != 2 {
+		return false, nil
+	}
+
+	enrollments[0].GroupID, enrollments[1].GroupID = enrollments[1].GroupID, enrollments[0].GroupID
+	for k := range enrollments {
+		if err := Update(tx, "user_group", enrollments[k].ID, &enrollments[k]); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM
+  group_swaps
+WHERE
+  course_id = $1
+AND
+  user_id = ANY($2)`, courseID, pq.Array([]int64{userID, partnerID}))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetGroupSwapsOfUser returns all swap requests from and to a student.
+func (s *GroupStore) GetGroupSwapsOfUser(courseID int64, userID int64) ([]model.GroupSwap, error) {
+	p := []model.GroupSwap{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  group_swaps
+WHERE
+  course_id = $1
+AND
+  (user_id = $2 OR partner_id = $2)
+ORDER BY
+  created_at ASC, id ASC`, courseID, userID)
+	return p, err
+}
+
+// DeleteGroupSwapOfUser withdraws the swap request of a student.
+func (s *GroupStore) DeleteGroupSwapOfUser(courseID int64, userID int64) error {
+	_, err := s.db.Exec(`DELETE FROM group_swaps WHERE course_id = $1 AND user_id = $2`, courseID, userID)
+	return err
+}
+
+// PromoteWaitlist gives free seats of a group, e.g. after its capacity has been
+// raised, to the students on its waitlist.
+func (s *GroupStore) PromoteWaitlist(courseID int64, groupID int64) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := lockCourseGroups(tx, courseID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := promoteWaitlist(tx, courseID, groupID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTutors returns the lead tutor and all co-tutors and substitutes of a group.
+func (s *GroupStore) GetTutors(groupID int64) ([]model.GroupTutor, error) {
+	p := []model.GroupTutor{}
+	err := s.db.Select(&p, `
+SELECT
+  0 id,
+  g.created_at,
+  g.updated_at,
+  g.id group_id,
+  g.tutor_id user_id,
+  0 role,
+  NULL valid_from,
+  NULL valid_until,
+  u.first_name,
+  u.last_name,
+  u.email,
+  true active
+FROM
+  groups g
+INNER JOIN users u ON u.id = g.tutor_id
+WHERE
+  g.id = $1
+UNION ALL
+SELECT
+  gt.id,
+  gt.created_at,
+  gt.updated_at,
+  gt.group_id,
+  gt.user_id,
+  gt.role,
+  gt.valid_from,
+  gt.valid_until,
+  u.first_name,
+  u.last_name,
+  u.email,
+  (gt.valid_from IS NULL OR gt.valid_from <= now())
+    AND (gt.valid_until IS NULL OR gt.valid_until >= now()) active
+FROM
+  group_tutors gt
+INNER JOIN users u ON u.id = gt.user_id
+WHERE
+  gt.group_id = $1
+ORDER BY
+  role, user_id`, groupID)
+	return p, err
+}
+
+// SetTutor adds a co-tutor or substitute to a group or updates the role and
+// validity of an existing one.
+func (s *GroupStore) SetTutor(p *model.GroupTutor) error {
+	_, err := s.db.Exec(`
+INSERT INTO group_tutors
+  (group_id, user_id, role, valid_from, valid_until)
+VALUES
+  ($1, $2, $3, $4, $5)
+ON CONFLICT (group_id, user_id) DO UPDATE SET
+  role = EXCLUDED.role,
+  valid_from = EXCLUDED.valid_from,
+  valid_until = EXCLUDED.valid_until,
+  updated_at = current_timestamp`,
+		p.GroupID, p.UserID, p.Role, p.ValidFrom, p.ValidUntil)
+	return err
+}
+
+// SetLeadTutor makes a user the lead tutor of a group. The user is no longer a
+// co-tutor or substitute of this group.
+func (s *GroupStore) SetLeadTutor(groupID int64, userID int64) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE groups SET tutor_id = $2, updated_at = current_timestamp WHERE id = $1`, groupID, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM group_tutors WHERE group_id = $1 AND user_id = $2`, groupID, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTutor removes a co-tutor or substitute from a group.
+func (s *GroupStore) RemoveTutor(groupID int64, userID int64) error {
+	_, err := s.db.Exec(`DELETE FROM group_tutors WHERE group_id = $1 AND user_id = $2`, groupID, userID)
+	return err
+}
+
+// IsTutorOfGroup reports whether a user currently leads or supports a group as
+// co-tutor or substitute.
+func (s *GroupStore) IsTutorOfGroup(groupID int64, userID int64) (bool, error) {
+	var isTutor bool
+	err := s.db.Get(&isTutor, `
+SELECT EXISTS (
+  SELECT 1 FROM groups g WHERE g.id = $1 AND `+tutorsGroup("g", "$2")+`
+)`, groupID, userID)
+	return isTutor, err
+}
@@ -0,0 +1,99 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginLockoutStore struct {
+	db *sqlx.DB
+}
+
+func NewLoginLockoutStore(db *sqlx.DB) *LoginLockoutStore {
+	return &LoginLockoutStore{
+		db: db,
+	}
+}
+
+func (s *LoginLockoutStore) Get(userID int64) (*model.LoginLockout, error) {
+	p := model.LoginLockout{}
+	err := s.db.Get(&p, `
+SELECT
+  l.*, u.first_name, u.last_name, u.email
+FROM
+  login_lockouts l
+INNER JOIN
+  users u ON u.id = l.user_id
+WHERE
+  l.user_id = $1
+LIMIT 1;`, userID)
+	return &p, err
+}
+
+// GetAll returns the failed logins of all accounts.
+func (s *LoginLockoutStore) GetAll() ([]model.LoginLockout, error) {
+	p := []model.LoginLockout{}
+	err := s.db.Select(&p, `
+SELECT
+  l.*, u.first_name, u.last_name, u.email
+FROM
+  login_lockouts l
+INNER JOIN
+  users u ON u.id = l.user_id
+ORDER BY
+  l.last_failed_at DESC;`)
+	return p, err
+}
+
+// RecordFailure counts a failed login. Failures before forgetBefore are not
+// counted anymore.
+func (s *LoginLockoutStore) RecordFailure(userID int64, failedAt time.Time, address string, forgetBefore time.Time) (*model.LoginLockout, error) {
+	p := model.LoginLockout{}
+	err := s.db.Get(&p, `
+INSERT INTO login_lockouts
+  (user_id, failed_logins, last_failed_at, last_failed_address)
+VALUES
+  ($1, 1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET
+  failed_logins = CASE
+    WHEN login_lockouts.last_failed_at < $4 THEN 1
+    ELSE login_lockouts.failed_logins + 1
+  END,
+  last_failed_at = $2,
+  last_failed_address = $3
+RETURNING *;`, userID, failedAt, address, forgetBefore)
+	return &p, err
+}
+
+// Lock blocks all logins of an account until the given time.
+func (s *LoginLockoutStore) Lock(userID int64, until time.Time) error {
+	_, err := s.db.Exec("UPDATE login_lockouts SET locked_until = $2 WHERE user_id = $1;", userID, until)
+	return err
+}
+
+// Clear forgets all failed logins of an account.
+func (s *LoginLockoutStore) Clear(userID int64) error {
+	_, err := s.db.Exec("DELETE FROM login_lockouts WHERE user_id = $1;", userID)
+	return err
+}
@@ -0,0 +1,111 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginSessionStore struct {
+	db *sqlx.DB
+}
+
+func NewLoginSessionStore(db *sqlx.DB) *LoginSessionStore {
+	return &LoginSessionStore{
+		db: db,
+	}
+}
+
+func (s *LoginSessionStore) Get(sessionID int64) (*model.LoginSession, error) {
+	p := model.LoginSession{ID: sessionID}
+	err := s.db.Get(&p, "SELECT * FROM login_sessions WHERE id = $1 LIMIT 1;", p.ID)
+	return &p, err
+}
+
+// SessionsOfUser returns all sessions of a user which did not expire yet.
+func (s *LoginSessionStore) SessionsOfUser(userID int64, now time.Time) ([]model.LoginSession, error) {
+	p := []model.LoginSession{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  login_sessions
+WHERE
+  user_id = $1
+AND
+  expires_at > $2
+ORDER BY
+  last_used_at DESC;`, userID, now)
+	return p, err
+}
+
+func (s *LoginSessionStore) Create(p *model.LoginSession) (*model.LoginSession, error) {
+	newID, err := Insert(s.db, "login_sessions", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+// Rotate replaces the token of a session if it is still the given one. It
+// reports whether the session was rotated, which fails when another request
+// rotated it in the meantime.
+func (s *LoginSessionStore) Rotate(sessionID int64, oldTokenID string, newTokenID string, usedAt time.Time, expiresAt time.Time) (bool, error) {
+	res, err := s.db.Exec(`
+UPDATE
+  login_sessions
+SET
+  token_id = $3, last_used_at = $4, expires_at = $5, updated_at = $4
+WHERE
+  id = $1
+AND
+  token_id = $2;`, sessionID, oldTokenID, newTokenID, usedAt, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows == 1, err
+}
+
+// Touch records the last usage of a session.
+func (s *LoginSessionStore) Touch(sessionID int64, usedAt time.Time) error {
+	_, err := s.db.Exec("UPDATE login_sessions SET last_used_at = $2 WHERE id = $1;", sessionID, usedAt)
+	return err
+}
+
+func (s *LoginSessionStore) Delete(sessionID int64) error {
+	return Delete(s.db, "login_sessions", sessionID)
+}
+
+// DeleteAllOfUser revokes all sessions of a user except the given one, which
+// can be 0 to revoke every session.
+func (s *LoginSessionStore) DeleteAllOfUser(userID int64, exceptSessionID int64) error {
+	_, err := s.db.Exec("DELETE FROM login_sessions WHERE user_id = $1 AND id <> $2;", userID, exceptSessionID)
+	return err
+}
+
+// DeleteExpired removes sessions which expired before the given time.
+func (s *LoginSessionStore) DeleteExpired(now time.Time) error {
+	_, err := s.db.Exec("DELETE FROM login_sessions WHERE expires_at <= $1;", now)
+	return err
+}
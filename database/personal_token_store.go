@@ -0,0 +1,81 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type PersonalTokenStore struct {
+	db *sqlx.DB
+}
+
+func NewPersonalTokenStore(db *sqlx.DB) *PersonalTokenStore {
+	return &PersonalTokenStore{
+		db: db,
+	}
+}
+
+func (s *PersonalTokenStore) Get(tokenID int64) (*model.PersonalToken, error) {
+	p := model.PersonalToken{ID: tokenID}
+	err := s.db.Get(&p, "SELECT * FROM personal_tokens WHERE id = $1 LIMIT 1;", p.ID)
+	return &p, err
+}
+
+// TokensOfUser returns all tokens of a user including expired ones.
+func (s *PersonalTokenStore) TokensOfUser(userID int64) ([]model.PersonalToken, error) {
+	p := []model.PersonalToken{}
+	err := s.db.Select(&p, "SELECT * FROM personal_tokens WHERE user_id = $1 ORDER BY id ASC;", userID)
+	return p, err
+}
+
+// FindByHash returns the token with the given sha256 hash.
+func (s *PersonalTokenStore) FindByHash(tokenHash string) (*model.PersonalToken, error) {
+	p := model.PersonalToken{}
+	err := s.db.Get(&p, "SELECT * FROM personal_tokens WHERE token_hash = $1 LIMIT 1;", tokenHash)
+	return &p, err
+}
+
+func (s *PersonalTokenStore) Create(p *model.PersonalToken) (*model.PersonalToken, error) {
+	newID, err := Insert(s.db, "personal_tokens", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+func (s *PersonalTokenStore) Delete(tokenID int64) error {
+	return Delete(s.db, "personal_tokens", tokenID)
+}
+
+// DeleteAllOfUser revokes all tokens of a user.
+func (s *PersonalTokenStore) DeleteAllOfUser(userID int64) error {
+	_, err := s.db.Exec("DELETE FROM personal_tokens WHERE user_id = $1;", userID)
+	return err
+}
+
+// Touch records the last usage of a token.
+func (s *PersonalTokenStore) Touch(tokenID int64, usedAt time.Time) error {
+	_, err := s.db.Exec("UPDATE personal_tokens SET last_used_at = $2 WHERE id = $1;", tokenID, usedAt)
+	return err
+}
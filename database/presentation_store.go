@@ -0,0 +1,110 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"github.com/infomark-org/infomark/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type PresentationStore struct {
+	db *sqlx.DB
+}
+
+func NewPresentationStore(db *sqlx.DB) *PresentationStore {
+	return &PresentationStore{
+		db: db,
+	}
+}
+
+func (s *PresentationStore) Get(presentationID int64) (*model.Presentation, error) {
+	p := model.Presentation{ID: presentationID}
+	err := s.db.Get(&p, `
+SELECT
+  p.*,
+  u.first_name,
+  u.last_name,
+  u.email,
+  t.name task_name
+FROM
+  presentations p
+INNER JOIN users u ON u.id = p.user_id
+INNER JOIN tasks t ON t.id = p.task_id
+WHERE
+  p.id = $1
+LIMIT 1`, p.ID)
+	return &p, err
+}
+
+func (s *PresentationStore) PresentationsOfGroup(groupID int64) ([]model.Presentation, error) {
+	p := []model.Presentation{}
+	err := s.db.Select(&p, `
+SELECT
+  p.*,
+  u.first_name,
+  u.last_name,
+  u.email,
+  t.name task_name
+FROM
+  presentations p
+INNER JOIN users u ON u.id = p.user_id
+INNER JOIN tasks t ON t.id = p.task_id
+WHERE
+  p.group_id = $1
+ORDER BY
+  p.presented_at ASC, p.id ASC`, groupID)
+	return p, err
+}
+
+func (s *PresentationStore) Create(p *model.Presentation) (*model.Presentation, error) {
+	newID, err := Insert(s.db, "presentations", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+func (s *PresentationStore) Update(p *model.Presentation) error {
+	return Update(s.db, "presentations", p.ID, p)
+}
+
+func (s *PresentationStore) Delete(presentationID int64) error {
+	return Delete(s.db, "presentations", presentationID)
+}
+
+// CountsOfCourse returns the number of presentations of each student who
+// presented at least once in any group of the course.
+func (s *PresentationStore) CountsOfCourse(courseID int64) ([]model.PresentationCount, error) {
+	p := []model.PresentationCount{}
+	err := s.db.Select(&p, `
+SELECT
+  p.user_id,
+  count(*) count
+FROM
+  presentations p
+INNER JOIN groups g ON g.id = p.group_id
+WHERE
+  g.course_id = $1
+GROUP BY
+  p.user_id
+ORDER BY
+  p.user_id`, courseID)
+	return p, err
+}
@@ -70,12 +70,14 @@ func (s *SubmissionStore) GetFiltered(filterCourseID, filterGroupID, filterUserI
 	err := s.db.Select(&p,
 		`
 SELECT
-  s.*
+  s.*,
+  sh.anonymous sheet_anonymous
 FROM
   submissions s
 INNER JOIN user_group ug ON ug.user_id = s.user_id
 INNER JOIN groups g ON g.id = ug.group_id
 INNEr JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheets sh ON sh.id = ts.sheet_id
 WHERE
   ($1 = 0 or s.user_id = $1)
 AND
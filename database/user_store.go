@@ -46,6 +46,27 @@ func (s *UserStore) FindByEmail(email string) (*model.User, error) {
 	return &p, err
 }
 
+// FindByOIDCSubject returns the user linked to a single sign-on identity.
+func (s *UserStore) FindByOIDCSubject(subject string) (*model.User, error) {
+	p := model.User{}
+	err := s.db.Get(&p, "SELECT * FROM users WHERE oidc_subject = $1 LIMIT 1", subject)
+	return &p, err
+}
+
+// FindByCalendarTokenHash returns the user owning a calendar feed.
+func (s *UserStore) FindByCalendarTokenHash(hash string) (*model.User, error) {
+	p := model.User{}
+	err := s.db.Get(&p, "SELECT * FROM users WHERE calendar_token_hash = $1 LIMIT 1", hash)
+	return &p, err
+}
+
+// FindByStudentNumber returns all users with the given student number.
+func (s *UserStore) FindByStudentNumber(studentNumber string) ([]model.User, error) {
+	p := []model.User{}
+	err := s.db.Select(&p, "SELECT * FROM users WHERE student_number = $1", studentNumber)
+	return p, err
+}
+
 func (s *UserStore) Find(query string) ([]model.User, error) {
 	p := []model.User{}
 	err := s.db.Select(&p, `
@@ -80,6 +101,12 @@ func (s *UserStore) Update(p *model.User) error {
 	return Update(s.db, "users", p.ID, p)
 }
 
+// UpdatePasswordHash replaces the password hash of a user only.
+func (s *UserStore) UpdatePasswordHash(userID int64, encryptedPassword string) error {
+	_, err := s.db.Exec(`UPDATE users SET encrypted_password = $2 WHERE id = $1`, userID, encryptedPassword)
+	return err
+}
+
 func (s *UserStore) Delete(userID int64) error {
 	return Delete(s.db, "users", userID)
 }
@@ -98,3 +125,67 @@ WHERE
 	return p, err
 
 }
+
+// GetRecoveryCodes returns the unused recovery codes of a user.
+func (s *UserStore) GetRecoveryCodes(userID int64) ([]model.RecoveryCode, error) {
+	p := []model.RecoveryCode{}
+	err := s.db.Select(&p, "SELECT * FROM recovery_codes WHERE user_id = $1 ORDER BY id ASC", userID)
+	return p, err
+}
+
+// ReplaceRecoveryCodes removes all recovery codes of a user and stores the
+// given hashes instead.
+func (s *UserStore) ReplaceRecoveryCodes(userID int64, encryptedCodes []string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, encryptedCode := range encryptedCodes {
+		if _, err := tx.Exec(`
+INSERT INTO recovery_codes
+  (user_id, encrypted_code)
+VALUES
+  ($1, $2)`, userID, encryptedCode); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep records the time step of an accepted code. It reports false if
+// the same or a later step has been used before, e.g. by a concurrent login.
+func (s *UserStore) UseTOTPStep(userID int64, step int64) (bool, error) {
+	res, err := s.db.Exec(`
+UPDATE
+  users
+SET
+  totp_last_step = $2
+WHERE
+  id = $1
+AND
+  totp_last_step < $2;`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows == 1, err
+}
+
+// UseRecoveryCode removes a recovery code. It reports false if the code has
+// already been used, e.g. by a concurrent login.
+func (s *UserStore) UseRecoveryCode(codeID int64) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM recovery_codes WHERE id = $1;`, codeID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows == 1, err
+}
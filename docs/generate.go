@@ -158,6 +158,10 @@ func main() {
 	f.WriteString("    description: Gradings related requests\n")
 	f.WriteString("  - name: groups\n")
 	f.WriteString("    description: Exercise groups related requests\n")
+	f.WriteString("  - name: attendances\n")
+	f.WriteString("    description: Attendance at group sessions related requests\n")
+	f.WriteString("  - name: presentations\n")
+	f.WriteString("    description: Presentations of solutions in groups related requests\n")
 	f.WriteString("  - name: enrollments\n")
 	f.WriteString("    description: Enrollments related requests\n")
 	f.WriteString("  - name: materials\n")
@@ -205,6 +209,22 @@ func main() {
 	f.WriteString("          schema:\n")
 	f.WriteString("            type: string\n")
 	f.WriteString("            format: binary\n")
+	f.WriteString("    SpreadsheetFile:\n")
+	f.WriteString("      description: A spreadsheet as a download.\n")
+	f.WriteString("      content:\n")
+	f.WriteString("        text/csv:\n")
+	f.WriteString("          schema:\n")
+	f.WriteString("            type: string\n")
+	f.WriteString("        application/vnd.openxmlformats-officedocument.spreadsheetml.sheet:\n")
+	f.WriteString("          schema:\n")
+	f.WriteString("            type: string\n")
+	f.WriteString("            format: binary\n")
+	f.WriteString("    CalendarFile:\n")
+	f.WriteString("      description: An iCalendar feed.\n")
+	f.WriteString("      content:\n")
+	f.WriteString("        text/calendar:\n")
+	f.WriteString("          schema:\n")
+	f.WriteString("            type: string\n")
 	f.WriteString("    ImageFile:\n")
 	f.WriteString("      description: A file as a download.\n")
 	f.WriteString("      content:\n")
@@ -214,6 +234,8 @@ func main() {
 	f.WriteString("            format: binary\n")
 	f.WriteString("    OK:\n")
 	f.WriteString("      description: Post successfully delivered.\n")
+	f.WriteString("    Redirect:\n")
+	f.WriteString("      description: Redirect to the location given in the header.\n")
 	f.WriteString("    NoContent:\n")
 	f.WriteString("      description: Update was successful.\n")
 	f.WriteString("    BadRequest:\n")
@@ -357,6 +379,18 @@ func main() {
 					f.WriteString("            encoding:\n")
 					f.WriteString("              file_data:\n")
 					f.WriteString("                contentType: image/jpeg\n")
+				case "csvfile":
+					f.WriteString("        content:\n")
+					f.WriteString("          multipart/form-data:\n")
+					f.WriteString("            schema:\n")
+					f.WriteString("              type: object\n")
+					f.WriteString("              properties:\n")
+					f.WriteString("                file_data:\n")
+					f.WriteString("                  type: string\n")
+					f.WriteString("                  format: binary\n")
+					f.WriteString("            encoding:\n")
+					f.WriteString("              file_data:\n")
+					f.WriteString("                contentType: text/csv\n")
 				case "empty":
 
 				default:
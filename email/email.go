@@ -219,8 +219,32 @@ If you have not requested the change, you can ignore this mail.
 
 Your password can only be changed manually by you.
 
+`
+
+	inviteUserTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+An account has been created for you to take part in the course {{.course_name}}.
+
+Please choose your password using the following link.
+
+{{.reset_password_url}}/{{.email_address}}/{{.reset_password_token}}
+
+`
+
+	accountLockedTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+There were {{.failed_logins}} failed attempts to log into your account, the last one from {{.address}}.
+To protect your account, logins are blocked until {{.locked_until}}.
+
+If this was not you, someone might try to guess your password. Please consider choosing
+a stronger password using the following link.
+
+{{.reset_password_url}}
+
 `
 )
 
 var ConfirmEmailTemplateEN *template.Template = template.Must(template.New("confirmEmailTemplateSrcEN").Parse(confirmEmailTemplateSrcEN))
 var RequestPasswordTokenTemailTemplateEN *template.Template = template.Must(template.New("requestPasswordTokenTemailTemplateSrcEN").Parse(requestPasswordTokenTemailTemplateSrcEN))
+var InviteUserTemplateEN *template.Template = template.Must(template.New("inviteUserTemplateSrcEN").Parse(inviteUserTemplateSrcEN))
+var AccountLockedTemplateEN *template.Template = template.Must(template.New("accountLockedTemplateSrcEN").Parse(accountLockedTemplateSrcEN))
@@ -8,14 +8,16 @@ require (
 	github.com/davecgh/go-spew v1.1.1
 	github.com/docker/docker v24.0.9+incompatible
 	github.com/franela/goblin v0.0.0-20211003143422-0a4f594942bf
+	github.com/go-asn1-ber/asn1-ber v1.5.5
 	github.com/go-chi/chi/v5 v5.2.2
 	github.com/go-chi/cors v1.2.1
 	github.com/go-chi/jwtauth/v5 v5.1.0
 	github.com/go-chi/render v1.0.2
+	github.com/go-ldap/ldap/v3 v3.4.6
 	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
 	github.com/golang-jwt/jwt/v4 v4.5.2
 	github.com/golang-migrate/migrate/v4 v4.14.1
-	github.com/google/uuid v1.3.0
+	github.com/google/uuid v1.3.1
 	// Cannot be changed to v1.3 as this breaks the JOIN
 	// https://github.com/jmoiron/sqlx/issues/755
 	// https://github.com/jmoiron/sqlx/pull/754
@@ -36,6 +38,7 @@ require (
 )
 
 require (
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
 	github.com/Microsoft/go-winio v0.6.0 // indirect
 	github.com/ajg/form v1.5.1 // indirect
 	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
@@ -0,0 +1,21 @@
+BEGIN;
+-- minimal number of solutions a student has to present in the group
+ALTER TABLE courses ADD COLUMN required_presentations INT not null DEFAULT 0;
+
+-- a student presented the solution of a task in the group ("Vorrechnen")
+CREATE TABLE IF NOT EXISTS presentations(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  group_id INT not null,
+  task_id INT not null,
+  user_id INT not null,
+  presented_at TIMESTAMP not null,
+  score INT NULL,
+
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  FOREIGN KEY (task_id)  REFERENCES tasks (id)  ON DELETE CASCADE,
+  FOREIGN KEY (user_id)  REFERENCES users (id)  ON DELETE CASCADE
+);
+COMMIT;
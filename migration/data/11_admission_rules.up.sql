@@ -0,0 +1,10 @@
+BEGIN;
+-- number of sheets with the lowest percentage which do not count for admission
+ALTER TABLE courses ADD COLUMN droppable_sheets INT not null DEFAULT 0;
+
+-- minimal percentage of the points of a single sheet required for admission
+ALTER TABLE sheets ADD COLUMN required_percentage INT not null DEFAULT 0;
+
+-- passing this exam is required for admission, it does not require admission itself
+ALTER TABLE exams ADD COLUMN admission_prerequisite BOOLEAN not null DEFAULT false;
+COMMIT;
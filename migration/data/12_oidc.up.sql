@@ -0,0 +1,4 @@
+BEGIN;
+-- subject identifier of the account at the single sign-on identity provider
+ALTER TABLE users ADD COLUMN oidc_subject TEXT NULL DEFAULT NULL UNIQUE;
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+-- base32 secret of the authenticator app, enabled after the first valid code
+ALTER TABLE users ADD COLUMN totp_secret TEXT NULL DEFAULT NULL;
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN not null DEFAULT false;
+-- last accepted time step, such that each code can be used only once
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT not null DEFAULT 0;
+
+-- single-use codes to log in when the authenticator app is lost
+CREATE TABLE IF NOT EXISTS recovery_codes(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  user_id INT not null,
+  encrypted_code TEXT not null,
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+-- long-lived tokens for scripts, only the sha256 hash of the token is stored
+CREATE TABLE IF NOT EXISTS personal_tokens(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  user_id INT not null,
+  name TEXT not null,
+  token_hash TEXT not null UNIQUE,
+  -- one of "read", "grading", "course_admin"
+  scope TEXT not null,
+  -- restricts the token to a single course, required for "course_admin"
+  course_id INT NULL,
+  expires_at TIMESTAMP NULL,
+  last_used_at TIMESTAMP NULL,
+
+  FOREIGN KEY (user_id)   REFERENCES users (id)   ON DELETE CASCADE,
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+-- logins tracked server-side to revoke them, a session is either a cookie or
+-- a chain of refresh tokens
+CREATE TABLE IF NOT EXISTS login_sessions(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  user_id INT not null,
+  -- one of "cookie", "refresh", "impersonation"
+  kind TEXT not null,
+  -- id of the only refresh token of the session which is still valid
+  token_id TEXT not null,
+  user_agent TEXT not null DEFAULT '',
+  address TEXT not null DEFAULT '',
+  last_used_at TIMESTAMP not null DEFAULT current_timestamp,
+  expires_at TIMESTAMP not null,
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+COMMIT;
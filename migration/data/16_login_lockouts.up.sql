@@ -0,0 +1,12 @@
+BEGIN;
+-- failed logins per account to slow down and lock out password guessing
+CREATE TABLE IF NOT EXISTS login_lockouts(
+  user_id INT not null primary key,
+  failed_logins INT not null DEFAULT 0,
+  last_failed_at TIMESTAMP not null DEFAULT current_timestamp,
+  last_failed_address TEXT not null DEFAULT '',
+  locked_until TIMESTAMP NULL,
+
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+-- secret of the calendar feed url, only the sha256 hash of the token is stored
+ALTER TABLE users ADD COLUMN calendar_token_hash TEXT NULL DEFAULT NULL UNIQUE;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+-- tutor who has to grade this submission, NULL means the tutor of the group
+ALTER TABLE grades ADD COLUMN assigned_tutor_id INT NULL;
+ALTER TABLE grades ADD FOREIGN KEY (assigned_tutor_id) REFERENCES users (id) ON DELETE SET NULL;
+-- ungraded submissions in the pool can be claimed by any tutor of the course
+ALTER TABLE grades ADD COLUMN pooled BOOLEAN not null DEFAULT false;
+COMMIT;
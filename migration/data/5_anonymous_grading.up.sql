@@ -0,0 +1,4 @@
+BEGIN;
+-- hide the identity of students from tutors while grading this sheet
+ALTER TABLE sheets ADD COLUMN anonymous BOOLEAN not null DEFAULT false;
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+-- weekly meetings of exercise groups
+CREATE TABLE IF NOT EXISTS group_slots(
+  id SERIAL not null primary key,
+  group_id INT not null,
+
+  -- 0: sunday, 1: monday, ..., 6: saturday
+  weekday INT not null,
+  start_time TIME not null,
+  end_time TIME not null,
+  location TEXT not null DEFAULT '',
+  online_url TEXT not null DEFAULT '',
+
+  CHECK (weekday >= 0 AND weekday <= 6),
+  CHECK (start_time < end_time),
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,39 @@
+BEGIN;
+-- maximal number of students in a group, 0 means unlimited
+ALTER TABLE groups ADD COLUMN capacity INT not null DEFAULT 0;
+
+-- 0: only admins enroll students into groups
+-- 1: students enroll themselves, first come first served
+-- 2: students enroll themselves, full groups have a waitlist
+ALTER TABLE courses ADD COLUMN group_enrollment_mode INT not null DEFAULT 0;
+ALTER TABLE courses ADD COLUMN group_enrollment_begins_at TIMESTAMP NULL;
+ALTER TABLE courses ADD COLUMN group_enrollment_ends_at TIMESTAMP NULL;
+
+-- students waiting for a seat in a full group
+CREATE TABLE IF NOT EXISTS group_waitlist(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  user_id INT not null,
+  group_id INT not null,
+
+  UNIQUE (user_id, group_id),
+  FOREIGN KEY (user_id)  REFERENCES users (id)  ON DELETE CASCADE,
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+-- a student asks to swap the group with another student of the same course
+CREATE TABLE IF NOT EXISTS group_swaps(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  user_id INT not null,
+  partner_id INT not null,
+
+  UNIQUE (course_id, user_id),
+  FOREIGN KEY (course_id)  REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id)    REFERENCES users (id)   ON DELETE CASCADE,
+  FOREIGN KEY (partner_id) REFERENCES users (id)   ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+-- additional tutors of a group, the lead tutor remains groups.tutor_id
+CREATE TABLE IF NOT EXISTS group_tutors(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  group_id INT not null,
+  user_id INT not null,
+  -- 1: co-tutor, 2: substitute
+  role INT not null DEFAULT 1,
+  valid_from TIMESTAMP NULL,
+  valid_until TIMESTAMP NULL,
+
+  UNIQUE (group_id, user_id),
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id)  REFERENCES users (id)  ON DELETE CASCADE
+);
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+-- minimal percentage of the sessions of their group a student has to attend
+ALTER TABLE courses ADD COLUMN required_attendance INT not null DEFAULT 0;
+
+-- a single meeting of an exercise group
+CREATE TABLE IF NOT EXISTS group_sessions(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  group_id INT not null,
+  held_at TIMESTAMP not null,
+  description TEXT not null DEFAULT '',
+  -- short-lived code students enter to record their attendance
+  code TEXT not null DEFAULT '',
+  code_expires_at TIMESTAMP NULL,
+
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS attendances(
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  session_id INT not null,
+  user_id INT not null,
+
+  UNIQUE (session_id, user_id),
+  FOREIGN KEY (session_id) REFERENCES group_sessions (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id)    REFERENCES users (id)          ON DELETE CASCADE
+);
+COMMIT;
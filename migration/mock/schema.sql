@@ -7,6 +7,17 @@ DROP TABLE IF EXISTS user_group;
 DROP TABLE IF EXISTS sheet_course;
 DROP TABLE IF EXISTS task_sheet;
 DROP TABLE IF EXISTS group_bids;
+DROP TABLE IF EXISTS group_slots;
+DROP TABLE IF EXISTS group_waitlist;
+DROP TABLE IF EXISTS group_swaps;
+DROP TABLE IF EXISTS group_tutors;
+DROP TABLE IF EXISTS attendances;
+DROP TABLE IF EXISTS group_sessions;
+DROP TABLE IF EXISTS presentations;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS personal_tokens;
+DROP TABLE IF EXISTS login_sessions;
+DROP TABLE IF EXISTS login_lockouts;
 --  renamed to task_ratings
 -- DROP TABLE IF EXISTS task_feedbacks;
 DROP TABLE IF EXISTS task_ratings;
@@ -0,0 +1,63 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSession is a single meeting of an exercise group. While the code is not
+// expired, students can record their attendance by entering it.
+type GroupSession struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	GroupID       int64     `db:"group_id"`
+	HeldAt        time.Time `db:"held_at"`
+	Description   string    `db:"description"`
+	Code          string    `db:"code"`
+	CodeExpiresAt null.Time `db:"code_expires_at"`
+}
+
+// Attendance records that a student attended a group session.
+type Attendance struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+
+	SessionID int64 `db:"session_id"`
+	UserID    int64 `db:"user_id"`
+
+	FirstName     string `db:"first_name,readonly"`
+	LastName      string `db:"last_name,readonly"`
+	Email         string `db:"email,readonly"`
+	StudentNumber string `db:"student_number,readonly"`
+}
+
+// AttendanceSummary counts the past sessions of the group of a student and how
+// many of them the student attended.
+type AttendanceSummary struct {
+	UserID   int64 `db:"user_id"`
+	GroupID  int64 `db:"group_id"`
+	Sessions int   `db:"sessions"`
+	Attended int   `db:"attended"`
+}
@@ -21,6 +21,8 @@ package model
 
 import (
 	"time"
+
+	null "gopkg.in/guregu/null.v3"
 )
 
 // Course holds specific application settings linked to an entity, which
@@ -30,9 +32,16 @@ type Course struct {
 	CreatedAt time.Time `db:"created_at,omitempty"`
 	UpdatedAt time.Time `db:"updated_at,omitempty"`
 
-	Name               string    `db:"name"`
-	Description        string    `db:"description"`
-	BeginsAt           time.Time `db:"begins_at"`
-	EndsAt             time.Time `db:"ends_at"`
-	RequiredPercentage int       `db:"required_percentage"`
+	Name                  string    `db:"name"`
+	Description           string    `db:"description"`
+	BeginsAt              time.Time `db:"begins_at"`
+	EndsAt                time.Time `db:"ends_at"`
+	RequiredPercentage    int       `db:"required_percentage"`
+	RequiredAttendance    int       `db:"required_attendance"`
+	RequiredPresentations int       `db:"required_presentations"`
+	DroppableSheets       int       `db:"droppable_sheets"`
+
+	GroupEnrollmentMode     int       `db:"group_enrollment_mode"`
+	GroupEnrollmentBeginsAt null.Time `db:"group_enrollment_begins_at"`
+	GroupEnrollmentEndsAt   null.Time `db:"group_enrollment_ends_at"`
 }
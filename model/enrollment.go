@@ -44,3 +44,14 @@ type UserCourse struct {
 	Subject       string      `db:"subject"`
 	Language      string      `db:"language"`
 }
+
+// EnrollmentImportEntry is a line of an enrollment import to be written. Users
+// without an id are created.
+type EnrollmentImportEntry struct {
+	User    *User
+	OldRole int // -1 if the user is not enrolled yet
+	NewRole int
+	GroupID int64 // 0 to keep the group of the user
+	// Joined reports whether the user got a seat in the group.
+	Joined bool
+}
@@ -32,6 +32,9 @@ type Exam struct {
 	Description string    `db:"description"`
 	ExamTime    time.Time `db:"exam_time"`
 	CourseID    int64     `db:"course_id"`
+	// AdmissionPrerequisite exams have to be passed for admission to all other
+	// exams of the course.
+	AdmissionPrerequisite bool `db:"admission_prerequisite"`
 }
 
 // Enrollment represents a an enrollment-type of a given user
@@ -21,6 +21,8 @@ package model
 
 import (
 	"time"
+
+	null "gopkg.in/guregu/null.v3"
 )
 
 // -- 0: pending, 1: running, 2: finished
@@ -43,10 +45,18 @@ type Grade struct {
 	Feedback              string `db:"feedback"`
 	TutorID               int64  `db:"tutor_id"`
 	SubmissionID          int64  `db:"submission_id"`
-	UserID                int64  `db:"user_id,readonly"`
-	UserFirstName         string `db:"user_first_name,readonly"`
-	UserLastName          string `db:"user_last_name,readonly"`
-	UserEmail             string `db:"user_email,readonly"`
+	// AssignedTutorID is the tutor who has to grade the submission. If it is
+	// not set, the tutor of the group of the student is responsible.
+	AssignedTutorID null.Int `db:"assigned_tutor_id"`
+	// Pooled grades can be claimed by any tutor of the course.
+	Pooled        bool   `db:"pooled"`
+	UserID        int64  `db:"user_id,readonly"`
+	UserFirstName string `db:"user_first_name,readonly"`
+	UserLastName  string `db:"user_last_name,readonly"`
+	UserEmail     string `db:"user_email,readonly"`
+	// SheetAnonymous tells whether the identity of the student is hidden
+	// from tutors.
+	SheetAnonymous bool `db:"sheet_anonymous,readonly"`
 }
 
 // MissingGrade is a database view containing all grades which are finished
@@ -71,3 +81,38 @@ type OverviewGrade struct {
 	Name    string `db:"name"`
 	Points  int    `db:"points"`
 }
+
+// GradeStatisticEntry is a database view containing a single grade together
+// with its task, sheet, group and responsible tutor (0 if there is none) to
+// aggregate statistics.
+type GradeStatisticEntry struct {
+	GradeID               int64     `db:"grade_id"`
+	TaskID                int64     `db:"task_id"`
+	TaskName              string    `db:"task_name"`
+	MaxPoints             int       `db:"max_points"`
+	SheetID               int64     `db:"sheet_id"`
+	SheetName             string    `db:"sheet_name"`
+	SheetDueAt            time.Time `db:"sheet_due_at"`
+	GroupID               int64     `db:"group_id"`
+	TutorID               int64     `db:"tutor_id"`
+	TutorFirstName        string    `db:"tutor_first_name"`
+	TutorLastName         string    `db:"tutor_last_name"`
+	AcquiredPoints        int       `db:"acquired_points"`
+	Feedback              string    `db:"feedback"`
+	PublicExecutionState  int       `db:"public_execution_state"`
+	PrivateExecutionState int       `db:"private_execution_state"`
+	PublicTestStatus      int       `db:"public_test_status"`
+	PrivateTestStatus     int       `db:"private_test_status"`
+	UpdatedAt             time.Time `db:"updated_at"`
+}
+
+// TutorWorkload is a database view containing the number of ungraded
+// submissions a tutor is responsible for and the number of graded ones.
+type TutorWorkload struct {
+	TutorID   int64  `db:"tutor_id"`
+	FirstName string `db:"first_name"`
+	LastName  string `db:"last_name"`
+	Email     string `db:"email"`
+	Open      int    `db:"open"`
+	Finished  int    `db:"finished"`
+}
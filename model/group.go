@@ -34,6 +34,7 @@ type Group struct {
 	TutorID     int64  `db:"tutor_id"`
 	CourseID    int64  `db:"course_id"`
 	Description string `db:"description"`
+	Capacity    int    `db:"capacity"`
 }
 
 // GroupEnrollment is a database view for an enrollment of a student into a group.
@@ -54,4 +55,58 @@ type GroupWithTutor struct {
 	TutorAvatarURL null.String `db:"tutor_avatar_url"`
 	TutorEmail     string      `db:"tutor_email"`
 	TutorLanguage  string      `db:"tutor_language"`
+
+	Members int `db:"members"`
+}
+
+// GroupSlot is a database view for a weekly meeting of a group. Times are
+// given as "15:04" in the local time of the university.
+type GroupSlot struct {
+	ID      int64 `db:"id"`
+	GroupID int64 `db:"group_id"`
+
+	Weekday   int    `db:"weekday"`
+	StartTime string `db:"start_time"`
+	EndTime   string `db:"end_time"`
+	Location  string `db:"location"`
+	OnlineURL string `db:"online_url"`
+}
+
+// GroupJoin is the outcome of a student trying to join a group.
+type GroupJoin struct {
+	Enrolled   bool
+	Waitlisted bool
+	// Position on the waitlist starting at 1
+	Position int
+}
+
+// GroupSwap is a database view for the request of a student to swap the group
+// with another student of the same course.
+type GroupSwap struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+
+	CourseID  int64 `db:"course_id"`
+	UserID    int64 `db:"user_id"`
+	PartnerID int64 `db:"partner_id"`
+}
+
+// GroupTutor is a database view for a tutor of a group. The lead tutor is
+// stored in the group itself, co-tutors and substitutes are stored separately.
+// Substitutes are only tutors of the group within their validity dates.
+type GroupTutor struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	GroupID    int64     `db:"group_id"`
+	UserID     int64     `db:"user_id"`
+	Role       int       `db:"role"`
+	ValidFrom  null.Time `db:"valid_from"`
+	ValidUntil null.Time `db:"valid_until"`
+
+	FirstName string `db:"first_name,readonly"`
+	LastName  string `db:"last_name,readonly"`
+	Email     string `db:"email,readonly"`
+	Active    bool   `db:"active,readonly"`
 }
@@ -0,0 +1,39 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// LoginLockout counts the recent failed logins of an account.
+type LoginLockout struct {
+	UserID            int64     `db:"user_id"`
+	FailedLogins      int       `db:"failed_logins"`
+	LastFailedAt      time.Time `db:"last_failed_at"`
+	LastFailedAddress string    `db:"last_failed_address"`
+	LockedUntil       null.Time `db:"locked_until"`
+
+	FirstName string `db:"first_name"`
+	LastName  string `db:"last_name"`
+	Email     string `db:"email"`
+}
@@ -0,0 +1,51 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Presentation records that a student presented the solution of a task in the
+// group ("Vorrechnen"). The score is optional.
+type Presentation struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	GroupID     int64     `db:"group_id"`
+	TaskID      int64     `db:"task_id"`
+	UserID      int64     `db:"user_id"`
+	PresentedAt time.Time `db:"presented_at"`
+	Score       null.Int  `db:"score"`
+
+	FirstName string `db:"first_name,readonly"`
+	LastName  string `db:"last_name,readonly"`
+	Email     string `db:"email,readonly"`
+	TaskName  string `db:"task_name,readonly"`
+}
+
+// PresentationCount is the number of presentations of a student in a course.
+type PresentationCount struct {
+	UserID int64 `db:"user_id"`
+	Count  int   `db:"count"`
+}
@@ -33,6 +33,17 @@ type Sheet struct {
 	Name      string    `db:"name"`
 	PublishAt time.Time `db:"publish_at"`
 	DueAt     time.Time `db:"due_at"`
+	// Anonymous hides the identity of students from tutors.
+	Anonymous bool `db:"anonymous"`
+	// RequiredPercentage of the points of this sheet is required for admission.
+	RequiredPercentage int `db:"required_percentage"`
+}
+
+// UserSheetPoints are the points a student acquired in a sheet.
+type UserSheetPoints struct {
+	UserID         int64 `db:"user_id"`
+	SheetID        int64 `db:"sheet_id"`
+	AcquiredPoints int   `db:"acquired_points"`
 }
 
 // SheetPoints contains the performance of a specific student
@@ -32,4 +32,6 @@ type Submission struct {
 
 	UserID int64 `db:"user_id"`
 	TaskID int64 `db:"task_id"`
+
+	SheetAnonymous bool `db:"sheet_anonymous,readonly"`
 }
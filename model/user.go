@@ -45,6 +45,27 @@ type User struct {
 	ResetPasswordToken null.String `db:"reset_password_token"`
 	ConfirmEmailToken  null.String `db:"confirm_email_token"`
 	Root               bool        `db:"root"`
+	// OIDCSubject links the account to an identity of the single sign-on.
+	OIDCSubject null.String `db:"oidc_subject"`
+
+	// TOTPSecret is set during the enrollment of two-factor authentication,
+	// which is active once TOTPEnabled is true.
+	TOTPSecret   null.String `db:"totp_secret"`
+	TOTPEnabled  bool        `db:"totp_enabled"`
+	TOTPLastStep int64       `db:"totp_last_step"`
+
+	// CalendarTokenHash identifies the secret url of the calendar feed.
+	CalendarTokenHash null.String `db:"calendar_token_hash"`
+}
+
+// RecoveryCode is a hashed single-use code to pass the two-factor
+// authentication without the authenticator app.
+type RecoveryCode struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+
+	UserID        int64  `db:"user_id"`
+	EncryptedCode string `db:"encrypted_code"`
 }
 
 // FullName is a wrapper for returning the fullname of a user
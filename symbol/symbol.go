@@ -38,6 +38,8 @@ const (
 	CtxKeySheet        key = iota
 	CtxKeyGrade        key = iota
 	CtxKeyExam         key = iota
+	CtxKeyGroupSession key = iota
+	CtxKeyPresentation key = iota
 	// ...
 )
 